@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/darkphotonKN/fireplace/config"
 	"github.com/joho/godotenv"
@@ -20,12 +23,8 @@ func main() {
 		log.Println("No .env file found, using system environment variables")
 	}
 
-	// database setup
+	// database setup, closed by the server on shutdown
 	db := config.InitDB()
-	defer db.Close()
-
-	// router setup
-	router := config.SetupRouter(db)
 
 	defaultDevPort := ":8080"
 
@@ -34,6 +33,13 @@ func main() {
 		port = defaultDevPort
 	}
 
+	// shuts down gracefully on interrupt or termination
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// starts server and listen on port
-	router.Run(fmt.Sprintf(":%s", port)) // port = ":" + PORT
+	server := config.NewServer(db, fmt.Sprintf(":%s", port)) // port = ":" + PORT
+	if err := server.Run(ctx); err != nil {
+		log.Fatalf("Error when running server: %s", err.Error())
+	}
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/hmac"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/webhooks"
+)
+
+/**
+* A local stand-in for a webhook receiver, for trying out webhooks during development.
+* It prints every delivery, checks its signature when given the secret of the endpoint
+* and responds with the configured status, so that failed deliveries and their retries
+* can be tried out as well.
+*
+* The api must be started with WEBHOOKS_ALLOW_PRIVATE_ADDRESSES=true to reach it:
+*   go run ./cmd/webhook-standin -secret whsec_... -status 200
+* and the endpoint registered with the url http://localhost:9099/webhook
+**/
+func main() {
+	addr := flag.String("addr", ":9099", "address to listen on")
+	secret := flag.String("secret", os.Getenv("WEBHOOK_SECRET"), "secret of the endpoint, signatures are not checked without it")
+	status := flag.Int("status", http.StatusOK, "status to respond with")
+	tolerance := flag.Duration("tolerance", 5*time.Minute, "how old a signed timestamp may be")
+	flag.Parse()
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "could not read body", http.StatusBadRequest)
+			return
+		}
+
+		fmt.Printf("%s %s delivery %s\n", time.Now().Format(time.RFC3339), r.Header.Get(webhooks.HeaderEvent), r.Header.Get(webhooks.HeaderDelivery))
+		fmt.Printf("  %s\n", body)
+
+		if *secret != "" {
+			if err := verify(r, body, *secret, *tolerance); err != nil {
+				fmt.Printf("  signature invalid: %s\n", err.Error())
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			fmt.Printf("  signature valid\n")
+		}
+
+		w.WriteHeader(*status)
+		fmt.Fprintf(w, "received with status %d\n", *status)
+	})
+
+	fmt.Printf("Webhook stand-in listening on %s, responding with %d\n", *addr, *status)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+// checks the signature the way receivers are expected to
+func verify(r *http.Request, body []byte, secret string, tolerance time.Duration) error {
+	timestamp, err := strconv.ParseInt(r.Header.Get(webhooks.HeaderTimestamp), 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing or invalid %s header", webhooks.HeaderTimestamp)
+	}
+
+	if age := time.Since(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("timestamp is %s off", age.Round(time.Second))
+	}
+
+	signature, ok := strings.CutPrefix(r.Header.Get(webhooks.HeaderSignature), "sha256=")
+	if !ok {
+		return fmt.Errorf("missing or invalid %s header", webhooks.HeaderSignature)
+	}
+
+	expected := webhooks.Sign(secret, timestamp, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return fmt.Errorf("signature does not match")
+	}
+
+	return nil
+}
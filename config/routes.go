@@ -3,24 +3,39 @@ package config
 import (
 	// "context"
 	"fmt"
+	"log"
+	"os"
 	"time"
 
 	"github.com/darkphotonKN/fireplace/internal/ai"
+	"github.com/darkphotonKN/fireplace/internal/auth"
 	"github.com/darkphotonKN/fireplace/internal/checklistitems"
+	"github.com/darkphotonKN/fireplace/internal/completions"
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/deltasync"
 	"github.com/darkphotonKN/fireplace/internal/discovery"
+	"github.com/darkphotonKN/fireplace/internal/events"
+	"github.com/darkphotonKN/fireplace/internal/idempotency"
 	"github.com/darkphotonKN/fireplace/internal/insights"
 	"github.com/darkphotonKN/fireplace/internal/jobs"
+	"github.com/darkphotonKN/fireplace/internal/links"
+	"github.com/darkphotonKN/fireplace/internal/notifications"
 	"github.com/darkphotonKN/fireplace/internal/plans"
+	"github.com/darkphotonKN/fireplace/internal/realtime"
+	"github.com/darkphotonKN/fireplace/internal/search"
+	"github.com/darkphotonKN/fireplace/internal/timetracking"
 	"github.com/darkphotonKN/fireplace/internal/user"
+	"github.com/darkphotonKN/fireplace/internal/webhooks"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 )
 
 /**
-* Sets up API prefix route and all routers.
+* Sets up API prefix route and all routers, and starts the background jobs of the returned
+* manager along with the returned realtime hub.
 **/
-func SetupRouter(db *sqlx.DB) *gin.Engine {
+func SetupRouter(db *sqlx.DB) (*gin.Engine, *jobs.Manager, *realtime.Hub) {
 	router := gin.Default()
 
 	// NOTE: debugging middleware
@@ -33,8 +48,8 @@ func SetupRouter(db *sqlx.DB) *gin.Engine {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3010"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "If-Match", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", "ETag", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -47,6 +62,12 @@ func SetupRouter(db *sqlx.DB) *gin.Engine {
 	//
 	// go finder.FindResources(context.Background(), []concepts.Concept{})
 
+	// --- IDEMPOTENCY ---
+
+	// -- Idempotency Setup --
+	idempotencyRepo := idempotency.NewRepository(db)
+	idempotencyMiddleware := idempotency.NewMiddleware(idempotencyRepo)
+
 	// --- USER ---
 
 	// -- User Setup --
@@ -60,6 +81,7 @@ func SetupRouter(db *sqlx.DB) *gin.Engine {
 	userRoutes.GET("", userHandler.GetAll)
 	userRoutes.POST("/signup", userHandler.Create)
 	userRoutes.POST("/signin", userHandler.Login)
+	userRoutes.PATCH("/:id/settings", userHandler.UpdateSettings)
 
 	// --- Plan Routes ---
 
@@ -72,16 +94,89 @@ func SetupRouter(db *sqlx.DB) *gin.Engine {
 	planRoutes := api.Group("/plans")
 	planRoutes.GET("/:id", planHandler.GetById)
 	planRoutes.GET("", planHandler.GetAll)
-	planRoutes.POST("", planHandler.Create)
+	planRoutes.POST("", idempotencyMiddleware.Handle(), planHandler.Create)
 	planRoutes.PATCH("/:id", planHandler.Update)
 	planRoutes.PATCH("/:id/toggle-daily-reset", planHandler.ToggleDailyReset)
+	planRoutes.PUT("/:id/archive-policy", planHandler.UpdateArchivePolicy)
 	planRoutes.DELETE("/:id", planHandler.Delete)
 
+	// --- COMPLETIONS ---
+
+	// -- Completions Setup --
+	completionRepo := completions.NewRepository(db)
+	completionService := completions.NewService(completionRepo)
+	completionHandler := completions.NewHandler(completionService)
+
+	// -- Completions Routes --
+	completionRoutes := api.Group("/plans/:id/completions")
+	completionRoutes.GET("/stats", completionHandler.GetPlanStats)
+	completionRoutes.GET("/heatmap", completionHandler.GetHeatmap)
+
+	// --- EVENTS ---
+
+	// -- Events Setup --
+	eventRepo := events.NewRepository(db)
+	eventDispatcher := events.NewDispatcher(eventRepo)
+
+	// --- REALTIME ---
+
+	// -- Realtime Setup --
+	realtimeRepo := realtime.NewRepository(db)
+	realtimeHub := realtime.NewHub(realtimeRepo)
+	realtimeHandler := realtime.NewHandler(realtimeHub)
+	realtimeHub.Start()
+
+	// -- Realtime Routes --
+	realtimeRoutes := api.Group("/realtime")
+	realtimeRoutes.POST("/tickets", realtimeHandler.IssueTicket)
+	realtimeRoutes.GET("/stream", auth.RequireStreamTicket(), realtimeHandler.Stream)
+
+	// --- WEBHOOKS ---
+
+	// -- Webhooks Setup --
+	webhookRepo := webhooks.NewRepository(db)
+	// endpoints on localhost or private networks are only reached when allowed, e.g. for a local stand-in
+	webhookSender := webhooks.NewSender(os.Getenv("WEBHOOKS_ALLOW_PRIVATE_ADDRESSES") == "true")
+	webhookService := webhooks.NewService(webhookRepo, webhookSender)
+	webhookHandler := webhooks.NewHandler(webhookService)
+	eventDispatcher.Subscribe(webhooks.NewSubscriber(webhookRepo))
+
+	// -- Webhooks Routes --
+	webhookRoutes := api.Group("/webhooks")
+	webhookRoutes.GET("", webhookHandler.GetEndpoints)
+	webhookRoutes.POST("", webhookHandler.CreateEndpoint)
+	webhookRoutes.GET("/:id", webhookHandler.GetEndpoint)
+	webhookRoutes.PATCH("/:id", webhookHandler.UpdateEndpoint)
+	webhookRoutes.DELETE("/:id", webhookHandler.DeleteEndpoint)
+	webhookRoutes.POST("/:id/rotate-secret", webhookHandler.RotateSecret)
+	webhookRoutes.POST("/:id/ping", webhookHandler.Ping)
+	webhookRoutes.GET("/:id/deliveries", webhookHandler.GetDeliveries)
+
+	// --- NOTIFICATIONS ---
+
+	// -- Notifications Setup --
+	notificationRepo := notifications.NewRepository(db)
+	notificationService := notifications.NewService(notificationRepo)
+	notificationHandler := notifications.NewHandler(notificationService)
+	notificationDispatcher := notifications.NewDispatcher(notificationRepo, map[constants.NotificationChannel]notifications.Notifier{
+		constants.ChannelInApp:   notifications.NewInboxNotifier(notificationRepo),
+		constants.ChannelEmail:   notifications.NewEmailNotifier(),
+		constants.ChannelWebhook: notifications.NewWebhookNotifier(webhookService),
+	})
+
+	// -- Notifications Routes --
+	notificationRoutes := api.Group("/notifications")
+	notificationRoutes.GET("", notificationHandler.GetInbox)
+	notificationRoutes.POST("/read-all", notificationHandler.MarkAllRead)
+	notificationRoutes.PATCH("/:id/read", notificationHandler.MarkRead)
+	notificationRoutes.GET("/preferences", notificationHandler.GetPreferences)
+	notificationRoutes.PUT("/preferences", notificationHandler.UpdatePreferences)
+
 	// --- CHECKLIST ---
 
 	// -- Checklist Setup --
 	checkListRepo := checklistitems.NewRepository(db)
-	checkListService := checklistitems.NewService(checkListRepo)
+	checkListService := checklistitems.NewService(checkListRepo, notificationDispatcher)
 	checkListHandler := checklistitems.NewHandler(checkListService)
 
 	// -- Checklist Plan-Specific Routes --
@@ -91,11 +186,75 @@ func SetupRouter(db *sqlx.DB) *gin.Engine {
 	checkListRoutes.GET("/archived", checkListHandler.GetAllArchived)
 	checkListRoutes.GET("/upcoming", checkListHandler.GetUpcoming)
 	checkListRoutes.GET("/:checklist_id", checkListHandler.GetByID)
-	checkListRoutes.POST("", checkListHandler.Create)
+	checkListRoutes.POST("", idempotencyMiddleware.Handle(), checkListHandler.Create)
+	checkListRoutes.POST("/move", checkListHandler.MoveToPlan)
+	checkListRoutes.POST("/copy", checkListHandler.CopyToPlan)
+	checkListRoutes.POST("/auto-archive", checkListHandler.AutoArchive)
 	checkListRoutes.PATCH("/:checklist_id", checkListHandler.Update)
 	checkListRoutes.DELETE("/:checklist_id", checkListHandler.Delete)
 	checkListRoutes.PATCH("/:checklist_id/schedule", checkListHandler.SetSchedule)
 	checkListRoutes.PATCH("/:checklist_id/archive", checkListHandler.Archive)
+	checkListRoutes.PATCH("/:checklist_id/unarchive", checkListHandler.Unarchive)
+	checkListRoutes.GET("/:checklist_id/stats", completionHandler.GetItemStats)
+
+	// -- Checklist Operation Routes --
+	operationRoutes := api.Group("/checklist-operations")
+	operationRoutes.GET("", checkListHandler.GetOperations)
+	operationRoutes.POST("/:token/undo", checkListHandler.Undo)
+
+	// --- SYNC ---
+
+	// -- Sync Setup --
+	syncRepo := deltasync.NewRepository(db)
+	syncService := deltasync.NewService(syncRepo, planService, checkListService)
+	syncHandler := deltasync.NewHandler(syncService)
+
+	// -- Sync Routes --
+	syncRoutes := api.Group("/sync")
+	syncRoutes.GET("/changes", syncHandler.GetChanges)
+	syncRoutes.POST("/push", idempotencyMiddleware.Handle(), syncHandler.Push)
+
+	// --- LINKS ---
+
+	// -- Links Setup --
+	linkRepo := links.NewRepository(db)
+	linkService := links.NewService(linkRepo, discovery.NewMetadataFetcher())
+	linkHandler := links.NewHandler(linkService)
+
+	// -- Links Routes --
+	checkListRoutes.GET("/:checklist_id/links", linkHandler.GetAll)
+	checkListRoutes.POST("/:checklist_id/links", linkHandler.Create)
+	checkListRoutes.POST("/:checklist_id/links/:link_id/refresh", linkHandler.RefreshMetadata)
+	checkListRoutes.DELETE("/:checklist_id/links/:link_id", linkHandler.Delete)
+
+	// --- TIME TRACKING ---
+
+	// -- Time Tracking Setup --
+	timeTrackingRepo := timetracking.NewRepository(db)
+	timeTrackingService := timetracking.NewService(timeTrackingRepo)
+	timeTrackingHandler := timetracking.NewHandler(timeTrackingService)
+
+	// -- Time Tracking Routes --
+	checkListRoutes.POST("/:checklist_id/timer/start", timeTrackingHandler.StartTimer)
+	checkListRoutes.POST("/:checklist_id/pomodoro", timeTrackingHandler.StartPomodoro)
+	checkListRoutes.GET("/:checklist_id/time-entries", timeTrackingHandler.GetAllByItem)
+	checkListRoutes.POST("/:checklist_id/time-entries", timeTrackingHandler.CreateManualEntry)
+	checkListRoutes.DELETE("/:checklist_id/time-entries/:entry_id", timeTrackingHandler.Delete)
+	planRoutes.GET("/:id/time-totals", timeTrackingHandler.GetPlanTotals)
+
+	timerRoutes := api.Group("/timer")
+	timerRoutes.GET("", timeTrackingHandler.GetRunning)
+	timerRoutes.POST("/stop", timeTrackingHandler.StopTimer)
+
+	// --- SEARCH ---
+
+	// -- Search Setup --
+	searchRepo := search.NewRepository(db)
+	searchService := search.NewService(searchRepo)
+	searchHandler := search.NewHandler(searchService)
+
+	// -- Search Routes --
+	api.GET("/search", searchHandler.Search)
 
 	// --- INSIGHTS ---
 
@@ -124,14 +283,47 @@ func SetupRouter(db *sqlx.DB) *gin.Engine {
 	insightsRoutes.GET("/suggest-videos", videoInsightsHandler.GenerateSuggestedVideoLinks)
 
 	// --- JOBS ---
-	// TODO: write a job manager for graceful shutdown
 	dailyJob := jobs.NewDailyResetJob(checkListService)
 	scheduledItemsJob := jobs.NewScheduledItemsJob(checkListService)
+	autoArchiveJob := jobs.NewAutoArchiveJob(checkListService)
+	idempotencyCleanupJob := jobs.NewIdempotencyCleanupJob(idempotencyMiddleware)
+	undoPurgeJob := jobs.NewUndoPurgeJob(checkListService)
+	outboxDispatchJob := jobs.NewOutboxDispatchJob(eventDispatcher)
+	webhookDeliveryJob := jobs.NewWebhookDeliveryJob(webhookService)
+	syncTombstonePurgeJob := jobs.NewSyncTombstonePurgeJob(syncService)
+	outboxPurgeJob := jobs.NewOutboxPurgeJob(eventDispatcher)
 
-	jobManager := jobs.NewManager()
+	jobRepo := jobs.NewRepository(db)
+	jobManager := jobs.NewManager(jobRepo, jobs.SystemClock, os.Getenv)
 	jobManager.AddJob(dailyJob)
 	jobManager.AddJob(scheduledItemsJob)
-	jobManager.StartAll()
+	jobManager.AddJob(autoArchiveJob)
+	jobManager.AddJob(idempotencyCleanupJob)
+	jobManager.AddJob(undoPurgeJob)
+	jobManager.AddJob(outboxDispatchJob)
+	jobManager.AddJob(webhookDeliveryJob)
+	jobManager.AddJob(syncTombstonePurgeJob)
+	jobManager.AddJob(outboxPurgeJob)
+	if err := jobManager.StartAll(); err != nil {
+		log.Fatalf("Invalid job configuration: %s", err.Error())
+	}
+
+	// -- Jobs Admin Routes --
+	// only mounted when admins are configured, as jobs can be paused and triggered through them
+	adminIDs, err := auth.ParseUserIDs(os.Getenv("ADMIN_USER_IDS"))
+	if err != nil {
+		log.Fatalf("Invalid ADMIN_USER_IDS: %s", err.Error())
+	}
+
+	if len(adminIDs) > 0 {
+		jobHandler := jobs.NewHandler(jobManager)
+		adminJobRoutes := api.Group("/admin/jobs", auth.RequireAccessToken(), auth.RequireAdmin(adminIDs))
+		adminJobRoutes.GET("", jobHandler.ListJobs)
+		adminJobRoutes.GET("/:name/runs", jobHandler.GetRuns)
+		adminJobRoutes.POST("/:name/trigger", jobHandler.Trigger)
+		adminJobRoutes.POST("/:name/pause", jobHandler.Pause)
+		adminJobRoutes.POST("/:name/resume", jobHandler.Resume)
+	}
 
-	return router
+	return router, jobManager, realtimeHub
 }
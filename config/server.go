@@ -0,0 +1,134 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// time in-flight requests get to finish before they are aborted
+	drainTimeout = 15 * time.Second
+	// time running jobs get to finish after they were stopped
+	jobsStopTimeout = 30 * time.Second
+)
+
+type httpServer interface {
+	ListenAndServe() error
+	Shutdown(ctx context.Context) error
+	Close() error
+}
+
+type jobStopper interface {
+	StopAll(ctx context.Context) error
+}
+
+/**
+* Runs the HTTP server along with the background jobs and shuts everything down in order
+* once asked to stop.
+**/
+type Server struct {
+	httpServer httpServer
+	jobs       jobStopper
+	db         io.Closer
+
+	// cancels the base context of every request, aborting outstanding AI and crawler calls
+	cancel context.CancelFunc
+
+	drainTimeout    time.Duration
+	jobsStopTimeout time.Duration
+}
+
+func NewServer(db *sqlx.DB, addr string) *Server {
+	baseCtx, cancel := context.WithCancel(context.Background())
+
+	router, jobManager, realtimeHub := SetupRouter(db)
+
+	httpServer := &http.Server{
+		Addr:    addr,
+		Handler: router,
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
+	}
+	// realtime streams never finish on their own, so they are ended once draining starts
+	httpServer.RegisterOnShutdown(realtimeHub.Close)
+
+	return newServer(httpServer, jobManager, db, cancel)
+}
+
+func newServer(httpServer httpServer, jobs jobStopper, db io.Closer, cancel context.CancelFunc) *Server {
+	return &Server{
+		httpServer:      httpServer,
+		jobs:            jobs,
+		db:              db,
+		cancel:          cancel,
+		drainTimeout:    drainTimeout,
+		jobsStopTimeout: jobsStopTimeout,
+	}
+}
+
+/**
+* Serves requests until ctx is done or the server fails, then shuts down.
+**/
+func (s *Server) Run(ctx context.Context) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.httpServer.ListenAndServe()
+	}()
+
+	var err error
+	select {
+	case err = <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+	case <-ctx.Done():
+		fmt.Println("Shutting down server...")
+	}
+
+	return errors.Join(err, s.Shutdown(context.Background()))
+}
+
+/**
+* Shuts down in order: drains in-flight requests, stops all jobs and waits for their
+* running functions, cancels outstanding AI and crawler contexts, then closes the
+* database. Every step runs even if an earlier one failed, their errors are joined.
+**/
+func (s *Server) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	drainCtx, cancelDrain := context.WithTimeout(ctx, s.drainTimeout)
+	defer cancelDrain()
+
+	if err := s.httpServer.Shutdown(drainCtx); err != nil {
+		errs = append(errs, fmt.Errorf("draining requests: %w", err))
+
+		// abort the requests that did not finish in time
+		s.cancel()
+		if err := s.httpServer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("closing server: %w", err))
+		}
+	}
+
+	jobsCtx, cancelJobs := context.WithTimeout(ctx, s.jobsStopTimeout)
+	defer cancelJobs()
+
+	if err := s.jobs.StopAll(jobsCtx); err != nil {
+		errs = append(errs, fmt.Errorf("stopping jobs: %w", err))
+	}
+
+	s.cancel()
+
+	if err := s.db.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("closing database: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
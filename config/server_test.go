@@ -0,0 +1,181 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// records the order the parts of the server are shut down in
+type shutdownLog struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (l *shutdownLog) add(step string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.steps = append(l.steps, step)
+}
+
+func (l *shutdownLog) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.steps...)
+}
+
+type fakeHTTPServer struct {
+	log         *shutdownLog
+	serveErr    error
+	shutdownErr error
+	closed      chan struct{}
+	once        sync.Once
+}
+
+func (s *fakeHTTPServer) ListenAndServe() error {
+	if s.serveErr != nil {
+		return s.serveErr
+	}
+	<-s.closed
+	return http.ErrServerClosed
+}
+
+func (s *fakeHTTPServer) Shutdown(ctx context.Context) error {
+	s.log.add("drain")
+	if s.shutdownErr != nil {
+		return s.shutdownErr
+	}
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+func (s *fakeHTTPServer) Close() error {
+	s.log.add("close server")
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+type fakeJobs struct {
+	log *shutdownLog
+	err error
+}
+
+func (j *fakeJobs) StopAll(ctx context.Context) error {
+	j.log.add("stop jobs")
+	return j.err
+}
+
+type fakeDB struct {
+	log *shutdownLog
+	err error
+}
+
+func (d *fakeDB) Close() error {
+	d.log.add("close database")
+	return d.err
+}
+
+func TestServerShutdown(t *testing.T) {
+	drainErr := errors.New("requests still running")
+	jobsErr := errors.New("jobs still running")
+	dbErr := errors.New("database gone")
+
+	tests := []struct {
+		name      string
+		drainErr  error
+		jobsErr   error
+		dbErr     error
+		wantSteps []string
+		wantErrs  []error
+	}{
+		{
+			"in order",
+			nil, nil, nil,
+			[]string{"drain", "stop jobs", "cancel", "close database"},
+			nil,
+		},
+		{
+			"requests that are not drained in time are aborted",
+			drainErr, nil, nil,
+			[]string{"drain", "cancel", "close server", "stop jobs", "cancel", "close database"},
+			[]error{drainErr},
+		},
+		{
+			"every step runs after failures",
+			drainErr, jobsErr, dbErr,
+			[]string{"drain", "cancel", "close server", "stop jobs", "cancel", "close database"},
+			[]error{drainErr, jobsErr, dbErr},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &shutdownLog{}
+			httpServer := &fakeHTTPServer{log: log, shutdownErr: tt.drainErr, closed: make(chan struct{})}
+			server := newServer(httpServer, &fakeJobs{log: log, err: tt.jobsErr}, &fakeDB{log: log, err: tt.dbErr}, func() { log.add("cancel") })
+
+			err := server.Shutdown(context.Background())
+
+			for _, want := range tt.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("Shutdown error = %v, want it to include %v", err, want)
+				}
+			}
+			if tt.wantErrs == nil && err != nil {
+				t.Errorf("Shutdown returned error: %v", err)
+			}
+
+			if got := log.get(); !reflect.DeepEqual(got, tt.wantSteps) {
+				t.Errorf("shutdown steps = %v, want %v", got, tt.wantSteps)
+			}
+		})
+	}
+}
+
+func TestServerRun(t *testing.T) {
+	t.Run("shuts down once the context is done", func(t *testing.T) {
+		log := &shutdownLog{}
+		httpServer := &fakeHTTPServer{log: log, closed: make(chan struct{})}
+		server := newServer(httpServer, &fakeJobs{log: log}, &fakeDB{log: log}, func() { log.add("cancel") })
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- server.Run(ctx)
+		}()
+
+		cancel()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Run returned error: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Run did not return after its context was done")
+		}
+
+		if got, want := log.get(), []string{"drain", "stop jobs", "cancel", "close database"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("shutdown steps = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("shuts down when serving fails", func(t *testing.T) {
+		serveErr := errors.New("address already in use")
+		log := &shutdownLog{}
+		httpServer := &fakeHTTPServer{log: log, serveErr: serveErr, closed: make(chan struct{})}
+		server := newServer(httpServer, &fakeJobs{log: log}, &fakeDB{log: log}, func() { log.add("cancel") })
+
+		if err := server.Run(context.Background()); !errors.Is(err, serveErr) {
+			t.Errorf("Run error = %v, want %v", err, serveErr)
+		}
+
+		if got := log.get(); len(got) == 0 || got[len(got)-1] != "close database" {
+			t.Errorf("shutdown steps = %v, want the database closed last", got)
+		}
+	})
+}
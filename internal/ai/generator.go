@@ -31,14 +31,15 @@ func NewGenerator(systemPrompt string, clientKey string) interfaces.ContentGener
 	}
 }
 
-func (g *Generator) Generate(message string) (string, error) {
+// Generate completes the message, giving up early once ctx is cancelled
+func (g *Generator) Generate(ctx context.Context, message string) (string, error) {
 	var resp openai.ChatCompletionResponse
 	var err error
 
 	// retry on error
 	for attempt := 0; attempt < g.maxRetries; attempt++ {
 		resp, err = g.client.CreateChatCompletion(
-			context.Background(),
+			ctx,
 			openai.ChatCompletionRequest{
 				Model: openai.GPT4o,
 				Messages: []openai.ChatCompletionMessage{
@@ -59,7 +60,12 @@ func (g *Generator) Generate(message string) (string, error) {
 		// retry chat completion gen after a short delay
 		if err != nil {
 			fmt.Printf("Error occured while attempting chat completion: %v\n", err)
-			time.Sleep(g.retryDelay)
+
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(g.retryDelay):
+			}
 			continue
 		}
 
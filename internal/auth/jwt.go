@@ -2,11 +2,13 @@ package auth
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"time"
 
 	"github.com/darkphotonKN/fireplace/internal/models"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type TokenType string
@@ -14,10 +16,15 @@ type TokenType string
 const (
 	Refresh TokenType = "refresh"
 	Access  TokenType = "access"
+	// short lived and only accepted when opening a realtime stream
+	Stream TokenType = "stream"
 )
 
+// how long a stream ticket can be used to open a stream
+const StreamTicketExpiry = time.Minute
+
 /**
-* Generates and signs a JWT token with claims of either the "access" or "refresh" types.
+* Generates and signs a JWT token with claims of the "access", "refresh" or "stream" types.
 **/
 func GenerateJWT(user models.User, tokenType TokenType, expiration time.Duration) (string, error) {
 	JWTSecret := []byte(os.Getenv("JWT_SECRET"))
@@ -63,3 +70,53 @@ func RefreshToken(refreshToken string, user models.User) (string, int, error) {
 	// Return the new access token and expiration time (in seconds)
 	return newAccessToken, int(15 * 60), nil
 }
+
+/**
+* Issues a stream ticket for the user. Clients such as EventSource cannot set headers, so
+* the ticket is sent in the query string and expires soon after, keeping what ends up in
+* logs useless.
+**/
+func GenerateStreamTicket(userID uuid.UUID) (string, error) {
+	return GenerateJWT(models.User{BaseDBDateModel: models.BaseDBDateModel{ID: userID}}, Stream, StreamTicketExpiry)
+}
+
+/**
+* Validates an access token and returns the id of the user it was issued to.
+**/
+func ParseAccessToken(accessToken string) (uuid.UUID, error) {
+	return parseToken(accessToken, Access)
+}
+
+/**
+* Validates a stream ticket and returns the id of the user it was issued to.
+**/
+func ParseStreamTicket(ticket string) (uuid.UUID, error) {
+	return parseToken(ticket, Stream)
+}
+
+func parseToken(raw string, tokenType TokenType) (uuid.UUID, error) {
+	token, err := jwt.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+
+	if err != nil || !token.Valid {
+		return uuid.Nil, fmt.Errorf("invalid %s token", tokenType)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["tokenType"] != string(tokenType) {
+		return uuid.Nil, errors.New("invalid token type")
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil {
+		return uuid.Nil, errors.New("invalid token subject")
+	}
+
+	userID, err := uuid.Parse(subject)
+	if err != nil {
+		return uuid.Nil, errors.New("invalid token subject")
+	}
+
+	return userID, nil
+}
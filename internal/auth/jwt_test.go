@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestParseStreamTicket(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	userID := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	user := models.User{BaseDBDateModel: models.BaseDBDateModel{ID: userID}}
+
+	sign := func(tokenType TokenType, expiration time.Duration) string {
+		token, err := GenerateJWT(user, tokenType, expiration)
+		if err != nil {
+			t.Fatalf("GenerateJWT returned error: %v", err)
+		}
+		return token
+	}
+
+	ticket, err := GenerateStreamTicket(userID)
+	if err != nil {
+		t.Fatalf("GenerateStreamTicket returned error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		ticket  string
+		wantErr bool
+	}{
+		{"ticket", ticket, false},
+		{"access token", sign(Access, time.Hour), true},
+		{"refresh token", sign(Refresh, time.Hour), true},
+		{"expired ticket", sign(Stream, -time.Second), true},
+		{"malformed", "not-a-ticket", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStreamTicket(tt.ticket)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseStreamTicket returned %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseStreamTicket returned error: %v", err)
+			}
+			if got != userID {
+				t.Errorf("ParseStreamTicket = %s, want %s", got, userID)
+			}
+		})
+	}
+}
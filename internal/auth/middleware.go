@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// key of the authenticated user's id in the gin context
+const UserIDKey = "userId"
+
+/**
+* Requires a valid access token, sent as "Authorization: Bearer <token>". The id of the
+* user is stored in the context under UserIDKey.
+**/
+func RequireAccessToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"statusCode": http.StatusUnauthorized, "message": "Missing access token"})
+			return
+		}
+
+		userID, err := ParseAccessToken(token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"statusCode": http.StatusUnauthorized, "message": "Invalid access token", "error": err.Error()})
+			return
+		}
+
+		c.Set(UserIDKey, userID)
+		c.Next()
+	}
+}
+
+/**
+* Requires a valid stream ticket in the ticket query parameter, for realtime streams that
+* are opened by clients such as EventSource that cannot set headers. The id of the user is
+* stored in the context under UserIDKey.
+**/
+func RequireStreamTicket() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ticket := c.Query("ticket")
+		if ticket == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"statusCode": http.StatusUnauthorized, "message": "Missing stream ticket"})
+			return
+		}
+
+		userID, err := ParseStreamTicket(ticket)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"statusCode": http.StatusUnauthorized, "message": "Invalid stream ticket", "error": err.Error()})
+			return
+		}
+
+		c.Set(UserIDKey, userID)
+		c.Next()
+	}
+}
+
+// UserID returns the id of the user authenticated by RequireAccessToken or RequireStreamTicket
+func UserID(c *gin.Context) uuid.UUID {
+	return c.MustGet(UserIDKey).(uuid.UUID)
+}
+
+/**
+* Only lets through the users listed as admins, must come after RequireAccessToken. With
+* no admins every request is refused.
+**/
+func RequireAdmin(adminIDs []uuid.UUID) gin.HandlerFunc {
+	admins := make(map[uuid.UUID]bool, len(adminIDs))
+	for _, id := range adminIDs {
+		admins[id] = true
+	}
+
+	return func(c *gin.Context) {
+		if !admins[UserID(c)] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"statusCode": http.StatusForbidden, "message": "Admin access is required"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ParseUserIDs parses a comma separated list of user ids, such as the ADMIN_USER_IDS setting
+func ParseUserIDs(raw string) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		id, err := uuid.Parse(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user id %q: %w", part, err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -2,11 +2,17 @@ package checklistitems
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/darkphotonKN/fireplace/internal/constants"
 	"github.com/darkphotonKN/fireplace/internal/models"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/darkphotonKN/fireplace/internal/utils/filterutils"
+	"github.com/darkphotonKN/fireplace/internal/utils/httputils"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -16,15 +22,22 @@ type Handler struct {
 }
 
 type Service interface {
-	GetAllByPlanId(ctx context.Context, planId uuid.UUID, scope *string, upcoming *string) ([]*models.ChecklistItem, error)
-	GetAllArchivedByPlanId(ctx context.Context, planId uuid.UUID, scope *string) ([]*models.ChecklistItem, error)
+	GetAllByPlanId(ctx context.Context, planId uuid.UUID, opts ListOptions) ([]*models.ChecklistItem, *pageutils.Page, error)
+	GetAllArchivedByPlanId(ctx context.Context, planId uuid.UUID, opts ListOptions) ([]*models.ChecklistItem, *pageutils.Page, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*models.ChecklistItem, error)
 	Create(ctx context.Context, req CreateReq, planID uuid.UUID) (*models.ChecklistItem, error)
+	CreateWithID(ctx context.Context, id uuid.UUID, req CreateReq, planID uuid.UUID) (*models.ChecklistItem, error)
 	Update(ctx context.Context, id uuid.UUID, req UpdateReq) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID, version *int) (*Operation, error)
 	SetSchedule(ctx context.Context, id uuid.UUID, req SetScheduleReq) error
-	Archive(ctx context.Context, id uuid.UUID) error
-	GetUpcoming(ctx context.Context, planId uuid.UUID) ([]*models.ChecklistItem, error)
+	Archive(ctx context.Context, userID uuid.UUID, id uuid.UUID, version *int) (*Operation, error)
+	Unarchive(ctx context.Context, id uuid.UUID, version *int) error
+	AutoArchive(ctx context.Context, userID uuid.UUID, planID uuid.UUID, dryRun bool) ([]*ArchiveCandidate, *Operation, error)
+	GetUpcoming(ctx context.Context, planId uuid.UUID, opts ListOptions) ([]*models.ChecklistItem, error)
+	MoveToPlan(ctx context.Context, userID uuid.UUID, sourcePlanID uuid.UUID, req TransferItemsReq) ([]*models.ChecklistItem, *Operation, error)
+	CopyToPlan(ctx context.Context, userID uuid.UUID, sourcePlanID uuid.UUID, req TransferItemsReq) ([]*models.ChecklistItem, *Operation, error)
+	Undo(ctx context.Context, userID uuid.UUID, token uuid.UUID) (*Operation, error)
+	GetOperations(ctx context.Context, userID uuid.UUID, page *pageutils.Params) ([]*Operation, *pageutils.Page, error)
 }
 
 func NewHandler(service Service) *Handler {
@@ -42,18 +55,36 @@ func (h *Handler) GetAll(c *gin.Context) {
 		return
 	}
 
-	scope := c.Query("scope")
-	var scopePtr *string
-	if scope != "" {
-		scopePtr = &scope
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fields, err := filterutils.ParseFields(c.Query("fields"), models.ChecklistItem{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	items, err := h.service.GetAllByPlanId(c.Request.Context(), planId, scopePtr, nil)
+	opts.Page, err = pageutils.ParseParams(c.Query("limit"), c.Query("cursor"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get checklist items. Error:" + err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "successfully retrieved all checklist items.", "result": items})
+
+	items, page, err := h.service.GetAllByPlanId(c.Request.Context(), planId, opts)
+	if err != nil {
+		c.JSON(writeErrorStatus(err), gin.H{"error": "Failed to get checklist items. Error:" + err.Error()})
+		return
+	}
+
+	result, err := filterutils.SelectFields(items, fields)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to select fields. Error:" + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "successfully retrieved all checklist items.", "result": result, "page": page})
 }
 
 func (h *Handler) GetAllArchived(c *gin.Context) {
@@ -65,18 +96,36 @@ func (h *Handler) GetAllArchived(c *gin.Context) {
 		return
 	}
 
-	scope := c.Query("scope")
-	var scopePtr *string
-	if scope != "" {
-		scopePtr = &scope
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fields, err := filterutils.ParseFields(c.Query("fields"), models.ChecklistItem{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts.Page, err = pageutils.ParseParams(c.Query("limit"), c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	items, err := h.service.GetAllArchivedByPlanId(c.Request.Context(), planId, scopePtr)
+	items, page, err := h.service.GetAllArchivedByPlanId(c.Request.Context(), planId, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get archived checklist items. Error:" + err.Error()})
+		c.JSON(writeErrorStatus(err), gin.H{"error": "Failed to get archived checklist items. Error:" + err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "successfully retrieved archived checklist items.", "result": items})
+
+	result, err := filterutils.SelectFields(items, fields)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to select fields. Error:" + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "successfully retrieved archived checklist items.", "result": result, "page": page})
 }
 
 func (h *Handler) Create(c *gin.Context) {
@@ -98,7 +147,7 @@ func (h *Handler) Create(c *gin.Context) {
 	newItem, err := h.service.Create(c.Request.Context(), req, planID)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create checklist item. Error: " + err.Error()})
+		c.JSON(writeErrorStatus(err), gin.H{"error": "Failed to create checklist item. Error: " + err.Error()})
 		return
 	}
 
@@ -120,8 +169,14 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
+	req.Version, err = httputils.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	if err := h.service.Update(c.Request.Context(), id, req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update checklist item. Error: " + err.Error()})
+		c.JSON(writeErrorStatus(err), gin.H{"error": "Failed to update checklist item. Error: " + err.Error()})
 		return
 	}
 
@@ -129,6 +184,9 @@ func (h *Handler) Update(c *gin.Context) {
 }
 
 func (h *Handler) Delete(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
 	idStr := c.Param("checklist_id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
@@ -136,12 +194,19 @@ func (h *Handler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Delete(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete checklist item"})
+	version, err := httputils.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "result": constants.UpdateStatusFailure})
+		return
+	}
+
+	op, err := h.service.Delete(c.Request.Context(), userId, id, version)
+	if err != nil {
+		c.JSON(writeErrorStatus(err), gin.H{"error": "Failed to delete checklist item. Error: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "successfully deleted checklist item.", "result": constants.UpdateStatusSuccess})
+	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "successfully deleted checklist item.", "result": constants.UpdateStatusSuccess, "undoToken": op.ID})
 }
 
 func (h *Handler) GetByID(c *gin.Context) {
@@ -158,6 +223,7 @@ func (h *Handler) GetByID(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", httputils.ETag(item.Version))
 	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "Successfully retrieved checklist item.", "result": item})
 }
 
@@ -175,8 +241,14 @@ func (h *Handler) SetSchedule(c *gin.Context) {
 		return
 	}
 
+	req.Version, err = httputils.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "result": constants.UpdateStatusFailure})
+		return
+	}
+
 	if err := h.service.SetSchedule(c.Request.Context(), id, req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set schedule on checklist item. Error: " + err.Error()})
+		c.JSON(writeErrorStatus(err), gin.H{"error": "Failed to set schedule on checklist item. Error: " + err.Error()})
 		return
 	}
 
@@ -184,6 +256,9 @@ func (h *Handler) SetSchedule(c *gin.Context) {
 }
 
 func (h *Handler) Archive(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
 	idStr := c.Param("checklist_id")
 	id, err := uuid.Parse(idStr)
 	fmt.Println("Checklist Id was:", idStr)
@@ -192,12 +267,83 @@ func (h *Handler) Archive(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Archive(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to archive checklist item. Error: " + err.Error()})
+	version, err := httputils.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "result": constants.UpdateStatusFailure})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "Successfully archived checklist item.", "result": constants.UpdateStatusSuccess})
+	op, err := h.service.Archive(c.Request.Context(), userId, id, version)
+	if errors.Is(err, constants.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Checklist item not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(writeErrorStatus(err), gin.H{"error": "Failed to archive checklist item. Error: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "Successfully archived checklist item.", "result": constants.UpdateStatusSuccess, "undoToken": op.ID})
+}
+
+func (h *Handler) Unarchive(c *gin.Context) {
+	idStr := c.Param("checklist_id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format", "result": constants.UpdateStatusFailure})
+		return
+	}
+
+	version, err := httputils.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "result": constants.UpdateStatusFailure})
+		return
+	}
+
+	if err := h.service.Unarchive(c.Request.Context(), id, version); err != nil {
+		if errors.Is(err, constants.ErrNotFound) || errors.Is(err, constants.ErrNoRowsAffected) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Checklist item not found"})
+			return
+		}
+		c.JSON(writeErrorStatus(err), gin.H{"error": "Failed to unarchive checklist item. Error: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "Successfully unarchived checklist item.", "result": constants.UpdateStatusSuccess})
+}
+
+// AutoArchive applies the auto archive policy of a plan, only reporting the matched items with ?dryRun=true
+func (h *Handler) AutoArchive(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	planId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect uuid format."})
+		return
+	}
+
+	dryRun := c.Query("dryRun") == "true"
+
+	items, op, err := h.service.AutoArchive(c.Request.Context(), userId, planId, dryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to auto archive checklist items. Error: " + err.Error()})
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "Checklist items that would be auto archived.", "result": items})
+		return
+	}
+
+	response := gin.H{"statusCode:": http.StatusOK, "message": "Successfully auto archived checklist items.", "result": items}
+
+	// nothing to undo when no items were archived
+	if op != nil {
+		response["undoToken"] = op.ID
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // GetUpcoming returns all upcoming tasks for a plan
@@ -210,10 +356,190 @@ func (h *Handler) GetUpcoming(c *gin.Context) {
 		return
 	}
 
-	items, err := h.service.GetUpcoming(c.Request.Context(), planId)
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fields, err := filterutils.ParseFields(c.Query("fields"), models.ChecklistItem{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, err := h.service.GetUpcoming(c.Request.Context(), planId, opts)
+	if err != nil {
+		c.JSON(writeErrorStatus(err), gin.H{"error": "Failed to get upcoming tasks. Error:" + err.Error()})
+		return
+	}
+
+	result, err := filterutils.SelectFields(items, fields)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to select fields. Error:" + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "Successfully retrieved upcoming tasks.", "result": result})
+}
+
+// MoveToPlan moves checklist items to another plan
+func (h *Handler) MoveToPlan(c *gin.Context) {
+	h.transfer(c, h.service.MoveToPlan, "moved")
+}
+
+// CopyToPlan copies checklist items to another plan
+func (h *Handler) CopyToPlan(c *gin.Context) {
+	h.transfer(c, h.service.CopyToPlan, "copied")
+}
+
+type transferFunc func(ctx context.Context, userID uuid.UUID, sourcePlanID uuid.UUID, req TransferItemsReq) ([]*models.ChecklistItem, *Operation, error)
+
+func (h *Handler) transfer(c *gin.Context, fn transferFunc, action string) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	planId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect uuid format provided plan id in the param."})
+		return
+	}
+
+	var req TransferItemsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body. Error was: " + err.Error()})
+		return
+	}
+
+	items, op, err := fn(c.Request.Context(), userId, planId, req)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, constants.ErrForbidden):
+			status = http.StatusForbidden
+		case errors.Is(err, constants.ErrNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": fmt.Sprintf("Failed to transfer checklist items. Error: %s", err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": fmt.Sprintf("Successfully %s checklist items.", action), "result": items, "undoToken": op.ID})
+}
+
+// Undo reverts a delete, archive, move or copy by the undo token it returned
+func (h *Handler) Undo(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	token, err := uuid.Parse(c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect undo token format."})
+		return
+	}
+
+	op, err := h.service.Undo(c.Request.Context(), userId, token)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get upcoming tasks. Error:" + err.Error()})
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, constants.ErrNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, constants.ErrAlreadyUndone):
+			status = http.StatusConflict
+		case errors.Is(err, constants.ErrUndoExpired):
+			status = http.StatusGone
+		}
+		c.JSON(status, gin.H{"error": "Failed to undo operation. Error: " + err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "Successfully retrieved upcoming tasks.", "result": items})
+
+	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "Successfully undid operation.", "result": op})
+}
+
+// GetOperations returns the journal of operations that can be undone, most recent first
+func (h *Handler) GetOperations(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	page, err := pageutils.ParseParams(c.Query("limit"), c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	operations, pageInfo, err := h.service.GetOperations(c.Request.Context(), userId, page)
+	if errors.Is(err, pageutils.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get operations. Error: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "Successfully retrieved operations.", "result": operations, "page": pageInfo})
+}
+
+// maps errors of writes guarded by If-Match to their status codes
+func writeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, constants.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, constants.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, constants.ErrInvalidInput), errors.Is(err, constants.ErrInvalidScope), errors.Is(err, pageutils.ErrInvalidCursor):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+/**
+* Reads the filter and sort query parameters shared by the listing endpoints. Sort takes
+* a comma separated list of fields where a '-' prefix sorts descending, e.g.
+* ?sort=-priority,dueDate
+**/
+func parseListOptions(c *gin.Context) (ListOptions, error) {
+	var opts ListOptions
+
+	if scope := c.Query("scope"); scope != "" {
+		opts.Scope = &scope
+	}
+
+	if priority := c.Query("priority"); priority != "" {
+		opts.Priority = &priority
+	}
+
+	if dueBefore := c.Query("dueBefore"); dueBefore != "" {
+		t, err := time.Parse(time.RFC3339, dueBefore)
+		if err != nil {
+			return opts, errorutils.Invalidf("dueBefore must be an RFC3339 datetime")
+		}
+		opts.DueBefore = &t
+	}
+
+	if dueAfter := c.Query("dueAfter"); dueAfter != "" {
+		t, err := time.Parse(time.RFC3339, dueAfter)
+		if err != nil {
+			return opts, errorutils.Invalidf("dueAfter must be an RFC3339 datetime")
+		}
+		opts.DueAfter = &t
+	}
+
+	opts.Overdue = c.Query("overdue") == "true"
+
+	// e.g. ?filter=done:false scope:daily scheduled<7d
+	filter, err := filterutils.Parse(c.Query("filter"), FilterFields, time.Now())
+	if err != nil {
+		return opts, err
+	}
+	opts.Filter = filter
+
+	if sortBy := c.Query("sort"); sortBy != "" {
+		opts.SortBy = &sortBy
+	}
+
+	if order := c.Query("order"); order != "" {
+		opts.SortOrder = &order
+	}
+
+	return opts, nil
 }
@@ -1,19 +1,60 @@
 package checklistitems
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/models"
+	"github.com/darkphotonKN/fireplace/internal/utils/filterutils"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
+	"github.com/google/uuid"
+)
 
 type CreateReq struct {
-	Description string  `json:"description"`
-	Scope       *string `json:"scope,omitempty"`
+	Description    string     `json:"description"`
+	Notes          *string    `json:"notes,omitempty"`
+	Scope          *string    `json:"scope,omitempty"`
+	DueDate        *time.Time `json:"dueDate,omitempty"`
+	Priority       *string    `json:"priority,omitempty"`
+	EffortEstimate *int       `json:"effortEstimate,omitempty"`
+	EffortUnit     *string    `json:"effortUnit,omitempty"`
 }
 
 type UpdateReq struct {
-	Description   *string `json:"description,omitempty"`
-	Done          *bool   `json:"done,omitempty"`
-	Sequence      *bool   `json:"sequence,omitempty"`
-	Scope         *string `json:"scope,omitempty"`
-	Archived      *bool   `json:"archived,omitempty"`
-	ScheduledTime *time.Time
+	Description    *string    `json:"description,omitempty"`
+	Notes          *string    `json:"notes,omitempty"`
+	Done           *bool      `json:"done,omitempty"`
+	Sequence       *bool      `json:"sequence,omitempty"`
+	Scope          *string    `json:"scope,omitempty"`
+	Archived       *bool      `json:"archived,omitempty"`
+	DueDate        *time.Time `json:"dueDate,omitempty"`
+	Priority       *string    `json:"priority,omitempty"`
+	EffortEstimate *int       `json:"effortEstimate,omitempty"`
+	EffortUnit     *string    `json:"effortUnit,omitempty"`
+	ScheduledTime  *time.Time `json:"scheduledTime,omitempty"`
+
+	// removes the due date, as a missing dueDate leaves it unchanged
+	ClearDueDate bool `json:"clearDueDate,omitempty"`
+	// removes the scheduled time, as a missing scheduledTime leaves it unchanged
+	ClearScheduledTime bool `json:"clearScheduledTime,omitempty"`
+
+	// minutes before the scheduled time reminders are sent at
+	ReminderLeadMinutes []int64 `json:"reminderLeadMinutes,omitempty"`
+
+	// version the client last saw, taken from the If-Match header
+	Version *int `json:"-"`
+}
+
+/**
+* Moves or copies checklist items into another plan. Position is the 1-based place in the
+* target plan's ordering the items are inserted at, by default they are added to the end.
+**/
+type TransferItemsReq struct {
+	ItemIDs      []uuid.UUID `json:"itemIds" binding:"required,min=1"`
+	TargetPlanID uuid.UUID   `json:"targetPlanId" binding:"required"`
+	Position     *int        `json:"position,omitempty"`
 }
 
 type BatchUpdateReq struct {
@@ -23,4 +64,94 @@ type BatchUpdateReq struct {
 type SetScheduleReq struct {
 	// NOTE: no binding for validation as datetime binding had a known issue
 	ScheduledTime *string `json:"scheduledTime,omitempty"`
+
+	// e.g. [10, 0] reminds 10 minutes before and at the scheduled time
+	ReminderLeadMinutes []int64 `json:"reminderLeadMinutes,omitempty"`
+
+	// version the client last saw, taken from the If-Match header
+	Version *int `json:"-"`
+}
+
+/**
+* Filtering and sorting options for listing checklist items. SortBy is a comma separated
+* list of sort fields, a field prefixed with '-' is sorted descending while the others
+* follow SortOrder.
+**/
+type ListOptions struct {
+	Scope     *string
+	Upcoming  *string
+	Priority  *string
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	Overdue   bool
+	Filter    *filterutils.Filter
+	SortBy    *string
+	SortOrder *string
+	Page      *pageutils.Params // nil lists every item
+}
+
+/**
+* A checklist item picked by the auto archive policy of its plan along with why it was
+* picked.
+**/
+type ArchiveCandidate struct {
+	models.ChecklistItem
+	Reason string `db:"reason" json:"reason"`
+}
+
+/**
+* An entry of the per-user operation journal. Its id is the undo token handed out by the
+* operation, and the snapshot holds what the affected items looked like beforehand.
+**/
+type Operation struct {
+	ID        uuid.UUID     `db:"id" json:"undoToken"`
+	UserID    uuid.UUID     `db:"user_id" json:"-"`
+	PlanID    *uuid.UUID    `db:"plan_id" json:"planId,omitempty"`
+	Kind      string        `db:"kind" json:"kind"`
+	Snapshot  ItemSnapshots `db:"snapshot" json:"items"`
+	CreatedAt time.Time     `db:"created_at" json:"createdAt"`
+	ExpiresAt time.Time     `db:"expires_at" json:"expiresAt"`
+	UndoneAt  *time.Time    `db:"undone_at" json:"undoneAt,omitempty"`
+}
+
+type ItemSnapshot struct {
+	ID       uuid.UUID `db:"id" json:"id"`
+	PlanID   uuid.UUID `db:"plan_id" json:"planId"`
+	Sequence int       `db:"sequence" json:"sequence"`
+	Archived bool      `db:"archived" json:"archived"`
+}
+
+// item snapshots are stored as a json array
+type ItemSnapshots []ItemSnapshot
+
+func (s ItemSnapshots) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *ItemSnapshots) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case []byte:
+		return json.Unmarshal(value, s)
+	case string:
+		return json.Unmarshal([]byte(value), s)
+	case nil:
+		*s = nil
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into item snapshots", src)
+}
+
+/**
+* A reminder of a scheduled checklist item that is due, claimed for delivery so that it
+* is only sent once.
+**/
+type Reminder struct {
+	ID              uuid.UUID `db:"id" json:"id"`
+	ChecklistItemID uuid.UUID `db:"checklist_item_id" json:"checklistItemId"`
+	UserID          uuid.UUID `db:"user_id" json:"userId"`
+	PlanID          uuid.UUID `db:"plan_id" json:"planId"`
+	Description     string    `db:"description" json:"description"`
+	LeadMinutes     int       `db:"lead_minutes" json:"leadMinutes"`
+	ScheduledTime   time.Time `db:"scheduled_time" json:"scheduledTime"`
+	RemindAt        time.Time `db:"remind_at" json:"remindAt"`
 }
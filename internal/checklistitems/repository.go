@@ -2,13 +2,24 @@ package checklistitems
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/darkphotonKN/fireplace/internal/completions"
 	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/events"
 	"github.com/darkphotonKN/fireplace/internal/models"
+	"github.com/darkphotonKN/fireplace/internal/utils/dbutils"
 	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/darkphotonKN/fireplace/internal/utils/filterutils"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type repository struct {
@@ -21,75 +32,227 @@ func NewRepository(db *sqlx.DB) Repository {
 	}
 }
 
-func (s *repository) GetAllByPlanId(ctx context.Context, planId uuid.UUID, scope *string, upcoming *string) ([]*models.ChecklistItem, error) {
-	query := `
-	SELECT id, description, done, sequence, scope, scheduled_time, archived, created_at, updated_at, plan_id
-	FROM checklist_items
-	WHERE plan_id = $1
-	AND archived = false
-	`
+const checklistItemColumns = `id, description, notes, done, sequence, scope, scheduled_time, due_date, priority, effort_estimate, effort_unit, archived, created_at, updated_at, plan_id, version, reminder_lead_minutes`
 
-	// Add scope filtering if provided
-	args := []interface{}{planId}
-	if scope != nil {
-		query += `AND scope = $2
-	`
-		args = append(args, *scope)
+// ranks priorities so that they can be sorted from least to most urgent
+const priorityRank = `CASE priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 WHEN 'urgent' THEN 4 END`
+
+var priorityRanks = map[constants.ChecklistItemPriority]int{
+	constants.PriorityLow:    1,
+	constants.PriorityMedium: 2,
+	constants.PriorityHigh:   3,
+	constants.PriorityUrgent: 4,
+}
+
+var sortColumns = map[constants.ChecklistSortField]string{
+	constants.SortSequence:  "sequence",
+	constants.SortPriority:  priorityRank,
+	constants.SortDueDate:   "due_date",
+	constants.SortEffort:    "effort_estimate",
+	constants.SortCreatedAt: "created_at",
+}
+
+// fields that can be used in filter expressions, e.g. done:false scope:daily scheduled<7d
+var FilterFields = map[string]filterutils.Field{
+	"done":        {Column: "done", Type: filterutils.BoolField},
+	"scope":       {Column: "scope", Type: filterutils.EnumField, Values: scopeValues()},
+	"priority":    {Column: "priority", Type: filterutils.EnumField, Values: []string{string(constants.PriorityLow), string(constants.PriorityMedium), string(constants.PriorityHigh), string(constants.PriorityUrgent)}},
+	"scheduled":   {Column: "scheduled_time", Type: filterutils.TimeField},
+	"due":         {Column: "due_date", Type: filterutils.TimeField},
+	"created":     {Column: "created_at", Type: filterutils.TimeField},
+	"updated":     {Column: "updated_at", Type: filterutils.TimeField},
+	"effort":      {Column: "effort_estimate", Type: filterutils.NumberField},
+	"description": {Column: "description", Type: filterutils.TextField},
+}
+
+func scopeValues() []string {
+	values := make([]string, 0, len(constants.ChecklistItemScopes))
+	for _, scope := range constants.ChecklistItemScopes {
+		values = append(values, string(scope))
 	}
+	return values
+}
 
-	if upcoming != nil {
-		interval := fmt.Sprintf("'1 %s'", *upcoming)
+// intervals of the upcoming listings
+var upcomingIntervals = map[constants.ChecklistUpcoming]string{
+	constants.UpcomingToday: "1 day",
+	constants.UpcomingWeek:  "1 week",
+	constants.UpcomingMonth: "1 month",
+	constants.UpcomingYear:  "1 year",
+}
 
-		query += fmt.Sprintf(`
-			AND scheduled_time IS NOT NULL 
-	    AND scheduled_time >= CURRENT_TIMESTAMP
-			AND scheduled_time <= CURRENT_TIMESTAMP + INTERVAL %s
-		`, interval)
+// qualifies each of the comma separated columns with a table alias
+func prefixColumns(alias string, columns string) string {
+	parts := strings.Split(columns, ",")
+	for i, column := range parts {
+		parts[i] = alias + "." + strings.TrimSpace(column)
 	}
+	return strings.Join(parts, ", ")
+}
+
+func (s *repository) GetAllByPlanId(ctx context.Context, planId uuid.UUID, opts ListOptions) ([]*models.ChecklistItem, *pageutils.Page, error) {
+	query := fmt.Sprintf(`
+	SELECT %s
+	FROM checklist_items
+	WHERE plan_id = $1
+	AND archived = false
+	AND deleted_at IS NULL
+	`, checklistItemColumns)
 
-	// Always add ordering
-	query += `ORDER BY sequence ASC`
+	query, args, err := applyListOptions(query, []interface{}{planId}, opts)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	fmt.Printf("constructed query: %s\n", query)
 	fmt.Printf("constructed args: %+v\n", args)
 
 	var items []*models.ChecklistItem
-	err := s.db.SelectContext(ctx, &items, query, args...)
+	err = s.db.SelectContext(ctx, &items, query, args...)
 	if err != nil {
-		return nil, errorutils.AnalyzeDBErr(err)
+		return nil, nil, errorutils.AnalyzeDBErr(err)
 	}
 
-	return items, nil
+	items, page := paginateItems(items, opts)
+
+	return items, page, nil
 }
 
-func (s *repository) GetAllArchivedByPlanId(ctx context.Context, planId uuid.UUID, scope *string) ([]*models.ChecklistItem, error) {
-	baseQuery := `
-	SELECT id, description, done, sequence, scope, scheduled_time, archived, created_at, updated_at, plan_id
+func (s *repository) GetAllArchivedByPlanId(ctx context.Context, planId uuid.UUID, opts ListOptions) ([]*models.ChecklistItem, *pageutils.Page, error) {
+	baseQuery := fmt.Sprintf(`
+	SELECT %s
 	FROM checklist_items
 	WHERE plan_id = $1
 	AND archived = true
-	`
+	AND deleted_at IS NULL
+	`, checklistItemColumns)
 
-	// Add scope filtering if provided
-	args := []interface{}{planId}
-	if scope != nil {
-		baseQuery += `AND scope = $2
-	`
-		args = append(args, *scope)
+	query, args, err := applyListOptions(baseQuery, []interface{}{planId}, opts)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	fmt.Printf("Args for archived items: %v\n", args)
 
-	// Always add ordering
-	baseQuery += `ORDER BY sequence ASC`
-
 	var items []*models.ChecklistItem
-	err := s.db.SelectContext(ctx, &items, baseQuery, args...)
+	err = s.db.SelectContext(ctx, &items, query, args...)
 	if err != nil {
-		return nil, errorutils.AnalyzeDBErr(err)
+		return nil, nil, errorutils.AnalyzeDBErr(err)
 	}
 
-	return items, nil
+	items, page := paginateItems(items, opts)
+
+	return items, page, nil
+}
+
+/**
+* Appends the filtering, ordering and pagination of the list options to a query that
+* already has a WHERE clause. Options are expected to have been validated by the service.
+**/
+func applyListOptions(query string, args []interface{}, opts ListOptions) (string, []interface{}, error) {
+	if opts.Scope != nil {
+		args = append(args, *opts.Scope)
+		query += fmt.Sprintf(`AND scope = $%d
+	`, len(args))
+	}
+
+	if opts.Priority != nil {
+		args = append(args, *opts.Priority)
+		query += fmt.Sprintf(`AND priority = $%d
+	`, len(args))
+	}
+
+	if opts.DueBefore != nil {
+		args = append(args, *opts.DueBefore)
+		query += fmt.Sprintf(`AND due_date <= $%d
+	`, len(args))
+	}
+
+	if opts.DueAfter != nil {
+		args = append(args, *opts.DueAfter)
+		query += fmt.Sprintf(`AND due_date >= $%d
+	`, len(args))
+	}
+
+	if opts.Overdue {
+		query += `AND due_date IS NOT NULL
+	AND due_date < CURRENT_TIMESTAMP
+	AND done = false
+	`
+	}
+
+	if opts.Upcoming != nil {
+		args = append(args, upcomingIntervals[constants.ChecklistUpcoming(*opts.Upcoming)])
+		query += fmt.Sprintf(`AND scheduled_time IS NOT NULL
+	AND scheduled_time >= CURRENT_TIMESTAMP
+	AND scheduled_time <= CURRENT_TIMESTAMP + $%d::interval
+	`, len(args))
+	}
+
+	query, args = opts.Filter.Apply(query, args)
+
+	// always add ordering, sequence is the default and the tiebreaker
+	keys := make([]pageutils.Key, 0)
+	for _, key := range listSortKeys(opts) {
+		keys = append(keys, pageutils.Key{
+			Column:   sortColumns[key.field],
+			Desc:     key.desc,
+			Nullable: key.field == constants.SortDueDate || key.field == constants.SortEffort,
+		})
+	}
+
+	return pageutils.Apply(query, args, keys, opts.Page)
+}
+
+/**
+* The sort keys of a listing, with sequence added as the tiebreaker when it is not sorted
+* on already. Without a sort, sequence is the sort key and follows the requested order.
+**/
+func listSortKeys(opts ListOptions) []sortKey {
+	keys := sortKeys(opts)
+	if len(keys) == 0 {
+		return []sortKey{{field: constants.SortSequence, desc: opts.SortOrder != nil && *opts.SortOrder == "desc"}}
+	}
+
+	for _, key := range keys {
+		if key.field == constants.SortSequence {
+			return keys
+		}
+	}
+	return append(keys, sortKey{field: constants.SortSequence})
+}
+
+// the value of a checklist item for a sort field, matching what its sort column holds
+func sortValue(item *models.ChecklistItem, field constants.ChecklistSortField) interface{} {
+	switch field {
+	case constants.SortPriority:
+		return priorityRanks[constants.ChecklistItemPriority(item.Priority)]
+	case constants.SortDueDate:
+		if item.DueDate == nil {
+			return nil
+		}
+		return *item.DueDate
+	case constants.SortEffort:
+		if item.EffortEstimate == nil {
+			return nil
+		}
+		return *item.EffortEstimate
+	case constants.SortCreatedAt:
+		return item.CreatedAt
+	}
+	return item.Sequence
+}
+
+func paginateItems(items []*models.ChecklistItem, opts ListOptions) ([]*models.ChecklistItem, *pageutils.Page) {
+	keys := listSortKeys(opts)
+
+	return pageutils.Paginate(items, opts.Page, func(item *models.ChecklistItem) (uuid.UUID, []interface{}) {
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			values[i] = sortValue(item, key.field)
+		}
+		return item.ID, values
+	})
 }
 
 func (s *repository) GetAll(ctx context.Context, scope *string) ([]*models.ChecklistItem, error) {
@@ -105,6 +268,7 @@ func (s *repository) GetAll(ctx context.Context, scope *string) ([]*models.Check
 		updated_at,
 		plan_id
 	FROM checklist_items
+	WHERE deleted_at IS NULL
 	`
 
 	var items []*models.ChecklistItem
@@ -112,7 +276,7 @@ func (s *repository) GetAll(ctx context.Context, scope *string) ([]*models.Check
 	args := []interface{}{}
 
 	if scope != nil {
-		query += "\nWHERE scope = $1"
+		query += "\nAND scope = $1"
 		args = append(args, *scope)
 		err := s.db.SelectContext(ctx, &items, query, args...)
 
@@ -149,11 +313,14 @@ func (s *repository) CountItems(ctx context.Context) (int, error) {
 	return count, nil
 }
 
-func (s *repository) Create(ctx context.Context, req CreateReq, planID uuid.UUID, sequenceNo int) (*models.ChecklistItem, error) {
+/**
+* Creates a checklist item along with its checklist_item.created event.
+**/
+func (s *repository) Create(ctx context.Context, id uuid.UUID, req CreateReq, planID uuid.UUID, sequenceNo int) (*models.ChecklistItem, error) {
 	query := `
-	INSERT INTO checklist_items (description, done, sequence, scope, plan_id)
-	VALUES(:description, :done, :sequence, :scope, :plan_id)
-	RETURNING id, description, done, sequence, plan_id, scope, created_at, updated_at
+	INSERT INTO checklist_items (id, description, notes, done, sequence, scope, due_date, priority, effort_estimate, effort_unit, plan_id)
+	VALUES(:id, :description, :notes, :done, :sequence, :scope, :due_date, :priority, :effort_estimate, :effort_unit, :plan_id)
+	RETURNING id, description, notes, done, sequence, plan_id, scope, due_date, priority, effort_estimate, effort_unit, created_at, updated_at, version, reminder_lead_minutes
 	`
 
 	scope := constants.ScopeLongterm
@@ -161,101 +328,379 @@ func (s *repository) Create(ctx context.Context, req CreateReq, planID uuid.UUID
 		scope = constants.ChecklistItemScope(*req.Scope)
 	}
 
+	priority := constants.PriorityMedium
+	if req.Priority != nil {
+		priority = constants.ChecklistItemPriority(*req.Priority)
+	}
+
 	item := struct {
-		PlanID      uuid.UUID                    `db:"plan_id"`
-		Description string                       `db:"description"`
-		Done        bool                         `db:"done"`
-		Sequence    int                          `db:"sequence"`
-		Scope       constants.ChecklistItemScope `db:"scope"`
+		ID             uuid.UUID                       `db:"id"`
+		PlanID         uuid.UUID                       `db:"plan_id"`
+		Description    string                          `db:"description"`
+		Notes          *string                         `db:"notes"`
+		Done           bool                            `db:"done"`
+		Sequence       int                             `db:"sequence"`
+		Scope          constants.ChecklistItemScope    `db:"scope"`
+		DueDate        *time.Time                      `db:"due_date"`
+		Priority       constants.ChecklistItemPriority `db:"priority"`
+		EffortEstimate *int                            `db:"effort_estimate"`
+		EffortUnit     *string                         `db:"effort_unit"`
 	}{
-		PlanID:      planID,
-		Description: req.Description,
-		Done:        false,
-		Sequence:    sequenceNo,
-		Scope:       scope,
+		ID:             id,
+		PlanID:         planID,
+		Description:    req.Description,
+		Notes:          req.Notes,
+		Done:           false,
+		Sequence:       sequenceNo,
+		Scope:          scope,
+		DueDate:        req.DueDate,
+		Priority:       priority,
+		EffortEstimate: req.EffortEstimate,
+		EffortUnit:     req.EffortUnit,
 	}
 
 	newItem := &models.ChecklistItem{}
 
-	rows, err := s.db.NamedQueryContext(ctx, query, item)
+	err := dbutils.ExecTx(s.db, func(tx *sqlx.Tx) error {
+		rows, err := sqlx.NamedQueryContext(ctx, tx, query, item)
 
-	if err != nil {
-		fmt.Printf("Error from db when attempting to create item: %v\n", err)
-		return nil, errorutils.AnalyzeDBErr(err)
-	}
-	defer rows.Close()
+		if err != nil {
+			fmt.Printf("Error from db when attempting to create item: %v\n", err)
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		// acquire the first item
+		if !rows.Next() {
+			err := rows.Err()
+			rows.Close()
+			if err != nil {
+				return errorutils.AnalyzeDBErr(err)
+			}
+			return constants.ErrNotFound
+		}
 
-	// acquire the first item
-	if rows.Next() {
-		if err := rows.StructScan(newItem); err != nil {
+		err = rows.StructScan(newItem)
+		rows.Close()
+
+		if err != nil {
 			fmt.Printf("Error from db when attempting to scan created item: %v\n", err)
-			return nil, errorutils.AnalyzeDBErr(err)
+			return errorutils.AnalyzeDBErr(err)
 		}
-	} else {
-		return nil, constants.ErrNotFound
+
+		return events.Enqueue(ctx, tx, events.NewEvent{
+			Type:        constants.EventChecklistItemCreated,
+			PlanID:      &planID,
+			AggregateID: newItem.ID,
+			Payload:     newItem,
+		})
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
 	return newItem, nil
 }
 
+/**
+* Updates a checklist item along with its checklist_item.updated event, and the
+* checklist_item.completed and checklist_item.scheduled events when the update completes
+* or schedules it.
+**/
 func (s *repository) Update(ctx context.Context, id uuid.UUID, req UpdateReq) error {
 	query := `
 	UPDATE checklist_items
 	SET
 		description = COALESCE(:description, description),
+		notes = COALESCE(:notes, notes),
 		done = COALESCE(:done, done),
 		scope = COALESCE(:scope, scope),
-		archived = COALESCE(:archived, archived),`
+		archived = COALESCE(:archived, archived),
+		priority = COALESCE(:priority, priority),
+		effort_estimate = COALESCE(:effort_estimate, effort_estimate),
+		effort_unit = COALESCE(:effort_unit, effort_unit),
+		reminder_lead_minutes = COALESCE(CAST(:reminder_lead_minutes AS INTEGER[]), reminder_lead_minutes),`
 
-	// check if scheduled time exists, otherwise set it to nil to remove scheduled time
-	if req.ScheduledTime == nil {
+	// the due date is only removed when asked to explicitly
+	if req.ClearDueDate {
+		query += `
+		due_date = NULL,`
+	} else {
+		query += `
+		due_date = COALESCE(:due_date, due_date),`
+	}
+
+	// same for the scheduled time
+	if req.ClearScheduledTime {
 		query += `
 		scheduled_time = NULL`
 	} else {
 		query += `
-		scheduled_time = :scheduled_time`
+		scheduled_time = COALESCE(:scheduled_time, scheduled_time)`
 	}
 
-	// always add where clause
+	// always add where clause, only updating the expected version when one is provided
 	query += `
-	WHERE id = :id`
+	FROM (
+		SELECT id AS previous_id, done AS previous_done, scheduled_time AS previous_scheduled_time
+		FROM checklist_items
+		WHERE id = :id
+		FOR UPDATE
+	) previous
+	WHERE checklist_items.id = previous.previous_id
+	AND deleted_at IS NULL
+	AND (CAST(:version AS INTEGER) IS NULL OR version = :version)
+	RETURNING ` + prefixColumns("checklist_items", checklistItemColumns) + `, previous.previous_done, previous.previous_scheduled_time`
 
 	item := map[string]interface{}{
-		"id":             id,
-		"description":    req.Description,
-		"done":           req.Done,
-		"scope":          req.Scope,
-		"scheduled_time": req.ScheduledTime,
-		"archived":       req.Archived,
+		"id":              id,
+		"description":     req.Description,
+		"notes":           req.Notes,
+		"done":            req.Done,
+		"scope":           req.Scope,
+		"scheduled_time":  req.ScheduledTime,
+		"archived":        req.Archived,
+		"due_date":        req.DueDate,
+		"priority":        req.Priority,
+		"effort_estimate": req.EffortEstimate,
+		"effort_unit":     req.EffortUnit,
+		"version":         req.Version,
+
+		"reminder_lead_minutes": pq.Int64Array(req.ReminderLeadMinutes),
 	}
 
-	fmt.Printf("Updating id: %+v\n")
+	fmt.Printf("Updating id: %+v\n", id)
 	fmt.Printf("Updating checklist_items with item: %+v\n", item)
 	fmt.Printf("constructed query: %s\n", query)
 
-	result, err := s.db.NamedExecContext(ctx, query, item)
+	err := dbutils.ExecTx(s.db, func(tx *sqlx.Tx) error {
+		rows, err := sqlx.NamedQueryContext(ctx, tx, query, item)
+		if err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
 
-	return errorutils.AnalyzeDBResults(err, result)
+		if !rows.Next() {
+			rows.Close()
+			return constants.ErrNoRowsAffected
+		}
+
+		var updated updatedItem
+		err = rows.StructScan(&updated)
+		rows.Close()
+
+		if err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		// record completion history so it survives daily resets
+		if updated.Done && !updated.PreviousDone {
+			err = completions.Record(ctx, tx, id, time.Now())
+		} else if !updated.Done && updated.PreviousDone {
+			err = completions.Remove(ctx, tx, id, time.Now())
+		}
+		if err != nil {
+			return err
+		}
+
+		return events.Enqueue(ctx, tx, itemUpdateEvents(&updated.ChecklistItem, updated.PreviousDone, updated.PreviousScheduledTime)...)
+	})
+
+	if errors.Is(err, constants.ErrNoRowsAffected) && req.Version != nil {
+		return s.versionMismatchErr(ctx, id)
+	}
+
+	return err
 }
 
-func (s *repository) Delete(ctx context.Context, id uuid.UUID) error {
+// an updated item along with whether it was done and when it was scheduled before the update
+type updatedItem struct {
+	models.ChecklistItem
+	PreviousDone          bool       `db:"previous_done"`
+	PreviousScheduledTime *time.Time `db:"previous_scheduled_time"`
+}
+
+// one event of the type for every item, with the item as its payload
+func itemEvents(eventType constants.EventType, items []*models.ChecklistItem) []events.NewEvent {
+	itemEvents := make([]events.NewEvent, 0, len(items))
+	for _, item := range items {
+		itemEvents = append(itemEvents, events.NewEvent{
+			Type:        eventType,
+			PlanID:      &item.PlanID,
+			AggregateID: item.ID,
+			Payload:     item,
+		})
+	}
+	return itemEvents
+}
+
+// reads the active items with the ids as part of a transaction, in the order of their plan
+func getItemsTx(ctx context.Context, tx *sqlx.Tx, ids []uuid.UUID) ([]*models.ChecklistItem, error) {
+	items := []*models.ChecklistItem{}
+	err := tx.SelectContext(ctx, &items, `
+		SELECT `+checklistItemColumns+`
+		FROM checklist_items
+		WHERE id = ANY($1)
+		AND deleted_at IS NULL
+		ORDER BY plan_id, sequence ASC
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return items, nil
+}
+
+// events of an updated item, given whether it was done and when it was scheduled before
+func itemUpdateEvents(item *models.ChecklistItem, previousDone bool, previousScheduledTime *time.Time) []events.NewEvent {
+	newEvent := func(eventType constants.EventType) events.NewEvent {
+		return events.NewEvent{
+			Type:        eventType,
+			PlanID:      &item.PlanID,
+			AggregateID: item.ID,
+			Payload:     item,
+		}
+	}
+
+	itemEvents := []events.NewEvent{newEvent(constants.EventChecklistItemUpdated)}
+
+	if item.Done && !previousDone {
+		itemEvents = append(itemEvents, newEvent(constants.EventChecklistItemCompleted))
+	}
+
+	if item.ScheduledTime != nil && (previousScheduledTime == nil || !previousScheduledTime.Equal(*item.ScheduledTime)) {
+		itemEvents = append(itemEvents, newEvent(constants.EventChecklistItemScheduled))
+	}
+
+	return itemEvents
+}
+
+// the order of the items of a plan, the payload of checklist_item.reordered events
+type planOrder struct {
+	PlanID uuid.UUID      `json:"planId"`
+	Items  []ItemSnapshot `json:"items"`
+}
+
+/**
+* Builds a checklist_item.reordered event holding the current order of every item of a
+* plan. It is sent whenever items are shifted or enter or leave the plan in bulk, so that
+* clients can bring their list in line without a refetch.
+**/
+func planOrderEvent(ctx context.Context, tx *sqlx.Tx, planID uuid.UUID) (events.NewEvent, error) {
+	order := planOrder{PlanID: planID, Items: []ItemSnapshot{}}
+
+	err := tx.SelectContext(ctx, &order.Items, `
+		SELECT id, plan_id, sequence, archived
+		FROM checklist_items
+		WHERE plan_id = $1
+		AND deleted_at IS NULL
+		ORDER BY sequence ASC
+	`, planID)
+	if err != nil {
+		return events.NewEvent{}, errorutils.AnalyzeDBErr(err)
+	}
+
+	return events.NewEvent{
+		Type:        constants.EventChecklistItemReordered,
+		PlanID:      &planID,
+		AggregateID: planID,
+		Payload:     order,
+	}, nil
+}
+
+// enqueues a checklist_item.reordered event for each of the plans
+func enqueuePlanOrders(ctx context.Context, tx *sqlx.Tx, planIDs ...uuid.UUID) error {
+	seen := make(map[uuid.UUID]bool, len(planIDs))
+
+	for _, planID := range planIDs {
+		if seen[planID] {
+			continue
+		}
+		seen[planID] = true
+
+		event, err := planOrderEvent(ctx, tx, planID)
+		if err != nil {
+			return err
+		}
+
+		if err := events.Enqueue(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/**
+* Soft deletes a checklist item and records the deletion in the journal of the user so
+* that it can be undone, along with its checklist_item.deleted event. The item is purged
+* for good once the undo window has passed.
+**/
+func (s *repository) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID, version *int) (*Operation, error) {
 	query := `
-	DELETE FROM checklist_items
+	UPDATE checklist_items
+	SET deleted_at = NOW()
 	WHERE id = $1
+	AND deleted_at IS NULL
+	AND ($2::INTEGER IS NULL OR version = $2)
+	RETURNING id, plan_id, sequence, archived
 	`
-	_, err := s.db.ExecContext(ctx, query, id)
+
+	var op *Operation
+	err := dbutils.ExecTx(s.db, func(tx *sqlx.Tx) error {
+		var item ItemSnapshot
+		err := tx.GetContext(ctx, &item, query, id, version)
+		if errors.Is(err, sql.ErrNoRows) && version != nil {
+			return s.versionMismatchErr(ctx, id)
+		}
+		if err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		op, err = recordOperation(ctx, tx, userID, &item.PlanID, constants.OperationDelete, ItemSnapshots{item})
+		if err != nil {
+			return err
+		}
+
+		return events.Enqueue(ctx, tx, events.NewEvent{
+			Type:        constants.EventChecklistItemDeleted,
+			UserID:      &userID,
+			PlanID:      &item.PlanID,
+			AggregateID: item.ID,
+			Payload:     item,
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+/**
+* Tells apart an item that no longer matches the expected version from one that does not
+* exist at all after a versioned write affected no rows.
+**/
+func (s *repository) versionMismatchErr(ctx context.Context, id uuid.UUID) error {
+	var exists bool
+	err := s.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM checklist_items WHERE id = $1 AND deleted_at IS NULL)`, id)
 	if err != nil {
 		return errorutils.AnalyzeDBErr(err)
 	}
 
-	return nil
+	if exists {
+		return constants.ErrPreconditionFailed
+	}
+
+	return constants.ErrNotFound
 }
 
 func (s *repository) GetByID(ctx context.Context, id uuid.UUID) (*models.ChecklistItem, error) {
 	query := `
-	SELECT id, description, done, sequence, scope, scheduled_time, created_at, updated_at, plan_id
+	SELECT ` + checklistItemColumns + `
 	FROM checklist_items
 	WHERE id = $1
+	AND deleted_at IS NULL
 	`
 
 	var item models.ChecklistItem
@@ -267,59 +712,942 @@ func (s *repository) GetByID(ctx context.Context, id uuid.UUID) (*models.Checkli
 	return &item, nil
 }
 
+/**
+* Sets the done state of every item of a scope in a plan, recording completions and
+* writing the events of each updated item as Update does.
+**/
 func (s *repository) BatchUpdate(ctx context.Context, planId uuid.UUID, done *bool, scope *constants.ChecklistItemScope) error {
 	query := `
 	UPDATE checklist_items
 	SET
-		done = COALESCE(:done, done)
-	WHERE plan_id = :planId
-	AND scope = :scope
+		done = COALESCE($2, done)
+	FROM (
+		SELECT id AS previous_id, done AS previous_done, scheduled_time AS previous_scheduled_time
+		FROM checklist_items
+		WHERE plan_id = $1
+		AND scope = $3
+		AND deleted_at IS NULL
+		FOR UPDATE
+	) previous
+	WHERE checklist_items.id = previous.previous_id
+	RETURNING ` + prefixColumns("checklist_items", checklistItemColumns) + `, previous.previous_done, previous.previous_scheduled_time`
+
+	return dbutils.ExecTx(s.db, func(tx *sqlx.Tx) error {
+		updated := []*updatedItem{}
+		if err := tx.SelectContext(ctx, &updated, query, planId, done, scope); err != nil {
+			fmt.Printf("Error when updating all checklist items: %s\n", err.Error())
+
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		now := time.Now()
+		for _, item := range updated {
+			var err error
+			if item.Done && !item.PreviousDone {
+				err = completions.Record(ctx, tx, item.ID, now)
+			} else if !item.Done && item.PreviousDone {
+				err = completions.Remove(ctx, tx, item.ID, now)
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := events.Enqueue(ctx, tx, itemUpdateEvents(&item.ChecklistItem, item.PreviousDone, item.PreviousScheduledTime)...); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+/**
+* Resets the repeating checklist items of the plans with daily reset set as true that are
+* due, meaning it is past the reset hour of their user in the user's timezone and they
+* were not yet reset on the user's local date. The local date is recorded on each plan so
+* that a plan resets exactly once per local day, regardless of DST changes or restarts.
+* Daily items are always reset, weekly and monthly items only once they were last
+* completed before the current local week or month of their user started. A plan.reset
+* event is written to the outbox for every plan that was reset.
+**/
+func (r *repository) BulkResetRepeatingItems(ctx context.Context) (int64, error) {
+	query := `
+	WITH reset_users AS MATERIALIZED (
+		-- a timezone postgres does not know would fail the reset of every user, such users
+		-- are skipped before any time is converted
+		SELECT id, timezone, week_starts_on, reset_hour
+		FROM users
+		WHERE timezone IN (SELECT name FROM pg_timezone_names)
+	),
+	due_plans AS (
+		SELECT
+			plans.id,
+			reset_users.timezone,
+			reset_users.week_starts_on,
+			(NOW() AT TIME ZONE reset_users.timezone)::DATE AS local_date
+		FROM plans
+		JOIN reset_users ON plans.user_id = reset_users.id
+		WHERE plans.daily_reset = true
+		AND EXTRACT(HOUR FROM NOW() AT TIME ZONE reset_users.timezone) >= reset_users.reset_hour
+		AND COALESCE(plans.last_reset_date, (plans.created_at AT TIME ZONE reset_users.timezone)::DATE)
+			< (NOW() AT TIME ZONE reset_users.timezone)::DATE
+		FOR UPDATE OF plans SKIP LOCKED
+	),
+	reset_items AS (
+		UPDATE checklist_items SET
+			done = false
+		FROM due_plans
+		WHERE checklist_items.plan_id = due_plans.id
+		AND checklist_items.done = true
+		AND checklist_items.deleted_at IS NULL
+		AND (
+			checklist_items.scope = 'daily'
+			OR (
+				checklist_items.scope = 'weekly'
+				AND (COALESCE(
+					(SELECT MAX(completed_at) FROM checklist_item_completions WHERE checklist_item_id = checklist_items.id),
+					checklist_items.updated_at
+				) AT TIME ZONE due_plans.timezone)::DATE
+					< due_plans.local_date - ((EXTRACT(DOW FROM due_plans.local_date)::INTEGER - due_plans.week_starts_on + 7) % 7)
+			)
+			OR (
+				checklist_items.scope = 'monthly'
+				AND (COALESCE(
+					(SELECT MAX(completed_at) FROM checklist_item_completions WHERE checklist_item_id = checklist_items.id),
+					checklist_items.updated_at
+				) AT TIME ZONE due_plans.timezone)::DATE
+					< date_trunc('month', due_plans.local_date)::DATE
+			)
+		)
+		RETURNING checklist_items.id, checklist_items.plan_id
+	),
+	reset_plans AS (
+		UPDATE plans SET
+			last_reset_date = due_plans.local_date
+		FROM due_plans
+		WHERE plans.id = due_plans.id
+		RETURNING plans.id, plans.user_id, due_plans.local_date
+	)
+
+	-- a plan.reset event per plan, written along with the reset itself
+	INSERT INTO outbox_events (type, user_id, plan_id, aggregate_id, payload)
+	SELECT
+		'plan.reset',
+		reset_plans.user_id,
+		reset_plans.id,
+		reset_plans.id,
+		jsonb_build_object(
+			'planId', reset_plans.id,
+			'resetDate', reset_plans.local_date,
+			'itemIds', COALESCE((SELECT jsonb_agg(reset_items.id) FROM reset_items WHERE reset_items.plan_id = reset_plans.id), '[]'::JSONB)
+		)
+	FROM reset_plans
 	`
 
-	params := map[string]interface{}{
-		"done":  *done,
-		"scope": *scope,
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		fmt.Printf("Error when resetting repeating checklist items: %s\n", err.Error())
+
+		return 0, errorutils.AnalyzeDBErr(err)
 	}
 
-	_, err := s.db.NamedExecContext(ctx, query, params)
+	resetPlans, err := result.RowsAffected()
+	if err != nil {
+		return 0, errorutils.AnalyzeDBErr(err)
+	}
+
+	return resetPlans, nil
+}
+
+/**
+* SetArchived archives or restores a checklist item without touching its other fields,
+* along with its checklist_item.updated event.
+**/
+func (r *repository) SetArchived(ctx context.Context, id uuid.UUID, archived bool, version *int) error {
+	query := `
+	UPDATE checklist_items
+	SET archived = $2
+	WHERE id = $1
+	AND deleted_at IS NULL
+	AND ($3::INTEGER IS NULL OR version = $3)
+	RETURNING ` + checklistItemColumns
+
+	return dbutils.ExecTx(r.db, func(tx *sqlx.Tx) error {
+		var item models.ChecklistItem
+		err := tx.GetContext(ctx, &item, query, id, archived, version)
+		if errors.Is(err, sql.ErrNoRows) {
+			return r.versionMismatchErr(ctx, id)
+		}
+		if err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		return events.Enqueue(ctx, tx, itemEvents(constants.EventChecklistItemUpdated, []*models.ChecklistItem{&item})...)
+	})
+}
+
+/**
+* Archives a checklist item and records it in the journal of the user so that it can be
+* undone, along with its checklist_item.updated event.
+**/
+func (r *repository) Archive(ctx context.Context, userID uuid.UUID, id uuid.UUID, version *int) (*Operation, error) {
+	query := `
+	UPDATE checklist_items ci
+	SET archived = true
+	FROM (
+		SELECT id, archived
+		FROM checklist_items
+		WHERE id = $1
+		AND deleted_at IS NULL
+		AND ($2::INTEGER IS NULL OR version = $2)
+		FOR UPDATE
+	) previous
+	WHERE ci.id = previous.id
+	RETURNING ci.id, ci.plan_id, ci.sequence, previous.archived
+	`
+
+	var op *Operation
+	err := dbutils.ExecTx(r.db, func(tx *sqlx.Tx) error {
+		var item ItemSnapshot
+		err := tx.GetContext(ctx, &item, query, id, version)
+		if errors.Is(err, sql.ErrNoRows) && version != nil {
+			return r.versionMismatchErr(ctx, id)
+		}
+		if err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		op, err = recordOperation(ctx, tx, userID, &item.PlanID, constants.OperationArchive, ItemSnapshots{item})
+		if err != nil {
+			return err
+		}
+
+		archived, err := getItemsTx(ctx, tx, []uuid.UUID{item.ID})
+		if err != nil {
+			return err
+		}
+
+		return events.Enqueue(ctx, tx, itemEvents(constants.EventChecklistItemUpdated, archived)...)
+	})
 
 	if err != nil {
-		fmt.Printf("Error when updating all checklist items: %s\n", err.Error())
+		return nil, err
+	}
 
-		return errorutils.AnalyzeDBErr(err)
+	return op, nil
+}
+
+/**
+* Finds the active checklist items matched by the auto archive policy of their plan:
+* longterm items completed more than the configured number of days ago, and items whose
+* scheduled time has passed. When dryRun is false the matched items are archived along
+* with their checklist_item.updated events, and when a user is provided the archiving is
+* recorded in their journal so that it can be undone. A nil planID runs the policies of
+* every plan.
+**/
+func (r *repository) AutoArchive(ctx context.Context, userID *uuid.UUID, planID *uuid.UUID, dryRun bool) ([]*ArchiveCandidate, *Operation, error) {
+	candidates := `
+	WITH candidates AS (
+		SELECT
+			ci.id,
+			CASE
+				WHEN p.auto_archive_past_scheduled
+					AND ci.scheduled_time IS NOT NULL
+					AND ci.scheduled_time < CURRENT_TIMESTAMP
+				THEN '` + string(constants.ArchiveReasonPastScheduled) + `'
+				ELSE '` + string(constants.ArchiveReasonCompleted) + `'
+			END AS reason
+		FROM checklist_items ci
+		JOIN plans p ON ci.plan_id = p.id
+		LEFT JOIN LATERAL (
+			SELECT MAX(completed_at) AS completed_at
+			FROM checklist_item_completions
+			WHERE checklist_item_id = ci.id
+		) last_completion ON true
+		WHERE ci.archived = false
+		AND ci.deleted_at IS NULL
+		AND ($1::uuid IS NULL OR ci.plan_id = $1)
+		AND (
+			(
+				p.auto_archive_completed_after_days IS NOT NULL
+				AND ci.scope = 'longterm'
+				AND ci.done = true
+				AND COALESCE(last_completion.completed_at, ci.updated_at)
+					<= CURRENT_TIMESTAMP - make_interval(days => p.auto_archive_completed_after_days)
+			)
+			OR (
+				p.auto_archive_past_scheduled
+				AND ci.scheduled_time IS NOT NULL
+				AND ci.scheduled_time < CURRENT_TIMESTAMP
+			)
+		)
+	)
+	`
+
+	var query string
+	if dryRun {
+		query = candidates + `
+		SELECT ` + prefixColumns("ci", checklistItemColumns) + `, candidates.reason
+		FROM checklist_items ci
+		JOIN candidates ON candidates.id = ci.id
+		ORDER BY ci.plan_id, ci.sequence
+		`
+	} else {
+		query = candidates + `
+		UPDATE checklist_items ci
+		SET archived = true
+		FROM candidates
+		WHERE candidates.id = ci.id
+		RETURNING ` + prefixColumns("ci", checklistItemColumns) + `, candidates.reason
+		`
 	}
 
-	return nil
+	archived := []*ArchiveCandidate{}
+	var op *Operation
+
+	err := dbutils.ExecTx(r.db, func(tx *sqlx.Tx) error {
+		if err := tx.SelectContext(ctx, &archived, query, planID); err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		if dryRun || len(archived) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(archived))
+		items := make([]*models.ChecklistItem, 0, len(archived))
+		for _, item := range archived {
+			ids = append(ids, item.ID)
+			items = append(items, &item.ChecklistItem)
+		}
+
+		if err := events.Enqueue(ctx, tx, itemEvents(constants.EventChecklistItemUpdated, items)...); err != nil {
+			return err
+		}
+
+		if userID == nil {
+			return nil
+		}
+
+		snapshot, err := snapshotItems(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+
+		// candidates are always active items, so undoing restores them as not archived
+		for i := range snapshot {
+			snapshot[i].Archived = false
+		}
+
+		op, err = recordOperation(ctx, tx, *userID, planID, constants.OperationAutoArchive, snapshot)
+		return err
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return archived, op, nil
 }
 
 /**
-* Reset all checklist items with daily reset column set as true for all plans under a user.
+* Checks that every provided plan belongs to the user.
 **/
-func (r *repository) BulkResetDailyItems(ctx context.Context) error {
+func (r *repository) PlansOwnedBy(ctx context.Context, userID uuid.UUID, planIDs []uuid.UUID) (bool, error) {
 	query := `
-	WITH items_to_update AS (
-	SELECT 
-		checklist_items.id as id,
-		checklist_items.done as done,
-		checklist_items.scope as scope,
-		plans.daily_reset as daily_reset
-	FROM checklist_items
-	JOIN plans ON checklist_items.plan_id = plans.id
-	WHERE done = true 
-	AND daily_reset = true
-	AND scope = 'daily'
+	SELECT COUNT(DISTINCT id)
+	FROM plans
+	WHERE id = ANY($1)
+	AND user_id = $2
+	`
+
+	unique := make(map[uuid.UUID]bool, len(planIDs))
+	for _, id := range planIDs {
+		unique[id] = true
+	}
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, pq.Array(planIDs), userID)
+	if err != nil {
+		return false, errorutils.AnalyzeDBErr(err)
+	}
+
+	return count == len(unique), nil
+}
+
+/**
+* Moves checklist items to another plan, keeping the order they were provided in. Their
+* completion history and logged time move along with them. The move is recorded in the
+* journal of the user so that it can be undone.
+**/
+func (r *repository) MoveToPlan(ctx context.Context, userID uuid.UUID, itemIDs []uuid.UUID, sourcePlanID uuid.UUID, targetPlanID uuid.UUID, position *int) ([]*models.ChecklistItem, *Operation, error) {
+	moved := make([]*models.ChecklistItem, 0, len(itemIDs))
+	var op *Operation
+
+	err := dbutils.ExecTx(r.db, func(tx *sqlx.Tx) error {
+		// where the items were before the move, so that it can be undone
+		var snapshot ItemSnapshots
+		err := tx.SelectContext(ctx, &snapshot, `
+			SELECT id, plan_id, sequence, archived
+			FROM checklist_items
+			WHERE id = ANY($1)
+			AND plan_id = $2
+			AND deleted_at IS NULL
+			ORDER BY sequence ASC
+			FOR UPDATE
+		`, pq.Array(itemIDs), sourcePlanID)
+		if err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		if len(snapshot) != len(itemIDs) {
+			return constants.ErrNotFound
+		}
+
+		sequence, err := reserveSequences(ctx, tx, targetPlanID, position, len(itemIDs))
+		if err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf(`
+		UPDATE checklist_items
+		SET plan_id = $1, sequence = $2
+		WHERE id = $3
+		AND plan_id = $4
+		AND deleted_at IS NULL
+		RETURNING %s
+		`, checklistItemColumns)
+
+		for i, id := range itemIDs {
+			var item models.ChecklistItem
+			if err := tx.GetContext(ctx, &item, query, targetPlanID, sequence+i, id, sourcePlanID); err != nil {
+				return errorutils.AnalyzeDBErr(err)
+			}
+			moved = append(moved, &item)
+		}
+
+		// history belongs to the item, so it follows it to the new plan
+		if _, err := tx.ExecContext(ctx, `UPDATE checklist_item_completions SET plan_id = $1 WHERE checklist_item_id = ANY($2)`, targetPlanID, pq.Array(itemIDs)); err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE time_entries SET plan_id = $1 WHERE checklist_item_id = ANY($2)`, targetPlanID, pq.Array(itemIDs)); err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		if err := events.Enqueue(ctx, tx, itemEvents(constants.EventChecklistItemUpdated, moved)...); err != nil {
+			return err
+		}
+
+		if err := enqueuePlanOrders(ctx, tx, sourcePlanID, targetPlanID); err != nil {
+			return err
+		}
+
+		op, err = recordOperation(ctx, tx, userID, &sourcePlanID, constants.OperationMove, snapshot)
+		return err
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return moved, op, nil
+}
+
+/**
+* Copies checklist items into another plan. Copies keep their content, scope, schedule
+* and links but start out not done and without completion history. The copy is recorded
+* in the journal of the user so that it can be undone.
+**/
+func (r *repository) CopyToPlan(ctx context.Context, userID uuid.UUID, itemIDs []uuid.UUID, sourcePlanID uuid.UUID, targetPlanID uuid.UUID, position *int) ([]*models.ChecklistItem, *Operation, error) {
+	copied := make([]*models.ChecklistItem, 0, len(itemIDs))
+	var op *Operation
+
+	err := dbutils.ExecTx(r.db, func(tx *sqlx.Tx) error {
+		sequence, err := reserveSequences(ctx, tx, targetPlanID, position, len(itemIDs))
+		if err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf(`
+		INSERT INTO checklist_items (description, notes, done, sequence, scope, scheduled_time, reminder_lead_minutes, due_date, priority, effort_estimate, effort_unit, archived, plan_id)
+		SELECT description, notes, false, $2, scope, scheduled_time, reminder_lead_minutes, due_date, priority, effort_estimate, effort_unit, false, $1
+		FROM checklist_items
+		WHERE id = $3
+		AND plan_id = $4
+		AND deleted_at IS NULL
+		RETURNING %s
+		`, checklistItemColumns)
+
+		linksQuery := `
+		INSERT INTO checklist_item_links (checklist_item_id, url, title, description, site_name, image_url, resource_type, metadata_fetched_at)
+		SELECT $1, url, title, description, site_name, image_url, resource_type, metadata_fetched_at
+		FROM checklist_item_links
+		WHERE checklist_item_id = $2
+		`
+
+		for i, id := range itemIDs {
+			var item models.ChecklistItem
+			if err := tx.GetContext(ctx, &item, query, targetPlanID, sequence+i, id, sourcePlanID); err != nil {
+				return errorutils.AnalyzeDBErr(err)
+			}
+
+			if _, err := tx.ExecContext(ctx, linksQuery, item.ID, id); err != nil {
+				return errorutils.AnalyzeDBErr(err)
+			}
+
+			copied = append(copied, &item)
+		}
+
+		// undoing a copy removes the copies again
+		ids := make([]uuid.UUID, 0, len(copied))
+		for _, item := range copied {
+			ids = append(ids, item.ID)
+		}
+
+		snapshot, err := snapshotItems(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+
+		if err := events.Enqueue(ctx, tx, itemEvents(constants.EventChecklistItemCreated, copied)...); err != nil {
+			return err
+		}
+
+		if err := enqueuePlanOrders(ctx, tx, targetPlanID); err != nil {
+			return err
+		}
+
+		op, err = recordOperation(ctx, tx, userID, &sourcePlanID, constants.OperationCopy, snapshot)
+		return err
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return copied, op, nil
+}
+
+/**
+* Makes room for a number of items in a plan's ordering and returns the first sequence
+* they should use. Without a position the items go after the last item of the plan,
+* otherwise every item from that position onwards is shifted back.
+**/
+func reserveSequences(ctx context.Context, tx *sqlx.Tx, planID uuid.UUID, position *int, count int) (int, error) {
+	var sequences []int
+	err := tx.SelectContext(ctx, &sequences, `
+		SELECT sequence
+		FROM checklist_items
+		WHERE plan_id = $1
+		AND deleted_at IS NULL
+		ORDER BY sequence ASC
+		FOR UPDATE
+	`, planID)
+	if err != nil {
+		return 0, errorutils.AnalyzeDBErr(err)
+	}
+
+	if position == nil || *position > len(sequences) {
+		if len(sequences) == 0 {
+			return 1, nil
+		}
+		return sequences[len(sequences)-1] + 1, nil
+	}
+
+	start := sequences[*position-1]
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE checklist_items
+		SET sequence = sequence + $1
+		WHERE plan_id = $2
+		AND sequence >= $3
+	`, count, planID, start)
+	if err != nil {
+		return 0, errorutils.AnalyzeDBErr(err)
+	}
+
+	return start, nil
+}
+
+const operationColumns = `id, user_id, plan_id, kind, snapshot, created_at, expires_at, undone_at`
+
+/**
+* Records an operation in the journal of a user as part of the transaction of the
+* operation itself, the returned operation's id is the undo token.
+**/
+func recordOperation(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, planID *uuid.UUID, kind constants.ChecklistOperationKind, snapshot ItemSnapshots) (*Operation, error) {
+	query := fmt.Sprintf(`
+	INSERT INTO checklist_operations (user_id, plan_id, kind, snapshot, expires_at)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING %s
+	`, operationColumns)
+
+	var op Operation
+	err := tx.GetContext(ctx, &op, query, userID, planID, string(kind), snapshot, time.Now().Add(undoWindow))
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &op, nil
+}
+
+// reads the current place and archived state of items for the journal
+func snapshotItems(ctx context.Context, tx *sqlx.Tx, ids []uuid.UUID) (ItemSnapshots, error) {
+	var snapshot ItemSnapshots
+	err := tx.SelectContext(ctx, &snapshot, `
+		SELECT id, plan_id, sequence, archived
+		FROM checklist_items
+		WHERE id = ANY($1)
+		ORDER BY sequence ASC
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return snapshot, nil
+}
+
+/**
+* Reverts an operation from the journal of a user. Deleted and moved items are put back
+* at their previous place in the ordering of their plan, archived items are restored and
+* copies are deleted again. The items are announced as created, updated or deleted
+* accordingly.
+**/
+func (r *repository) Undo(ctx context.Context, userID uuid.UUID, token uuid.UUID) (*Operation, error) {
+	var op Operation
+
+	err := dbutils.ExecTx(r.db, func(tx *sqlx.Tx) error {
+		query := fmt.Sprintf(`
+		SELECT %s
+		FROM checklist_operations
+		WHERE id = $1
+		AND user_id = $2
+		FOR UPDATE
+		`, operationColumns)
+
+		if err := tx.GetContext(ctx, &op, query, token, userID); err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		if op.UndoneAt != nil {
+			return constants.ErrAlreadyUndone
+		}
+
+		if time.Now().After(op.ExpiresAt) {
+			return constants.ErrUndoExpired
+		}
+
+		// the plans the items are in now and were in before, whose order the undo changes
+		ids := make([]uuid.UUID, 0, len(op.Snapshot))
+		planIDs := make([]uuid.UUID, 0, len(op.Snapshot))
+		for _, item := range op.Snapshot {
+			ids = append(ids, item.ID)
+			planIDs = append(planIDs, item.PlanID)
+		}
+
+		var currentPlanIDs []uuid.UUID
+		if err := tx.SelectContext(ctx, &currentPlanIDs, `SELECT DISTINCT plan_id FROM checklist_items WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+		planIDs = append(planIDs, currentPlanIDs...)
+
+		var err error
+		eventType := constants.EventChecklistItemUpdated
+		switch constants.ChecklistOperationKind(op.Kind) {
+		case constants.OperationDelete:
+			err = restoreItems(ctx, tx, op.Snapshot, true)
+			eventType = constants.EventChecklistItemCreated
+		case constants.OperationMove:
+			err = restoreItems(ctx, tx, op.Snapshot, false)
+		case constants.OperationArchive, constants.OperationAutoArchive:
+			err = restoreArchived(ctx, tx, op.Snapshot)
+		case constants.OperationCopy:
+			err = removeCopies(ctx, tx, op.Snapshot)
+			eventType = constants.EventChecklistItemDeleted
+		default:
+			err = fmt.Errorf("operation kind %s cannot be undone", op.Kind)
+		}
+		if err != nil {
+			return err
+		}
+
+		// removed copies are announced by their snapshot as deletes are, the other items as they are now
+		var undoneEvents []events.NewEvent
+		if eventType == constants.EventChecklistItemDeleted {
+			for _, item := range op.Snapshot {
+				undoneEvents = append(undoneEvents, events.NewEvent{
+					Type:        eventType,
+					UserID:      &userID,
+					PlanID:      &item.PlanID,
+					AggregateID: item.ID,
+					Payload:     item,
+				})
+			}
+		} else {
+			items, err := getItemsTx(ctx, tx, ids)
+			if err != nil {
+				return err
+			}
+			undoneEvents = itemEvents(eventType, items)
+		}
+
+		if err := events.Enqueue(ctx, tx, undoneEvents...); err != nil {
+			return err
+		}
+
+		if err := enqueuePlanOrders(ctx, tx, planIDs...); err != nil {
+			return err
+		}
+
+		query = fmt.Sprintf(`
+		UPDATE checklist_operations
+		SET undone_at = NOW()
+		WHERE id = $1
+		RETURNING %s
+		`, operationColumns)
+
+		return errorutils.AnalyzeDBErr(tx.GetContext(ctx, &op, query, token))
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &op, nil
+}
+
+/**
+* Puts items back into their plan at the sequence they had before the operation. When
+* another item has taken that place since, it and every item after it are shifted back.
+* Items are restored from the front so that items restored together keep their order.
+**/
+func restoreItems(ctx context.Context, tx *sqlx.Tx, snapshot ItemSnapshots, deleted bool) error {
+	items := make(ItemSnapshots, len(snapshot))
+	copy(items, snapshot)
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Sequence < items[j].Sequence
+	})
+
+	shiftQuery := `
+	UPDATE checklist_items
+	SET sequence = sequence + 1
+	WHERE plan_id = $1
+	AND sequence >= $2
+	AND id <> $3
+	AND EXISTS (
+		SELECT 1
+		FROM checklist_items
+		WHERE plan_id = $1
+		AND sequence = $2
+		AND id <> $3
+		AND deleted_at IS NULL
 	)
+	`
 
-	UPDATE checklist_items SET
-		done = false
-	WHERE id IN (SELECT id FROM items_to_update)
+	// deleted items are brought back, moved items are only moved back if still around
+	restoreQuery := `
+	UPDATE checklist_items
+	SET plan_id = $1, sequence = $2, deleted_at = NULL
+	WHERE id = $3
+	AND deleted_at IS NOT NULL
 	`
+	if !deleted {
+		restoreQuery = `
+		UPDATE checklist_items
+		SET plan_id = $1, sequence = $2
+		WHERE id = $3
+		AND deleted_at IS NULL
+		`
+	}
 
-	_, err := r.db.ExecContext(ctx, query)
+	for _, item := range items {
+		if _, err := tx.ExecContext(ctx, shiftQuery, item.PlanID, item.Sequence, item.ID); err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		result, err := tx.ExecContext(ctx, restoreQuery, item.PlanID, item.Sequence, item.ID)
+		if err := errorutils.AnalyzeDBResults(err, result); err != nil {
+			if errors.Is(err, constants.ErrNoRowsAffected) && !deleted {
+				continue
+			}
+			if errors.Is(err, constants.ErrNoRowsAffected) {
+				return constants.ErrNotFound
+			}
+			return err
+		}
 
+		if deleted {
+			continue
+		}
+
+		// history followed the item when it was moved, so it moves back with it
+		if _, err := tx.ExecContext(ctx, `UPDATE checklist_item_completions SET plan_id = $1 WHERE checklist_item_id = $2`, item.PlanID, item.ID); err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE time_entries SET plan_id = $1 WHERE checklist_item_id = $2`, item.PlanID, item.ID); err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+	}
+
+	return nil
+}
+
+// sets the archived state of items back to what it was before the operation
+func restoreArchived(ctx context.Context, tx *sqlx.Tx, snapshot ItemSnapshots) error {
+	for _, item := range snapshot {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE checklist_items
+			SET archived = $2
+			WHERE id = $1
+			AND deleted_at IS NULL
+		`, item.ID, item.Archived)
+		if err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+	}
+
+	return nil
+}
+
+// soft deletes the items created by a copy
+func removeCopies(ctx context.Context, tx *sqlx.Tx, snapshot ItemSnapshots) error {
+	ids := make([]uuid.UUID, 0, len(snapshot))
+	for _, item := range snapshot {
+		ids = append(ids, item.ID)
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE checklist_items
+		SET deleted_at = NOW()
+		WHERE id = ANY($1)
+		AND deleted_at IS NULL
+	`, pq.Array(ids))
+
+	return errorutils.AnalyzeDBErr(err)
+}
+
+// GetOperations returns the operation journal of a user, most recent first
+func (r *repository) GetOperations(ctx context.Context, userID uuid.UUID, page *pageutils.Params) ([]*Operation, *pageutils.Page, error) {
+	query := fmt.Sprintf(`
+	SELECT %s
+	FROM checklist_operations
+	WHERE user_id = $1
+	`, operationColumns)
+
+	keys := []pageutils.Key{{Column: "created_at", Desc: true}}
+
+	query, args, err := pageutils.Apply(query, []interface{}{userID}, keys, page)
 	if err != nil {
+		return nil, nil, err
+	}
+
+	operations := []*Operation{}
+	if err := r.db.SelectContext(ctx, &operations, query, args...); err != nil {
+		return nil, nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	operations, pageInfo := pageutils.Paginate(operations, page, func(op *Operation) (uuid.UUID, []interface{}) {
+		return op.ID, []interface{}{op.CreatedAt}
+	})
+
+	return operations, pageInfo, nil
+}
+
+/**
+* Permanently removes the items deleted before the cutoff along with the journal entries
+* that expired before the journal cutoff. Returns the number of purged items.
+**/
+func (r *repository) PurgeDeleted(ctx context.Context, itemsBefore time.Time, journalBefore time.Time) (int64, error) {
+	var purged int64
+
+	err := dbutils.ExecTx(r.db, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, `DELETE FROM checklist_items WHERE deleted_at < $1`, itemsBefore)
+		if err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		purged, err = result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM checklist_operations WHERE expires_at < $1`, journalBefore)
 		return errorutils.AnalyzeDBErr(err)
+	})
+
+	return purged, err
+}
+
+/**
+* Claims the reminders of scheduled items that are due, i.e. their scheduled time minus
+* one of their lead times has been reached but is not older than the grace cutoff.
+* Reminders are claimed by inserting their delivery state, so a reminder that was already
+* claimed is skipped unless its claim was never delivered and is older than the reclaim
+* cutoff.
+**/
+func (r *repository) ClaimDueReminders(ctx context.Context, now time.Time, graceCutoff time.Time, reclaimCutoff time.Time) ([]*Reminder, error) {
+	query := `
+	WITH due AS (
+		SELECT
+			ci.id AS checklist_item_id,
+			p.user_id,
+			lead.minutes AS lead_minutes,
+			ci.scheduled_time,
+			ci.scheduled_time - make_interval(mins => lead.minutes) AS remind_at
+		FROM checklist_items ci
+		JOIN plans p ON ci.plan_id = p.id
+		CROSS JOIN LATERAL (
+			SELECT DISTINCT unnest(ci.reminder_lead_minutes) AS minutes
+		) lead
+		WHERE ci.scheduled_time IS NOT NULL
+		AND ci.done = false
+		AND ci.archived = false
+		AND ci.deleted_at IS NULL
+		AND ci.scheduled_time - make_interval(mins => lead.minutes) <= $1
+		AND ci.scheduled_time - make_interval(mins => lead.minutes) > $2
+	), claimed AS (
+		INSERT INTO checklist_item_reminders (checklist_item_id, user_id, lead_minutes, scheduled_time, remind_at, claimed_at)
+		SELECT checklist_item_id, user_id, lead_minutes, scheduled_time, remind_at, $1
+		FROM due
+		ON CONFLICT (checklist_item_id, lead_minutes, scheduled_time) DO UPDATE
+		SET claimed_at = EXCLUDED.claimed_at
+		WHERE checklist_item_reminders.delivered_at IS NULL
+		AND checklist_item_reminders.claimed_at < $3
+		RETURNING id, checklist_item_id, user_id, lead_minutes, scheduled_time, remind_at
+	)
+	SELECT
+		claimed.id,
+		claimed.checklist_item_id,
+		claimed.user_id,
+		claimed.lead_minutes,
+		claimed.scheduled_time,
+		claimed.remind_at,
+		ci.plan_id,
+		ci.description
+	FROM claimed
+	JOIN checklist_items ci ON ci.id = claimed.checklist_item_id
+	ORDER BY claimed.remind_at ASC
+	`
+
+	reminders := []*Reminder{}
+	err := r.db.SelectContext(ctx, &reminders, query, now, graceCutoff, reclaimCutoff)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
 	}
 
-	return nil
+	return reminders, nil
+}
+
+// MarkReminderDelivered records that a claimed reminder was handed to the dispatcher
+func (r *repository) MarkReminderDelivered(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE checklist_item_reminders SET delivered_at = NOW() WHERE id = $1`, id)
+	return errorutils.AnalyzeDBResults(err, result)
+}
+
+// ReleaseReminder gives up the claim of a reminder that could not be dispatched so that it is retried
+func (r *repository) ReleaseReminder(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM checklist_item_reminders WHERE id = $1 AND delivered_at IS NULL`, id)
+	return errorutils.AnalyzeDBErr(err)
 }
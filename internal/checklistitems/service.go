@@ -2,33 +2,76 @@ package checklistitems
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/darkphotonKN/fireplace/internal/constants"
 	"github.com/darkphotonKN/fireplace/internal/models"
+	"github.com/darkphotonKN/fireplace/internal/notifications"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
+	"github.com/darkphotonKN/fireplace/internal/utils/sanitizeutils"
 	"github.com/google/uuid"
 )
 
+const (
+	maxNotesLength   = 20000
+	maxTransferItems = 100
+
+	// how long operations can be undone, deleted items are purged for good afterwards
+	undoWindow = 24 * time.Hour
+	// how long undone and expired operations stay in the journal
+	journalRetention = 30 * 24 * time.Hour
+
+	maxReminders           = 5
+	maxReminderLeadMinutes = 7 * 24 * 60
+	// reminders that were missed for longer are not sent anymore, unless caught up on after downtime
+	reminderGracePeriod = time.Hour
+	// reminders missed during downtime are caught up on for at most this long
+	maxReminderCatchUp = 24 * time.Hour
+	// claimed reminders that were not delivered within this time are claimed again
+	reminderClaimTimeout = 5 * time.Minute
+)
+
 type service struct {
-	repo Repository
+	repo               Repository
+	reminderDispatcher ReminderDispatcher
+}
+
+type ReminderDispatcher interface {
+	Dispatch(ctx context.Context, notification notifications.Notification) error
 }
 
 type Repository interface {
-	Create(ctx context.Context, req CreateReq, planID uuid.UUID, sequenceNo int) (*models.ChecklistItem, error)
+	Create(ctx context.Context, id uuid.UUID, req CreateReq, planID uuid.UUID, sequenceNo int) (*models.ChecklistItem, error)
 	Update(ctx context.Context, id uuid.UUID, req UpdateReq) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID, version *int) (*Operation, error)
 	GetAll(ctx context.Context, scope *string) ([]*models.ChecklistItem, error)
-	GetAllByPlanId(ctx context.Context, planId uuid.UUID, scope *string, upcoming *string) ([]*models.ChecklistItem, error)
-	GetAllArchivedByPlanId(ctx context.Context, planId uuid.UUID, scope *string) ([]*models.ChecklistItem, error)
+	GetAllByPlanId(ctx context.Context, planId uuid.UUID, opts ListOptions) ([]*models.ChecklistItem, *pageutils.Page, error)
+	GetAllArchivedByPlanId(ctx context.Context, planId uuid.UUID, opts ListOptions) ([]*models.ChecklistItem, *pageutils.Page, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*models.ChecklistItem, error)
 	CountItems(ctx context.Context) (int, error)
-	BulkResetDailyItems(ctx context.Context) error
+	BulkResetRepeatingItems(ctx context.Context) (int64, error)
+	PlansOwnedBy(ctx context.Context, userID uuid.UUID, planIDs []uuid.UUID) (bool, error)
+	MoveToPlan(ctx context.Context, userID uuid.UUID, itemIDs []uuid.UUID, sourcePlanID uuid.UUID, targetPlanID uuid.UUID, position *int) ([]*models.ChecklistItem, *Operation, error)
+	CopyToPlan(ctx context.Context, userID uuid.UUID, itemIDs []uuid.UUID, sourcePlanID uuid.UUID, targetPlanID uuid.UUID, position *int) ([]*models.ChecklistItem, *Operation, error)
+	SetArchived(ctx context.Context, id uuid.UUID, archived bool, version *int) error
+	Archive(ctx context.Context, userID uuid.UUID, id uuid.UUID, version *int) (*Operation, error)
+	AutoArchive(ctx context.Context, userID *uuid.UUID, planID *uuid.UUID, dryRun bool) ([]*ArchiveCandidate, *Operation, error)
+	Undo(ctx context.Context, userID uuid.UUID, token uuid.UUID) (*Operation, error)
+	GetOperations(ctx context.Context, userID uuid.UUID, page *pageutils.Params) ([]*Operation, *pageutils.Page, error)
+	PurgeDeleted(ctx context.Context, itemsBefore time.Time, journalBefore time.Time) (int64, error)
+	ClaimDueReminders(ctx context.Context, now time.Time, graceCutoff time.Time, reclaimCutoff time.Time) ([]*Reminder, error)
+	MarkReminderDelivered(ctx context.Context, id uuid.UUID) error
+	ReleaseReminder(ctx context.Context, id uuid.UUID) error
 }
 
-func NewService(repo Repository) *service {
+func NewService(repo Repository, reminderDispatcher ReminderDispatcher) *service {
 	return &service{
-		repo: repo,
+		repo:               repo,
+		reminderDispatcher: reminderDispatcher,
 	}
 }
 
@@ -36,30 +79,20 @@ func (s *service) GetAll(ctx context.Context, scope *string) ([]*models.Checklis
 	return s.repo.GetAll(ctx, scope)
 }
 
-func (s *service) GetAllByPlanId(ctx context.Context, planId uuid.UUID, scope *string, upcoming *string) ([]*models.ChecklistItem, error) {
-	if scope != nil {
-		if *scope != string(constants.ScopeLongterm) && *scope != string(constants.ScopeDaily) {
-			return nil, fmt.Errorf("scope must be either 'daily' or 'longterm'")
-		}
+func (s *service) GetAllByPlanId(ctx context.Context, planId uuid.UUID, opts ListOptions) ([]*models.ChecklistItem, *pageutils.Page, error) {
+	if err := validateListOptions(opts); err != nil {
+		return nil, nil, err
 	}
 
-	if upcoming != nil {
-		if *upcoming != string(constants.UpcomingWeek) && *upcoming != string(constants.UpcomingMonth) {
-			return nil, fmt.Errorf("Upcoming needs to be either 'week' or 'month'")
-		}
-	}
-	return s.repo.GetAllByPlanId(ctx, planId, scope, upcoming)
+	return s.repo.GetAllByPlanId(ctx, planId, opts)
 }
 
-func (s *service) GetAllArchivedByPlanId(ctx context.Context, planId uuid.UUID, scope *string) ([]*models.ChecklistItem, error) {
-	// Validate scope if provided
-	if scope != nil {
-		if *scope != string(constants.ScopeLongterm) && *scope != string(constants.ScopeDaily) {
-			return nil, fmt.Errorf("scope must be either 'daily' or 'longterm'")
-		}
+func (s *service) GetAllArchivedByPlanId(ctx context.Context, planId uuid.UUID, opts ListOptions) ([]*models.ChecklistItem, *pageutils.Page, error) {
+	if err := validateListOptions(opts); err != nil {
+		return nil, nil, err
 	}
 
-	return s.repo.GetAllArchivedByPlanId(ctx, planId, scope)
+	return s.repo.GetAllArchivedByPlanId(ctx, planId, opts)
 }
 
 func (s *service) GetByID(ctx context.Context, id uuid.UUID) (*models.ChecklistItem, error) {
@@ -67,6 +100,11 @@ func (s *service) GetByID(ctx context.Context, id uuid.UUID) (*models.ChecklistI
 }
 
 func (s *service) Create(ctx context.Context, req CreateReq, planID uuid.UUID) (*models.ChecklistItem, error) {
+	return s.CreateWithID(ctx, uuid.New(), req, planID)
+}
+
+// creates a checklist item under an id chosen by the client, used by items created offline
+func (s *service) CreateWithID(ctx context.Context, id uuid.UUID, req CreateReq, planID uuid.UUID) (*models.ChecklistItem, error) {
 	// count number of current items in table
 	count, err := s.repo.CountItems(ctx)
 
@@ -76,27 +114,86 @@ func (s *service) Create(ctx context.Context, req CreateReq, planID uuid.UUID) (
 
 	// validate scope
 	if req.Scope != nil {
-		if *req.Scope != string(constants.ScopeLongterm) && *req.Scope != string(constants.ScopeDaily) {
-			return nil, fmt.Errorf("Scope can only be either daily or longterm.")
+		if err := constants.ValidateScope(*req.Scope); err != nil {
+			return nil, err
 		}
 	}
 
+	if req.Priority != nil && !isValidPriority(*req.Priority) {
+		return nil, errorutils.Invalidf("priority must be one of 'low', 'medium', 'high' or 'urgent'")
+	}
+
+	effortUnit, err := validateEffort(req.EffortEstimate, req.EffortUnit)
+	if err != nil {
+		return nil, err
+	}
+	req.EffortUnit = effortUnit
+
+	notes, err := sanitizeNotes(req.Notes)
+	if err != nil {
+		return nil, err
+	}
+	req.Notes = notes
+
 	// add 1 to make new sequence
-	return s.repo.Create(ctx, req, planID, count+1)
+	return s.repo.Create(ctx, id, req, planID, count+1)
 }
 
 func (s *service) Update(ctx context.Context, id uuid.UUID, req UpdateReq) error {
 	// TODO: additional business logic for scheduled time
 	// if req.ScheduledTime
+
+	if req.Scope != nil {
+		if err := constants.ValidateScope(*req.Scope); err != nil {
+			return err
+		}
+	}
+
+	if req.Priority != nil && !isValidPriority(*req.Priority) {
+		return errorutils.Invalidf("priority must be one of 'low', 'medium', 'high' or 'urgent'")
+	}
+
+	if req.ClearDueDate && req.DueDate != nil {
+		return errorutils.Invalidf("dueDate cannot be set and cleared at once")
+	}
+
+	if req.ClearScheduledTime && req.ScheduledTime != nil {
+		return errorutils.Invalidf("scheduledTime cannot be set and cleared at once")
+	}
+
+	if err := validateReminderLeadMinutes(req.ReminderLeadMinutes); err != nil {
+		return err
+	}
+
+	effortUnit, err := validateEffort(req.EffortEstimate, req.EffortUnit)
+	if err != nil {
+		return err
+	}
+	req.EffortUnit = effortUnit
+
+	notes, err := sanitizeNotes(req.Notes)
+	if err != nil {
+		return err
+	}
+	req.Notes = notes
+
 	return s.repo.Update(ctx, id, req)
 }
 
-func (s *service) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.repo.Delete(ctx, id)
+/**
+* Deletes a checklist item, it can be restored with the undo token of the returned
+* operation until the undo window passes.
+**/
+func (s *service) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID, version *int) (*Operation, error) {
+	return s.repo.Delete(ctx, userID, id, version)
 }
 
 func (s *service) SetSchedule(ctx context.Context, id uuid.UUID, req SetScheduleReq) error {
-	var updateData UpdateReq
+	updateData := UpdateReq{Version: req.Version}
+
+	if err := validateReminderLeadMinutes(req.ReminderLeadMinutes); err != nil {
+		return err
+	}
 
 	if req.ScheduledTime != nil {
 		t, err := time.Parse(time.RFC3339, *req.ScheduledTime)
@@ -109,14 +206,16 @@ func (s *service) SetSchedule(ctx context.Context, id uuid.UUID, req SetSchedule
 		fmt.Printf("Parsed time into time.RFC3339: %v\n", t)
 
 		// format struct for updating scheduled time in database
-		updateData = UpdateReq{
-			ScheduledTime: &t,
-		}
+		updateData.ScheduledTime = &t
+		updateData.ReminderLeadMinutes = req.ReminderLeadMinutes
 
 		// 2. validate the time, ensure it's in the future
 		if t.Before(time.Now()) {
-			return fmt.Errorf("scheduled time must be a datetime in the future")
+			return errorutils.Invalidf("scheduled time must be a datetime in the future")
 		}
+	} else {
+		// setting no scheduled time removes it
+		updateData.ClearScheduledTime = true
 	}
 
 	// 3. if time validation checks out, update the time
@@ -124,9 +223,11 @@ func (s *service) SetSchedule(ctx context.Context, id uuid.UUID, req SetSchedule
 }
 
 /**
-* Resets the daily items from done true to false so that they can be repeated.
+* Resets the repeating items from done true to false so that they can be repeated. Plans
+* are reset once per local day of their user, daily items every time and weekly and
+* monthly items once their period has passed.
 **/
-func (s *service) ResetDailyItems(ctx context.Context) error {
+func (s *service) ResetRepeatingItems(ctx context.Context) (int64, error) {
 	// NOTE: old implementation
 	// daily := string(constants.ScopeDaily)
 	//
@@ -151,31 +252,368 @@ func (s *service) ResetDailyItems(ctx context.Context) error {
 	//
 	// return nil
 
-	return s.repo.BulkResetDailyItems(ctx)
+	return s.repo.BulkResetRepeatingItems(ctx)
 }
 
-func (s *service) Archive(ctx context.Context, id uuid.UUID) error {
-	archived := true
-	return s.repo.Update(ctx, id, UpdateReq{
-		Archived: &archived,
-	})
+func (s *service) Archive(ctx context.Context, userID uuid.UUID, id uuid.UUID, version *int) (*Operation, error) {
+	return s.repo.Archive(ctx, userID, id, version)
+}
+
+func (s *service) Unarchive(ctx context.Context, id uuid.UUID, version *int) error {
+	return s.repo.SetArchived(ctx, id, false, version)
+}
+
+/**
+* Applies the auto archive policy of a plan. With dryRun the items that would be archived
+* are returned without archiving them, otherwise the archiving can be undone with the
+* returned operation.
+**/
+func (s *service) AutoArchive(ctx context.Context, userID uuid.UUID, planID uuid.UUID, dryRun bool) ([]*ArchiveCandidate, *Operation, error) {
+	return s.repo.AutoArchive(ctx, &userID, &planID, dryRun)
 }
 
-func (s *service) GetUpcoming(ctx context.Context, planId uuid.UUID) ([]*models.ChecklistItem, error) {
+/**
+* Applies the auto archive policies of all plans, run periodically by the auto archive job.
+**/
+func (s *service) AutoArchiveAllPlans(ctx context.Context) (int64, error) {
+	archived, _, err := s.repo.AutoArchive(ctx, nil, nil, false)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(archived)), nil
+}
+
+/**
+* Gets overdue items first, ordered by how long they have been due unless another sort
+* is provided, followed by the items scheduled within the upcoming week. The remaining
+* list options narrow down both.
+**/
+func (s *service) GetUpcoming(ctx context.Context, planId uuid.UUID, opts ListOptions) ([]*models.ChecklistItem, error) {
+	overdueOpts := opts
+	overdueOpts.Overdue = true
+	if overdueOpts.SortBy == nil {
+		sortBy := string(constants.SortDueDate)
+		overdueOpts.SortBy = &sortBy
+	}
+
+	overdue, _, err := s.GetAllByPlanId(ctx, planId, overdueOpts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	upcomingOpts := opts
 	upcomingStr := string(constants.UpcomingWeek)
-	items, err := s.GetAllByPlanId(ctx, planId, nil, &upcomingStr)
+	upcomingOpts.Upcoming = &upcomingStr
+
+	upcoming, _, err := s.GetAllByPlanId(ctx, planId, upcomingOpts)
 
 	if err != nil {
 		return nil, err
 	}
 
+	items := make([]*models.ChecklistItem, 0, len(overdue)+len(upcoming))
+	seen := make(map[uuid.UUID]bool, len(overdue))
+
+	for _, item := range overdue {
+		seen[item.ID] = true
+		items = append(items, item)
+	}
+
+	for _, item := range upcoming {
+		if seen[item.ID] {
+			continue
+		}
+		items = append(items, item)
+	}
+
 	return items, nil
 }
 
-func (s *service) CheckAllScheduledItems(ctx context.Context) error {
+/**
+* Moves checklist items from one plan to another plan of the same user.
+**/
+func (s *service) MoveToPlan(ctx context.Context, userID uuid.UUID, sourcePlanID uuid.UUID, req TransferItemsReq) ([]*models.ChecklistItem, *Operation, error) {
+	if err := s.validateTransfer(ctx, userID, sourcePlanID, req); err != nil {
+		return nil, nil, err
+	}
+
+	return s.repo.MoveToPlan(ctx, userID, req.ItemIDs, sourcePlanID, req.TargetPlanID, req.Position)
+}
+
+/**
+* Copies checklist items from one plan into another plan of the same user.
+**/
+func (s *service) CopyToPlan(ctx context.Context, userID uuid.UUID, sourcePlanID uuid.UUID, req TransferItemsReq) ([]*models.ChecklistItem, *Operation, error) {
+	if err := s.validateTransfer(ctx, userID, sourcePlanID, req); err != nil {
+		return nil, nil, err
+	}
+
+	return s.repo.CopyToPlan(ctx, userID, req.ItemIDs, sourcePlanID, req.TargetPlanID, req.Position)
+}
+
+func (s *service) validateTransfer(ctx context.Context, userID uuid.UUID, sourcePlanID uuid.UUID, req TransferItemsReq) error {
+	if len(req.ItemIDs) > maxTransferItems {
+		return errorutils.Invalidf("at most %d items can be transferred at once", maxTransferItems)
+	}
+
+	if req.TargetPlanID == sourcePlanID {
+		return errorutils.Invalidf("target plan must be different from the current plan")
+	}
+
+	if req.Position != nil && *req.Position < 1 {
+		return errorutils.Invalidf("position must be 1 or greater")
+	}
+
+	seen := make(map[uuid.UUID]bool, len(req.ItemIDs))
+	for _, id := range req.ItemIDs {
+		if seen[id] {
+			return errorutils.Invalidf("item %s was provided more than once", id)
+		}
+		seen[id] = true
+	}
+
+	owned, err := s.repo.PlansOwnedBy(ctx, userID, []uuid.UUID{sourcePlanID, req.TargetPlanID})
+	if err != nil {
+		return err
+	}
+
+	if !owned {
+		return constants.ErrForbidden
+	}
+
+	return nil
+}
+
+/**
+* Reverts a delete, archive, move or copy of the user by its undo token.
+**/
+func (s *service) Undo(ctx context.Context, userID uuid.UUID, token uuid.UUID) (*Operation, error) {
+	return s.repo.Undo(ctx, userID, token)
+}
+
+// GetOperations returns the operation journal of a user
+func (s *service) GetOperations(ctx context.Context, userID uuid.UUID, page *pageutils.Params) ([]*Operation, *pageutils.Page, error) {
+	return s.repo.GetOperations(ctx, userID, page)
+}
+
+/**
+* Purges deleted items that can no longer be restored along with old journal entries, run
+* periodically by the undo purge job.
+**/
+func (s *service) PurgeDeleted(ctx context.Context) (int64, error) {
+	now := time.Now()
+
+	return s.repo.PurgeDeleted(ctx, now.Add(-undoWindow), now.Add(-journalRetention))
+}
+
+/**
+* Finds the reminders of scheduled items that are due and hands each of them to the
+* dispatcher, run every minute by the scheduled items job. Returns how many were sent.
+**/
+func (s *service) CheckAllScheduledItems(ctx context.Context) (int64, error) {
+	now := time.Now()
+
+	return s.sendDueReminders(ctx, now, now.Add(-reminderGracePeriod))
+}
+
+/**
+* Sends the reminders that became due since the given time, e.g. while no instance of the
+* server was up, limited to the last day. Returns how many were sent.
+**/
+func (s *service) CatchUpScheduledItems(ctx context.Context, since time.Time) (int64, error) {
+	now := time.Now()
+
+	graceCutoff := since.Add(-reminderGracePeriod)
+	if oldest := now.Add(-maxReminderCatchUp); graceCutoff.Before(oldest) {
+		graceCutoff = oldest
+	}
+
+	return s.sendDueReminders(ctx, now, graceCutoff)
+}
+
+// claims the reminders due by now and not before graceCutoff and dispatches them
+func (s *service) sendDueReminders(ctx context.Context, now time.Time, graceCutoff time.Time) (int64, error) {
+	reminders, err := s.repo.ClaimDueReminders(ctx, now, graceCutoff, now.Add(-reminderClaimTimeout))
+	if err != nil {
+		return 0, err
+	}
+
+	var sent int64
+	var errs []error
+	for _, reminder := range reminders {
+		if err := s.TriggerScheduledReminder(ctx, reminder); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+/**
+* Dispatches a claimed reminder and records its delivery. Reminders that could not be
+* dispatched are released so that the next check retries them.
+**/
+func (s *service) TriggerScheduledReminder(ctx context.Context, reminder *Reminder) error {
+	title := fmt.Sprintf("Starting now: %s", reminder.Description)
+	if reminder.LeadMinutes > 0 {
+		title = fmt.Sprintf("Starting in %d minutes: %s", reminder.LeadMinutes, reminder.Description)
+	}
+
+	err := s.reminderDispatcher.Dispatch(ctx, notifications.Notification{
+		UserID: reminder.UserID,
+		Kind:   constants.NotificationReminder,
+		Title:  title,
+		Body:   fmt.Sprintf("Scheduled for %s.", reminder.ScheduledTime.Format(time.RFC1123)),
+		Data: map[string]interface{}{
+			"checklistItemId": reminder.ChecklistItemID,
+			"planId":          reminder.PlanID,
+			"scheduledTime":   reminder.ScheduledTime,
+		},
+	})
+	if err != nil {
+		if releaseErr := s.repo.ReleaseReminder(ctx, reminder.ID); releaseErr != nil {
+			return errors.Join(err, releaseErr)
+		}
+		return err
+	}
+
+	return s.repo.MarkReminderDelivered(ctx, reminder.ID)
+}
+
+/**
+* Validates the filter and sort options shared by the listing endpoints.
+**/
+func validateListOptions(opts ListOptions) error {
+	if opts.Scope != nil {
+		if err := constants.ValidateScope(*opts.Scope); err != nil {
+			return err
+		}
+	}
+
+	if opts.Upcoming != nil {
+		if *opts.Upcoming != string(constants.UpcomingWeek) && *opts.Upcoming != string(constants.UpcomingMonth) {
+			return errorutils.Invalidf("Upcoming needs to be either 'week' or 'month'")
+		}
+	}
+
+	if opts.Priority != nil && !isValidPriority(*opts.Priority) {
+		return errorutils.Invalidf("priority must be one of 'low', 'medium', 'high' or 'urgent'")
+	}
+
+	if opts.SortOrder != nil && *opts.SortOrder != "asc" && *opts.SortOrder != "desc" {
+		return errorutils.Invalidf("order must be either 'asc' or 'desc'")
+	}
+
+	for _, key := range sortKeys(opts) {
+		switch key.field {
+		case constants.SortSequence, constants.SortPriority, constants.SortDueDate, constants.SortEffort, constants.SortCreatedAt:
+		default:
+			return errorutils.Invalidf("sort must be a comma separated list of 'sequence', 'priority', 'dueDate', 'effort' or 'createdAt', optionally prefixed with '-'")
+		}
+	}
+
 	return nil
 }
 
-func (s *service) TriggerScheduledReminder(ctx context.Context) error {
+type sortKey struct {
+	field constants.ChecklistSortField
+	desc  bool
+}
+
+// splits the sort option into its fields and their directions
+func sortKeys(opts ListOptions) []sortKey {
+	if opts.SortBy == nil {
+		return nil
+	}
+
+	defaultDesc := opts.SortOrder != nil && *opts.SortOrder == "desc"
+
+	keys := make([]sortKey, 0)
+	for _, field := range strings.Split(*opts.SortBy, ",") {
+		field = strings.TrimSpace(field)
+
+		key := sortKey{field: constants.ChecklistSortField(field), desc: defaultDesc}
+		if strings.HasPrefix(field, "-") {
+			key = sortKey{field: constants.ChecklistSortField(field[1:]), desc: true}
+		}
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func isValidPriority(priority string) bool {
+	switch constants.ChecklistItemPriority(priority) {
+	case constants.PriorityLow, constants.PriorityMedium, constants.PriorityHigh, constants.PriorityUrgent:
+		return true
+	}
+	return false
+}
+
+/**
+* Validates an effort estimate and returns the unit it should be stored with, estimates
+* without a unit default to minutes.
+**/
+func validateEffort(estimate *int, unit *string) (*string, error) {
+	if unit != nil && *unit != string(constants.EffortMinutes) && *unit != string(constants.EffortPoints) {
+		return nil, errorutils.Invalidf("effort unit must be either 'minutes' or 'points'")
+	}
+
+	if estimate == nil {
+		return unit, nil
+	}
+
+	if *estimate <= 0 {
+		return nil, errorutils.Invalidf("effort estimate must be greater than 0")
+	}
+
+	if unit == nil {
+		minutes := string(constants.EffortMinutes)
+		return &minutes, nil
+	}
+
+	return unit, nil
+}
+
+/**
+* Validates the lead times of the reminders of a scheduled item in minutes before its
+* scheduled time.
+**/
+func validateReminderLeadMinutes(leadMinutes []int64) error {
+	if len(leadMinutes) > maxReminders {
+		return errorutils.Invalidf("at most %d reminders can be set", maxReminders)
+	}
+
+	seen := make(map[int64]bool, len(leadMinutes))
+	for _, minutes := range leadMinutes {
+		if minutes < 0 || minutes > maxReminderLeadMinutes {
+			return errorutils.Invalidf("reminder lead times must be between 0 and %d minutes", maxReminderLeadMinutes)
+		}
+		if seen[minutes] {
+			return errorutils.Invalidf("reminder lead time of %d minutes was provided more than once", minutes)
+		}
+		seen[minutes] = true
+	}
+
 	return nil
 }
+
+/**
+* Sanitizes markdown notes so they are safe to render on the client.
+**/
+func sanitizeNotes(notes *string) (*string, error) {
+	if notes == nil {
+		return nil, nil
+	}
+
+	if len(*notes) > maxNotesLength {
+		return nil, errorutils.Invalidf("notes can be at most %d characters", maxNotesLength)
+	}
+
+	sanitized := sanitizeutils.SanitizeMarkdown(*notes)
+	return &sanitized, nil
+}
@@ -0,0 +1,99 @@
+package completions
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+}
+
+type Service interface {
+	GetItemStats(ctx context.Context, itemID uuid.UUID) (*ItemStats, error)
+	GetPlanStats(ctx context.Context, planID uuid.UUID) ([]*ItemStats, error)
+	GetHeatmap(ctx context.Context, planID uuid.UUID, from *string, to *string) ([]*HeatmapDay, error)
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// GetItemStats returns the streaks and completion rate of a single checklist item
+func (h *Handler) GetItemStats(c *gin.Context) {
+	idParam := c.Param("checklist_id")
+
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect uuid format."})
+		return
+	}
+
+	stats, err := h.service.GetItemStats(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get checklist item stats. Error: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved checklist item stats.", "result": stats})
+}
+
+// GetPlanStats returns the streaks and completion rates of every item in a plan
+func (h *Handler) GetPlanStats(c *gin.Context) {
+	planIdParam := c.Param("id")
+
+	planId, err := uuid.Parse(planIdParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect uuid format."})
+		return
+	}
+
+	stats, err := h.service.GetPlanStats(c.Request.Context(), planId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get plan completion stats. Error: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved plan completion stats.", "result": stats})
+}
+
+// GetHeatmap returns completion counts per day for a plan
+func (h *Handler) GetHeatmap(c *gin.Context) {
+	planIdParam := c.Param("id")
+
+	planId, err := uuid.Parse(planIdParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect uuid format."})
+		return
+	}
+
+	var fromPtr, toPtr *string
+	if from := c.Query("from"); from != "" {
+		fromPtr = &from
+	}
+	if to := c.Query("to"); to != "" {
+		toPtr = &to
+	}
+
+	days, err := h.service.GetHeatmap(c.Request.Context(), planId, fromPtr, toPtr)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, constants.ErrInvalidInput):
+			status = http.StatusBadRequest
+		case errors.Is(err, constants.ErrNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": "Failed to get completion heatmap. Error: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved completion heatmap.", "result": days})
+}
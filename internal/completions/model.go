@@ -0,0 +1,46 @@
+package completions
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Completion struct {
+	ID              uuid.UUID  `db:"id" json:"id"`
+	ChecklistItemID uuid.UUID  `db:"checklist_item_id" json:"checklistItemId"`
+	PlanID          uuid.UUID  `db:"plan_id" json:"planId"`
+	UserID          *uuid.UUID `db:"user_id" json:"userId,omitempty"`
+	Scope           string     `db:"scope" json:"scope"`
+	OccurrenceDate  time.Time  `db:"occurrence_date" json:"occurrenceDate"`
+	CompletedAt     time.Time  `db:"completed_at" json:"completedAt"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+}
+
+// item information required for computing stats
+type ItemInfo struct {
+	ID          uuid.UUID `db:"id"`
+	Description string    `db:"description"`
+	Scope       string    `db:"scope"`
+	CreatedAt   time.Time `db:"created_at"`
+	// timezone of the user owning the item, days are counted in it
+	Timezone string `db:"timezone"`
+	// day weekly items are counted from, 0 is sunday and 1 is monday
+	WeekStartsOn int `db:"week_starts_on"`
+}
+
+type ItemStats struct {
+	ChecklistItemID  uuid.UUID  `json:"checklistItemId"`
+	Description      string     `json:"description"`
+	Scope            string     `json:"scope"`
+	CurrentStreak    int        `json:"currentStreak"`
+	LongestStreak    int        `json:"longestStreak"`
+	TotalCompletions int        `json:"totalCompletions"`
+	CompletionRate   float64    `json:"completionRate"`
+	LastCompletedAt  *time.Time `json:"lastCompletedAt,omitempty"`
+}
+
+type HeatmapDay struct {
+	Date  string `db:"date" json:"date"`
+	Count int    `db:"count" json:"count"`
+}
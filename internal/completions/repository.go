@@ -0,0 +1,193 @@
+package completions
+
+import (
+	"context"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{
+		db: db,
+	}
+}
+
+/**
+* Records the completion of a checklist item within the transaction of the update that
+* marked it done, so that the item and its history never disagree. Plan, user and scope
+* are copied from the item at the time of completion so history stays intact if the item
+* changes later, and the completion counts towards the local day of the user. Call it
+* from within dbutils.ExecTx.
+**/
+func Record(ctx context.Context, tx *sqlx.Tx, itemID uuid.UUID, completedAt time.Time) error {
+	query := `
+	INSERT INTO checklist_item_completions (checklist_item_id, plan_id, user_id, scope, occurrence_date, completed_at)
+	SELECT
+		checklist_items.id,
+		checklist_items.plan_id,
+		plans.user_id,
+		checklist_items.scope,
+		($2::TIMESTAMPTZ AT TIME ZONE COALESCE(users.timezone, 'UTC'))::DATE,
+		$2
+	FROM checklist_items
+	JOIN plans ON checklist_items.plan_id = plans.id
+	LEFT JOIN users ON plans.user_id = users.id
+	WHERE checklist_items.id = $1
+	AND checklist_items.deleted_at IS NULL
+	ON CONFLICT (checklist_item_id, occurrence_date) DO NOTHING
+	`
+
+	_, err := tx.ExecContext(ctx, query, itemID, completedAt)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+/**
+* Removes the completion of a checklist item for the local day of its user, within the
+* transaction of the update that unchecked it before it was reset. Call it from within
+* dbutils.ExecTx.
+**/
+func Remove(ctx context.Context, tx *sqlx.Tx, itemID uuid.UUID, at time.Time) error {
+	query := `
+	DELETE FROM checklist_item_completions
+	USING plans
+	LEFT JOIN users ON plans.user_id = users.id
+	WHERE checklist_item_completions.plan_id = plans.id
+	AND checklist_item_completions.checklist_item_id = $1
+	AND checklist_item_completions.occurrence_date = ($2::TIMESTAMPTZ AT TIME ZONE COALESCE(users.timezone, 'UTC'))::DATE
+	`
+
+	_, err := tx.ExecContext(ctx, query, itemID, at)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) GetAllByItemId(ctx context.Context, itemID uuid.UUID) ([]*Completion, error) {
+	query := `
+	SELECT id, checklist_item_id, plan_id, user_id, scope, occurrence_date, completed_at, created_at
+	FROM checklist_item_completions
+	WHERE checklist_item_id = $1
+	ORDER BY occurrence_date ASC
+	`
+
+	completions := []*Completion{}
+	err := r.db.SelectContext(ctx, &completions, query, itemID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return completions, nil
+}
+
+func (r *repository) GetAllByPlanId(ctx context.Context, planID uuid.UUID) ([]*Completion, error) {
+	query := `
+	SELECT id, checklist_item_id, plan_id, user_id, scope, occurrence_date, completed_at, created_at
+	FROM checklist_item_completions
+	WHERE plan_id = $1
+	ORDER BY occurrence_date ASC
+	`
+
+	completions := []*Completion{}
+	err := r.db.SelectContext(ctx, &completions, query, planID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return completions, nil
+}
+
+func (r *repository) GetItemInfo(ctx context.Context, itemID uuid.UUID) (*ItemInfo, error) {
+	query := `
+	SELECT checklist_items.id, checklist_items.description, checklist_items.scope, checklist_items.created_at, COALESCE(users.timezone, 'UTC') AS timezone, COALESCE(users.week_starts_on, 1) AS week_starts_on
+	FROM checklist_items
+	JOIN plans ON checklist_items.plan_id = plans.id
+	LEFT JOIN users ON plans.user_id = users.id
+	WHERE checklist_items.id = $1
+	AND checklist_items.deleted_at IS NULL
+	`
+
+	var item ItemInfo
+	err := r.db.GetContext(ctx, &item, query, itemID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &item, nil
+}
+
+func (r *repository) GetItemInfosByPlanId(ctx context.Context, planID uuid.UUID) ([]*ItemInfo, error) {
+	query := `
+	SELECT checklist_items.id, checklist_items.description, checklist_items.scope, checklist_items.created_at, COALESCE(users.timezone, 'UTC') AS timezone, COALESCE(users.week_starts_on, 1) AS week_starts_on
+	FROM checklist_items
+	JOIN plans ON checklist_items.plan_id = plans.id
+	LEFT JOIN users ON plans.user_id = users.id
+	WHERE checklist_items.plan_id = $1
+	AND checklist_items.archived = false
+	AND checklist_items.deleted_at IS NULL
+	ORDER BY checklist_items.sequence ASC
+	`
+
+	items := []*ItemInfo{}
+	err := r.db.SelectContext(ctx, &items, query, planID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return items, nil
+}
+
+// GetPlanTimezone returns the timezone of the user owning a plan, days are counted in it
+func (r *repository) GetPlanTimezone(ctx context.Context, planID uuid.UUID) (string, error) {
+	query := `
+	SELECT COALESCE(users.timezone, 'UTC')
+	FROM plans
+	LEFT JOIN users ON plans.user_id = users.id
+	WHERE plans.id = $1
+	`
+
+	var timezone string
+	err := r.db.GetContext(ctx, &timezone, query, planID)
+	if err != nil {
+		return "", errorutils.AnalyzeDBErr(err)
+	}
+
+	return timezone, nil
+}
+
+/**
+* Counts completions per day for a plan between two dates (inclusive). Days without
+* completions are not returned.
+**/
+func (r *repository) GetHeatmap(ctx context.Context, planID uuid.UUID, from time.Time, to time.Time) ([]*HeatmapDay, error) {
+	query := `
+	SELECT
+		to_char(occurrence_date, 'YYYY-MM-DD') AS date,
+		COUNT(id) AS count
+	FROM checklist_item_completions
+	WHERE plan_id = $1
+	AND occurrence_date BETWEEN $2 AND $3
+	GROUP BY occurrence_date
+	ORDER BY occurrence_date ASC
+	`
+
+	days := []*HeatmapDay{}
+	err := r.db.SelectContext(ctx, &days, query, planID, from.Format(dateLayout), to.Format(dateLayout))
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return days, nil
+}
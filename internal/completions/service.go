@@ -0,0 +1,263 @@
+package completions
+
+import (
+	"context"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/google/uuid"
+)
+
+const (
+	dateLayout         = "2006-01-02"
+	defaultHeatmapDays = 365
+	// longest range of days a heatmap is returned for, a leap year
+	maxHeatmapDays = 366
+)
+
+type service struct {
+	repo Repository
+}
+
+type Repository interface {
+	GetAllByItemId(ctx context.Context, itemID uuid.UUID) ([]*Completion, error)
+	GetAllByPlanId(ctx context.Context, planID uuid.UUID) ([]*Completion, error)
+	GetItemInfo(ctx context.Context, itemID uuid.UUID) (*ItemInfo, error)
+	GetItemInfosByPlanId(ctx context.Context, planID uuid.UUID) ([]*ItemInfo, error)
+	GetPlanTimezone(ctx context.Context, planID uuid.UUID) (string, error)
+	GetHeatmap(ctx context.Context, planID uuid.UUID, from time.Time, to time.Time) ([]*HeatmapDay, error)
+}
+
+func NewService(repo Repository) Service {
+	return &service{
+		repo: repo,
+	}
+}
+
+func (s *service) GetItemStats(ctx context.Context, itemID uuid.UUID) (*ItemStats, error) {
+	item, err := s.repo.GetItemInfo(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	completions, err := s.repo.GetAllByItemId(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	return calculateStats(item, completions, time.Now()), nil
+}
+
+/**
+* Calculates streaks and completion rates for every active item in a plan.
+**/
+func (s *service) GetPlanStats(ctx context.Context, planID uuid.UUID) ([]*ItemStats, error) {
+	items, err := s.repo.GetItemInfosByPlanId(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	completions, err := s.repo.GetAllByPlanId(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	// group completions by their item
+	completionsByItem := make(map[uuid.UUID][]*Completion)
+	for _, completion := range completions {
+		completionsByItem[completion.ChecklistItemID] = append(completionsByItem[completion.ChecklistItemID], completion)
+	}
+
+	now := time.Now()
+	stats := make([]*ItemStats, 0, len(items))
+
+	for _, item := range items {
+		stats = append(stats, calculateStats(item, completionsByItem[item.ID], now))
+	}
+
+	return stats, nil
+}
+
+/**
+* Returns completion counts per day for a plan, with every day in the range present so
+* the result can be rendered directly as a calendar heatmap. Without a range the last year
+* up to today of the plan's user is returned.
+**/
+func (s *service) GetHeatmap(ctx context.Context, planID uuid.UUID, from *string, to *string) ([]*HeatmapDay, error) {
+	timezone, err := s.repo.GetPlanTimezone(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	end := occurrenceDate(time.Now(), loadLocation(timezone))
+	if to != nil {
+		t, err := time.Parse(dateLayout, *to)
+		if err != nil {
+			return nil, errorutils.Invalidf("to must be a date in the format YYYY-MM-DD")
+		}
+		end = t
+	}
+
+	start := end.AddDate(0, 0, -(defaultHeatmapDays - 1))
+	if from != nil {
+		t, err := time.Parse(dateLayout, *from)
+		if err != nil {
+			return nil, errorutils.Invalidf("from must be a date in the format YYYY-MM-DD")
+		}
+		start = t
+	}
+
+	if start.After(end) {
+		return nil, errorutils.Invalidf("from must be before to")
+	}
+
+	if start.AddDate(0, 0, maxHeatmapDays-1).Before(end) {
+		return nil, errorutils.Invalidf("a heatmap covers at most %d days", maxHeatmapDays)
+	}
+
+	counts, err := s.repo.GetHeatmap(ctx, planID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	countsByDate := make(map[string]int, len(counts))
+	for _, day := range counts {
+		countsByDate[day.Date] = day.Count
+	}
+
+	// fill in the days without any completions
+	days := make([]*HeatmapDay, 0)
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		date := d.Format(dateLayout)
+		days = append(days, &HeatmapDay{
+			Date:  date,
+			Count: countsByDate[date],
+		})
+	}
+
+	return days, nil
+}
+
+/**
+* Computes current and longest streak along with the completion rate of an item. Repeating
+* items are expected to be completed once per day, week or month since their creation and
+* their streaks count consecutive periods with a completion, longterm items are expected to
+* be completed once.
+**/
+func calculateStats(item *ItemInfo, completions []*Completion, now time.Time) *ItemStats {
+	stats := &ItemStats{
+		ChecklistItemID:  item.ID,
+		Description:      item.Description,
+		Scope:            item.Scope,
+		TotalCompletions: len(completions),
+	}
+
+	if len(completions) == 0 {
+		return stats
+	}
+
+	scope := constants.ChecklistItemScope(item.Scope)
+
+	// completions are ordered by occurrence date, several can fall into the same period
+	run := 0
+	periods := 0
+	var prev time.Time
+
+	for _, completion := range completions {
+		period := periodStart(completion.OccurrenceDate, scope, item.WeekStartsOn)
+		if periods > 0 && period.Equal(prev) {
+			continue
+		}
+
+		if periods > 0 && period.Equal(nextPeriod(prev, scope)) {
+			run++
+		} else {
+			run = 1
+		}
+		periods++
+
+		if run > stats.LongestStreak {
+			stats.LongestStreak = run
+		}
+
+		prev = period
+	}
+
+	// the current streak is still alive if the last completion was in this period or the one before
+	loc := loadLocation(item.Timezone)
+	current := periodStart(occurrenceDate(now, loc), scope, item.WeekStartsOn)
+	if prev.Equal(current) || nextPeriod(prev, scope).Equal(current) {
+		stats.CurrentStreak = run
+	}
+
+	last := completions[len(completions)-1].CompletedAt
+	stats.LastCompletedAt = &last
+
+	expected := 1
+	if scope.Repeats() {
+		created := periodStart(occurrenceDate(item.CreatedAt, loc), scope, item.WeekStartsOn)
+		expected = countPeriods(created, current, scope)
+	}
+
+	stats.CompletionRate = float64(periods) / float64(expected)
+	if stats.CompletionRate > 1 {
+		stats.CompletionRate = 1
+	}
+
+	return stats
+}
+
+/**
+* The first day of the period of a scope a date falls in, weeks start on the user's
+* weekStartsOn and months on their first day. Longterm items are counted per day.
+**/
+func periodStart(date time.Time, scope constants.ChecklistItemScope, weekStartsOn int) time.Time {
+	switch scope {
+	case constants.ScopeWeekly:
+		return date.AddDate(0, 0, -((int(date.Weekday()) - weekStartsOn + 7) % 7))
+	case constants.ScopeMonthly:
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return date
+}
+
+// the start of the period after the one starting at start
+func nextPeriod(start time.Time, scope constants.ChecklistItemScope) time.Time {
+	switch scope {
+	case constants.ScopeWeekly:
+		return start.AddDate(0, 0, 7)
+	case constants.ScopeMonthly:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// the number of periods from the one starting at from up to and including the one starting at to
+func countPeriods(from time.Time, to time.Time, scope constants.ChecklistItemScope) int {
+	switch scope {
+	case constants.ScopeWeekly:
+		return int(to.Sub(from).Hours()/24)/7 + 1
+	case constants.ScopeMonthly:
+		return (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+	}
+	return int(to.Sub(from).Hours()/24) + 1
+}
+
+/**
+* The local day of the user a time falls on, as a date at midnight UTC to compare with the
+* occurrence dates of completions.
+**/
+func occurrenceDate(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// timezones are validated when set, UTC is only a fallback for unknown ones
+func loadLocation(timezone string) *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
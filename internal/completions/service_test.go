@@ -0,0 +1,64 @@
+package completions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+)
+
+func TestCalculateStats(t *testing.T) {
+	// a tuesday
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		scope        constants.ChecklistItemScope
+		weekStartsOn int
+		dates        []string
+		wantCurrent  int
+		wantLongest  int
+		wantRate     float64
+	}{
+		{"daily run up to yesterday", constants.ScopeDaily, 1, []string{"2026-03-07", "2026-03-08", "2026-03-09"}, 3, 3, 3.0 / 69},
+		{"daily run broken two days ago", constants.ScopeDaily, 1, []string{"2026-03-01", "2026-03-02", "2026-03-03", "2026-03-08"}, 0, 3, 4.0 / 69},
+		{"weekly run up to this week", constants.ScopeWeekly, 1, []string{"2026-02-24", "2026-03-03", "2026-03-09"}, 3, 3, 3.0 / 11},
+		{"weekly run up to last week", constants.ScopeWeekly, 1, []string{"2026-02-23", "2026-03-04"}, 2, 2, 2.0 / 11},
+		{"weekly completions in the same week", constants.ScopeWeekly, 1, []string{"2026-03-02", "2026-03-05"}, 1, 1, 1.0 / 11},
+		{"weekly run broken", constants.ScopeWeekly, 1, []string{"2026-02-09", "2026-02-16", "2026-03-02"}, 1, 2, 3.0 / 11},
+		{"weeks starting on monday", constants.ScopeWeekly, 1, []string{"2026-03-07", "2026-03-08"}, 1, 1, 1.0 / 11},
+		{"weeks starting on sunday", constants.ScopeWeekly, 0, []string{"2026-03-07", "2026-03-08"}, 2, 2, 2.0 / 11},
+		{"monthly run up to this month", constants.ScopeMonthly, 1, []string{"2026-01-31", "2026-02-01", "2026-03-01"}, 3, 3, 1},
+		{"monthly run up to last month", constants.ScopeMonthly, 1, []string{"2026-02-28"}, 1, 1, 1.0 / 3},
+		{"monthly run broken", constants.ScopeMonthly, 1, []string{"2026-01-31"}, 0, 1, 1.0 / 3},
+		{"longterm", constants.ScopeLongterm, 1, []string{"2026-03-01"}, 0, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &ItemInfo{Scope: string(tt.scope), CreatedAt: created, Timezone: "UTC", WeekStartsOn: tt.weekStartsOn}
+
+			completions := make([]*Completion, 0, len(tt.dates))
+			for _, date := range tt.dates {
+				d, err := time.Parse(dateLayout, date)
+				if err != nil {
+					t.Fatalf("invalid date %s: %v", date, err)
+				}
+				completions = append(completions, &Completion{OccurrenceDate: d, CompletedAt: d.Add(9 * time.Hour)})
+			}
+
+			stats := calculateStats(item, completions, now)
+
+			if stats.CurrentStreak != tt.wantCurrent || stats.LongestStreak != tt.wantLongest {
+				t.Errorf("streaks = %d current, %d longest, want %d, %d", stats.CurrentStreak, stats.LongestStreak, tt.wantCurrent, tt.wantLongest)
+			}
+			if stats.CompletionRate != tt.wantRate {
+				t.Errorf("completion rate = %v, want %v", stats.CompletionRate, tt.wantRate)
+			}
+			if stats.TotalCompletions != len(tt.dates) {
+				t.Errorf("total completions = %d, want %d", stats.TotalCompletions, len(tt.dates))
+			}
+		})
+	}
+}
@@ -24,4 +24,12 @@ var (
 	ErrForbidden           = errors.New("You do not have permission to access this resource.")
 	ErrUnauthorized        = errors.New("Incorrect credentials entered during when attempting to authenticate.")
 	ErrNoRowsAffected      = errors.New("Operation executed successfully but no rows were affected.")
+	ErrPreconditionFailed  = errors.New("Resource was modified since it was last retrieved.")
+	ErrUndoExpired         = errors.New("Operation can no longer be undone.")
+	ErrAlreadyUndone       = errors.New("Operation was already undone.")
+	ErrInvalidScope        = errors.New("Scope must be one of 'daily', 'weekly', 'monthly' or 'longterm'.")
+	ErrJobRunning          = errors.New("Job is already running.")
+	ErrJobsStopped         = errors.New("Jobs were stopped.")
+	ErrRealtimeUnavailable = errors.New("Realtime updates are unavailable.")
+	ErrSyncTokenExpired    = errors.New("Sync token expired, a full sync is required.")
 )
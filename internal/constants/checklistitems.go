@@ -6,8 +6,31 @@ type ChecklistItemScope string
 const (
 	ScopeLongterm ChecklistItemScope = "longterm"
 	ScopeDaily    ChecklistItemScope = "daily"
+	ScopeWeekly   ChecklistItemScope = "weekly"
+	ScopeMonthly  ChecklistItemScope = "monthly"
 )
 
+// every checklist item scope, kept in sync with the check_valid_scope constraint
+var ChecklistItemScopes = []ChecklistItemScope{ScopeDaily, ScopeWeekly, ScopeMonthly, ScopeLongterm}
+
+/**
+* Validates a checklist item scope, the single place scopes provided by clients are
+* checked against.
+**/
+func ValidateScope(scope string) error {
+	for _, valid := range ChecklistItemScopes {
+		if ChecklistItemScope(scope) == valid {
+			return nil
+		}
+	}
+	return ErrInvalidScope
+}
+
+// Repeats reports whether items of the scope are reset at the start of every day, week or month
+func (s ChecklistItemScope) Repeats() bool {
+	return s == ScopeDaily || s == ScopeWeekly || s == ScopeMonthly
+}
+
 type ChecklistUpcoming string
 
 var (
@@ -16,3 +39,51 @@ var (
 	UpcomingMonth ChecklistUpcoming = "month"
 	UpcomingYear  ChecklistUpcoming = "year"
 )
+
+// Priority levels of checklist items
+type ChecklistItemPriority string
+
+const (
+	PriorityLow    ChecklistItemPriority = "low"
+	PriorityMedium ChecklistItemPriority = "medium"
+	PriorityHigh   ChecklistItemPriority = "high"
+	PriorityUrgent ChecklistItemPriority = "urgent"
+)
+
+// Units an effort estimate can be given in
+type EffortUnit string
+
+const (
+	EffortMinutes EffortUnit = "minutes"
+	EffortPoints  EffortUnit = "points"
+)
+
+// Fields checklist item listings can be sorted by
+type ChecklistSortField string
+
+const (
+	SortSequence  ChecklistSortField = "sequence"
+	SortPriority  ChecklistSortField = "priority"
+	SortDueDate   ChecklistSortField = "dueDate"
+	SortEffort    ChecklistSortField = "effort"
+	SortCreatedAt ChecklistSortField = "createdAt"
+)
+
+// Reasons a checklist item was archived automatically
+type AutoArchiveReason string
+
+const (
+	ArchiveReasonCompleted     AutoArchiveReason = "completed"
+	ArchiveReasonPastScheduled AutoArchiveReason = "pastScheduled"
+)
+
+// Operations on checklist items that can be undone
+type ChecklistOperationKind string
+
+const (
+	OperationDelete      ChecklistOperationKind = "delete"
+	OperationArchive     ChecklistOperationKind = "archive"
+	OperationAutoArchive ChecklistOperationKind = "autoArchive"
+	OperationMove        ChecklistOperationKind = "move"
+	OperationCopy        ChecklistOperationKind = "copy"
+)
@@ -0,0 +1,30 @@
+package constants
+
+// Types of domain events written to the outbox
+type EventType string
+
+const (
+	EventPlanCreated            EventType = "plan.created"
+	EventPlanUpdated            EventType = "plan.updated"
+	EventPlanDeleted            EventType = "plan.deleted"
+	EventPlanReset              EventType = "plan.reset"
+	EventChecklistItemCreated   EventType = "checklist_item.created"
+	EventChecklistItemUpdated   EventType = "checklist_item.updated"
+	EventChecklistItemCompleted EventType = "checklist_item.completed"
+	EventChecklistItemScheduled EventType = "checklist_item.scheduled"
+	EventChecklistItemDeleted   EventType = "checklist_item.deleted"
+	EventChecklistItemReordered EventType = "checklist_item.reordered"
+)
+
+var EventTypes = []EventType{
+	EventPlanCreated,
+	EventPlanUpdated,
+	EventPlanDeleted,
+	EventPlanReset,
+	EventChecklistItemCreated,
+	EventChecklistItemUpdated,
+	EventChecklistItemCompleted,
+	EventChecklistItemScheduled,
+	EventChecklistItemDeleted,
+	EventChecklistItemReordered,
+}
@@ -0,0 +1,19 @@
+package constants
+
+// What started a background job run
+type JobTrigger string
+
+const (
+	JobTriggerSchedule JobTrigger = "schedule"
+	JobTriggerManual   JobTrigger = "manual"
+	JobTriggerCatchUp  JobTrigger = "catchUp"
+)
+
+// Outcome of a background job run
+type JobRunStatus string
+
+const (
+	JobRunRunning   JobRunStatus = "running"
+	JobRunSucceeded JobRunStatus = "succeeded"
+	JobRunFailed    JobRunStatus = "failed"
+)
@@ -0,0 +1,23 @@
+package constants
+
+// Kinds of notifications sent to users
+type NotificationKind string
+
+const (
+	NotificationReminder     NotificationKind = "reminder"
+	NotificationDailySummary NotificationKind = "dailySummary"
+	NotificationAISuggestion NotificationKind = "aiSuggestion"
+)
+
+var NotificationKinds = []NotificationKind{NotificationReminder, NotificationDailySummary, NotificationAISuggestion}
+
+// Channels notifications are delivered through
+type NotificationChannel string
+
+const (
+	ChannelInApp   NotificationChannel = "inApp"
+	ChannelEmail   NotificationChannel = "email"
+	ChannelWebhook NotificationChannel = "webhook"
+)
+
+var NotificationChannels = []NotificationChannel{ChannelInApp, ChannelEmail, ChannelWebhook}
@@ -0,0 +1,30 @@
+package constants
+
+// Kinds of entities clients keep in sync
+type SyncEntity string
+
+const (
+	SyncEntityPlan          SyncEntity = "plan"
+	SyncEntityChecklistItem SyncEntity = "checklistItem"
+)
+
+// Operations clients push for an entity
+type SyncOp string
+
+const (
+	SyncOpCreate SyncOp = "create"
+	SyncOpUpdate SyncOp = "update"
+	SyncOpDelete SyncOp = "delete"
+)
+
+// Outcomes of a pushed mutation
+type SyncMutationStatus string
+
+const (
+	// the mutation was applied, or had been applied before
+	SyncMutationApplied SyncMutationStatus = "applied"
+	// the mutation was based on an outdated version, the server state was kept
+	SyncMutationConflict SyncMutationStatus = "conflict"
+	// the mutation was invalid and can never be applied
+	SyncMutationRejected SyncMutationStatus = "rejected"
+)
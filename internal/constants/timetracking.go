@@ -0,0 +1,24 @@
+package constants
+
+import "errors"
+
+// Kinds of time entries logged against checklist items
+type TimeEntryKind string
+
+const (
+	TimeEntryTimer    TimeEntryKind = "timer"
+	TimeEntryManual   TimeEntryKind = "manual"
+	TimeEntryPomodoro TimeEntryKind = "pomodoro"
+)
+
+// Periods time totals can be grouped by
+type TimePeriod string
+
+const (
+	PeriodDay  TimePeriod = "day"
+	PeriodWeek TimePeriod = "week"
+)
+
+var (
+	ErrTimerRunning = errors.New("A timer is already running, stop it before starting a new one.")
+)
@@ -0,0 +1,18 @@
+package constants
+
+// States of a webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+var WebhookDeliveryStatuses = []WebhookDeliveryStatus{WebhookDeliveryPending, WebhookDeliverySucceeded, WebhookDeliveryFailed}
+
+// event type of the test deliveries sent on request, never written to the outbox
+const WebhookPingEvent = "ping"
+
+// event type of notifications sent to the webhook channel, never written to the outbox
+const WebhookNotificationEvent EventType = "notification"
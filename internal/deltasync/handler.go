@@ -0,0 +1,88 @@
+package deltasync
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+}
+
+type Service interface {
+	GetChanges(ctx context.Context, userID uuid.UUID, rawToken string, limit int) (*Changes, error)
+	Push(ctx context.Context, userID uuid.UUID, req PushReq) ([]*MutationResult, error)
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+/**
+* Returns the plan and checklist item changes since the syncToken query parameter, or
+* everything for a full sync when it is left out. A 410 response means the token is too
+* old and the client has to start over with a full sync.
+**/
+func (h *Handler) GetChanges(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	var limit int
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "limit must be a number"})
+			return
+		}
+		limit = parsed
+	}
+
+	changes, err := h.service.GetChanges(c.Request.Context(), userId, c.Query("syncToken"), limit)
+	if err != nil {
+		status := statusFor(err)
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to get changes", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved changes", "result": changes})
+}
+
+// Push applies a batch of offline mutations, the result holds the outcome of each of them
+func (h *Handler) Push(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	var req PushReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid request body", "error": err.Error()})
+		return
+	}
+
+	results, err := h.service.Push(c.Request.Context(), userId, req)
+	if err != nil {
+		status := statusFor(err)
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to push mutations", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully pushed mutations", "result": results})
+}
+
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, constants.ErrSyncTokenExpired):
+		return http.StatusGone
+	case errors.Is(err, constants.ErrInvalidInput):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,81 @@
+package deltasync
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/models"
+	"github.com/google/uuid"
+)
+
+// the changes of a user since a sync token
+type Changes struct {
+	Plans          []*models.Plan          `json:"plans"`
+	ChecklistItems []*models.ChecklistItem `json:"checklistItems"`
+	Tombstones     []*Tombstone            `json:"tombstones"`
+	// token to request the next page with, or the next changes once HasMore is false
+	SyncToken string `json:"syncToken"`
+	HasMore   bool   `json:"hasMore"`
+}
+
+/**
+* Tells that an entity was removed. The tombstone of a plan also stands for every item
+* of the plan.
+**/
+type Tombstone struct {
+	Entity    constants.SyncEntity `db:"entity_type" json:"entity"`
+	ID        uuid.UUID            `db:"entity_id" json:"id"`
+	PlanID    *uuid.UUID           `db:"plan_id" json:"planId,omitempty"`
+	DeletedAt time.Time            `db:"deleted_at" json:"deletedAt"`
+}
+
+// an entity that changed, in the order of the change feed
+type change struct {
+	Entity    constants.SyncEntity `db:"entity_type"`
+	ID        uuid.UUID            `db:"entity_id"`
+	PlanID    *uuid.UUID           `db:"plan_id"`
+	ChangeXID string               `db:"change_xid"`
+	Deleted   bool                 `db:"deleted"`
+	DeletedAt *time.Time           `db:"deleted_at"`
+}
+
+type PushReq struct {
+	Mutations []Mutation `json:"mutations" binding:"required,min=1,max=100,dive"`
+}
+
+/**
+* A change made by a client while offline. Data holds the fields of the create or update,
+* in the same shape as the plan and checklist item endpoints take them.
+**/
+type Mutation struct {
+	ClientMutationID string               `json:"clientMutationId" binding:"required"`
+	Entity           constants.SyncEntity `json:"entity" binding:"required"`
+	Op               constants.SyncOp     `json:"op" binding:"required"`
+	ID               uuid.UUID            `json:"id" binding:"required"`
+	// plan a checklist item is created in
+	PlanID *uuid.UUID `json:"planId,omitempty"`
+	// version of the entity the client changed, required for updates
+	BaseVersion *int            `json:"baseVersion,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+}
+
+// the outcome of a mutation along with the server state of its entity
+type MutationResult struct {
+	ClientMutationID string                       `json:"clientMutationId"`
+	Status           constants.SyncMutationStatus `json:"status"`
+	Reason           string                       `json:"reason,omitempty"`
+	Entity           constants.SyncEntity         `json:"entity"`
+	ID               uuid.UUID                    `json:"id"`
+	Plan             *models.Plan                 `json:"plan,omitempty"`
+	ChecklistItem    *models.ChecklistItem        `json:"checklistItem,omitempty"`
+	// the entity no longer exists on the server
+	Deleted bool `json:"deleted"`
+}
+
+// a checklist item along with whether it was deleted
+type itemState struct {
+	models.ChecklistItem
+	UserID    uuid.UUID  `db:"user_id"`
+	DeletedAt *time.Time `db:"deleted_at"`
+}
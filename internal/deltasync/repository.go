@@ -0,0 +1,227 @@
+package deltasync
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/models"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{
+		db: db,
+	}
+}
+
+const planColumns = `id, user_id, name, description, focus, plan_type, daily_reset, auto_archive_completed_after_days, auto_archive_past_scheduled, version, created_at, updated_at`
+
+const checklistItemColumns = `checklist_items.id, checklist_items.description, checklist_items.notes, checklist_items.done, checklist_items.sequence,
+	checklist_items.scope, checklist_items.scheduled_time, checklist_items.due_date, checklist_items.priority, checklist_items.effort_estimate,
+	checklist_items.effort_unit, checklist_items.archived, checklist_items.created_at, checklist_items.updated_at, checklist_items.plan_id,
+	checklist_items.version, checklist_items.reminder_lead_minutes`
+
+// a page of the change feed along with where it ends
+type changePage struct {
+	changes *Changes
+	// the last change of the page, nil when it is empty
+	last *changeCursor
+	// the snapshot the page was read at
+	snapshot string
+}
+
+/**
+* Reads up to limit changes of a user after a position in the change feed. With a since
+* snapshot these are the rows changed by transactions not visible in it, removed rows
+* included, otherwise every row that exists. Everything is read from a single snapshot,
+* which is returned along with the page.
+**/
+func (r *repository) GetChanges(ctx context.Context, userID uuid.UUID, since string, after *changeCursor, limit int) (*changePage, error) {
+	query := `
+	SELECT entity_type, entity_id, plan_id, change_xid::TEXT AS change_xid, deleted, deleted_at
+	FROM (
+		SELECT 'plan' AS entity_type, id AS entity_id, id AS plan_id, change_xid, false AS deleted, NULL::TIMESTAMP WITH TIME ZONE AS deleted_at
+		FROM plans
+		WHERE user_id = $1
+
+		UNION ALL
+
+		SELECT 'checklistItem', checklist_items.id, checklist_items.plan_id, checklist_items.change_xid,
+			checklist_items.deleted_at IS NOT NULL, checklist_items.deleted_at
+		FROM checklist_items
+		JOIN plans ON plans.id = checklist_items.plan_id
+		WHERE plans.user_id = $1
+
+		UNION ALL
+
+		SELECT entity_type, entity_id, plan_id, change_xid, true, deleted_at
+		FROM sync_tombstones
+		WHERE user_id = $1
+	) changes
+	WHERE (
+		-- a full sync only holds what exists
+		($2::TEXT IS NULL AND NOT deleted)
+		OR (
+			$2::TEXT IS NOT NULL
+			AND change_xid >= pg_snapshot_xmin($2::TEXT::PG_SNAPSHOT)
+			AND NOT pg_visible_in_snapshot(change_xid, $2::TEXT::PG_SNAPSHOT)
+		)
+	)
+	AND ($3::TEXT IS NULL OR (change_xid, entity_type, entity_id) > ($3::TEXT::XID8, $4::TEXT, $5::UUID))
+	ORDER BY change_xid, entity_type, entity_id
+	LIMIT $6
+	`
+
+	var sinceArg, afterXID, afterEntity, afterID interface{}
+	if since != "" {
+		sinceArg = since
+	}
+	if after != nil {
+		afterXID, afterEntity, afterID = after.ChangeXID, string(after.Entity), after.ID
+	}
+
+	page := &changePage{
+		changes: &Changes{
+			Plans:          []*models.Plan{},
+			ChecklistItems: []*models.ChecklistItem{},
+			Tombstones:     []*Tombstone{},
+		},
+	}
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.GetContext(ctx, &page.snapshot, `SELECT pg_current_snapshot()::TEXT`); err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	// one extra row tells whether there are more changes
+	changes := []*change{}
+	if err := tx.SelectContext(ctx, &changes, query, userID, sinceArg, afterXID, afterEntity, afterID, limit+1); err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	if len(changes) > limit {
+		changes = changes[:limit]
+		page.changes.HasMore = true
+	}
+
+	var planIDs, itemIDs []uuid.UUID
+	for _, c := range changes {
+		switch {
+		case c.Deleted:
+			tombstone := &Tombstone{Entity: c.Entity, ID: c.ID, PlanID: c.PlanID}
+			if c.DeletedAt != nil {
+				tombstone.DeletedAt = *c.DeletedAt
+			}
+			page.changes.Tombstones = append(page.changes.Tombstones, tombstone)
+		case c.Entity == constants.SyncEntityPlan:
+			planIDs = append(planIDs, c.ID)
+		default:
+			itemIDs = append(itemIDs, c.ID)
+		}
+	}
+
+	if len(changes) > 0 {
+		last := changes[len(changes)-1]
+		page.last = &changeCursor{ChangeXID: last.ChangeXID, Entity: last.Entity, ID: last.ID}
+	}
+
+	if len(planIDs) > 0 {
+		err := tx.SelectContext(ctx, &page.changes.Plans, `
+			SELECT `+planColumns+`
+			FROM plans
+			WHERE id = ANY($1)
+			ORDER BY change_xid, id
+		`, pq.Array(planIDs))
+		if err != nil {
+			return nil, errorutils.AnalyzeDBErr(err)
+		}
+	}
+
+	if len(itemIDs) > 0 {
+		err := tx.SelectContext(ctx, &page.changes.ChecklistItems, `
+			SELECT `+checklistItemColumns+`
+			FROM checklist_items
+			WHERE id = ANY($1)
+			ORDER BY change_xid, id
+		`, pq.Array(itemIDs))
+		if err != nil {
+			return nil, errorutils.AnalyzeDBErr(err)
+		}
+	}
+
+	return page, nil
+}
+
+func (r *repository) GetPlan(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*models.Plan, error) {
+	query := `
+	SELECT ` + planColumns + `
+	FROM plans
+	WHERE id = $1 AND user_id = $2
+	`
+
+	var plan models.Plan
+	if err := r.db.GetContext(ctx, &plan, query, id, userID); err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &plan, nil
+}
+
+// GetItem returns a checklist item of a user, deleted items included
+func (r *repository) GetItem(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*itemState, error) {
+	query := `
+	SELECT ` + checklistItemColumns + `, plans.user_id, checklist_items.deleted_at
+	FROM checklist_items
+	JOIN plans ON plans.id = checklist_items.plan_id
+	WHERE checklist_items.id = $1 AND plans.user_id = $2
+	`
+
+	var item itemState
+	if err := r.db.GetContext(ctx, &item, query, id, userID); err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &item, nil
+}
+
+// IsRemoved reports whether an entity of a user was removed for good
+func (r *repository) IsRemoved(ctx context.Context, userID uuid.UUID, entity constants.SyncEntity, id uuid.UUID) (bool, error) {
+	query := `
+	SELECT EXISTS (
+		SELECT 1
+		FROM sync_tombstones
+		WHERE entity_id = $1 AND user_id = $2 AND entity_type = $3
+	)
+	`
+
+	var removed bool
+	if err := r.db.GetContext(ctx, &removed, query, id, userID, string(entity)); err != nil {
+		return false, errorutils.AnalyzeDBErr(err)
+	}
+
+	return removed, nil
+}
+
+// PurgeTombstones removes the tombstones of rows removed before the cutoff
+func (r *repository) PurgeTombstones(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sync_tombstones WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, errorutils.AnalyzeDBErr(err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,463 @@
+package deltasync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/checklistitems"
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/models"
+	"github.com/darkphotonKN/fireplace/internal/plans"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultChangesLimit = 500
+	maxChangesLimit     = 1000
+
+	// how long tombstones are kept, older sync tokens require a full sync
+	tombstoneRetention = 30 * 24 * time.Hour
+)
+
+type service struct {
+	repo             Repository
+	planService      plans.Service
+	checklistService SyncChecklistService
+}
+
+type SyncChecklistService interface {
+	CreateWithID(ctx context.Context, id uuid.UUID, req checklistitems.CreateReq, planID uuid.UUID) (*models.ChecklistItem, error)
+	Update(ctx context.Context, id uuid.UUID, req checklistitems.UpdateReq) error
+	Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID, version *int) (*checklistitems.Operation, error)
+}
+
+type Repository interface {
+	GetChanges(ctx context.Context, userID uuid.UUID, since string, after *changeCursor, limit int) (*changePage, error)
+	GetPlan(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*models.Plan, error)
+	GetItem(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*itemState, error)
+	IsRemoved(ctx context.Context, userID uuid.UUID, entity constants.SyncEntity, id uuid.UUID) (bool, error)
+	PurgeTombstones(ctx context.Context, before time.Time) (int64, error)
+}
+
+func NewService(repo Repository, planService plans.Service, checklistService SyncChecklistService) *service {
+	return &service{
+		repo:             repo,
+		planService:      planService,
+		checklistService: checklistService,
+	}
+}
+
+/**
+* Returns a page of the changes of a user since a sync token, or every plan and checklist
+* item for a full sync when there is no token. The returned token requests the next page
+* while HasMore is set, and the changes made after this sync once it is not.
+**/
+func (s *service) GetChanges(ctx context.Context, userID uuid.UUID, rawToken string, limit int) (*Changes, error) {
+	if limit <= 0 {
+		limit = defaultChangesLimit
+	}
+	if limit > maxChangesLimit {
+		limit = maxChangesLimit
+	}
+
+	token := &syncToken{}
+	if rawToken != "" {
+		decoded, err := decodeToken(rawToken, tombstoneRetention)
+		if err != nil {
+			return nil, err
+		}
+		token = decoded
+	}
+
+	page, err := s.repo.GetChanges(ctx, userID, token.Since, token.After, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// the first page fixes the snapshot the whole sync is taken at
+	until, untilAt := token.Until, token.UntilAt
+	if until == "" {
+		until, untilAt = page.snapshot, time.Now().Unix()
+	}
+
+	next := syncToken{Since: until, SinceAt: untilAt}
+	if page.changes.HasMore {
+		next = syncToken{
+			Since:   token.Since,
+			SinceAt: token.SinceAt,
+			Until:   until,
+			UntilAt: untilAt,
+			After:   page.last,
+		}
+	}
+
+	page.changes.SyncToken, err = next.encode()
+	if err != nil {
+		return nil, err
+	}
+
+	return page.changes, nil
+}
+
+/**
+* Applies the mutations a client made offline, in order and each on its own. Conflicts are
+* resolved deterministically, the first change to reach the server wins:
+*
+* - updates and deletes apply only to the version the client based them on, otherwise
+*   they conflict and the current server state is returned for the client to rebase on.
+* - removal wins, changes to a removed entity conflict and report it as deleted.
+* - creates are idempotent, creating an entity that already exists returns it.
+*
+* Mutations that can never apply are rejected, the rest of the batch still goes through.
+**/
+func (s *service) Push(ctx context.Context, userID uuid.UUID, req PushReq) ([]*MutationResult, error) {
+	results := make([]*MutationResult, 0, len(req.Mutations))
+
+	for _, mutation := range req.Mutations {
+		result := &MutationResult{
+			ClientMutationID: mutation.ClientMutationID,
+			Entity:           mutation.Entity,
+			ID:               mutation.ID,
+		}
+
+		var err error
+		switch mutation.Entity {
+		case constants.SyncEntityPlan:
+			err = s.applyPlanMutation(ctx, userID, mutation, result)
+		case constants.SyncEntityChecklistItem:
+			err = s.applyItemMutation(ctx, userID, mutation, result)
+		default:
+			err = errorutils.Invalidf("entity must be one of 'plan' or 'checklistItem'")
+		}
+
+		if err != nil {
+			if !isRejection(err) {
+				return nil, err
+			}
+			result.Status = constants.SyncMutationRejected
+			result.Reason = err.Error()
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (s *service) applyPlanMutation(ctx context.Context, userID uuid.UUID, mutation Mutation, result *MutationResult) error {
+	plan, err := s.findPlan(ctx, userID, mutation.ID)
+	if err != nil {
+		return err
+	}
+
+	if plan == nil && mutation.Op != constants.SyncOpDelete {
+		return s.missing(ctx, userID, mutation, result)
+	}
+
+	switch mutation.Op {
+	case constants.SyncOpCreate:
+		// creating again returns the plan, missing plans were created above
+		return applied(result, plan, nil)
+
+	case constants.SyncOpUpdate:
+		if mutation.BaseVersion == nil {
+			return errorutils.Invalidf("baseVersion is required to update a plan")
+		}
+		if plan.Version != *mutation.BaseVersion {
+			return conflict(result, "plan was changed since the base version", plan, nil)
+		}
+
+		var req plans.UpdatePlanReq
+		if err := decodeData(mutation.Data, &req); err != nil {
+			return err
+		}
+		req.Version = mutation.BaseVersion
+
+		err := s.planService.Update(ctx, mutation.ID, req, userID)
+		if errors.Is(err, constants.ErrPreconditionFailed) || errors.Is(err, constants.ErrNotFound) {
+			return s.planConflict(ctx, userID, mutation.ID, result)
+		}
+		if err != nil {
+			return err
+		}
+
+		plan, err := s.repo.GetPlan(ctx, userID, mutation.ID)
+		if err != nil {
+			return err
+		}
+		return applied(result, plan, nil)
+
+	case constants.SyncOpDelete:
+		// deleting what is already gone is what the client wanted
+		if plan == nil {
+			result.Status = constants.SyncMutationApplied
+			result.Deleted = true
+			return nil
+		}
+		if mutation.BaseVersion != nil && plan.Version != *mutation.BaseVersion {
+			return conflict(result, "plan was changed since the base version", plan, nil)
+		}
+
+		err := s.planService.Delete(ctx, mutation.ID, userID, mutation.BaseVersion)
+		if errors.Is(err, constants.ErrPreconditionFailed) {
+			return s.planConflict(ctx, userID, mutation.ID, result)
+		}
+		if err != nil && !errors.Is(err, constants.ErrNotFound) {
+			return err
+		}
+
+		result.Status = constants.SyncMutationApplied
+		result.Deleted = true
+		return nil
+	}
+
+	return errorutils.Invalidf("op must be one of 'create', 'update' or 'delete'")
+}
+
+func (s *service) applyItemMutation(ctx context.Context, userID uuid.UUID, mutation Mutation, result *MutationResult) error {
+	item, err := s.findItem(ctx, userID, mutation.ID)
+	if err != nil {
+		return err
+	}
+
+	// items that were deleted but can still be restored count as removed
+	if item != nil && item.DeletedAt != nil {
+		if mutation.Op == constants.SyncOpDelete {
+			result.Status = constants.SyncMutationApplied
+			result.Deleted = true
+			return nil
+		}
+		return conflict(result, "checklist item was deleted", nil, nil)
+	}
+
+	if item == nil && mutation.Op != constants.SyncOpDelete {
+		return s.missing(ctx, userID, mutation, result)
+	}
+
+	switch mutation.Op {
+	case constants.SyncOpCreate:
+		return applied(result, nil, &item.ChecklistItem)
+
+	case constants.SyncOpUpdate:
+		if mutation.BaseVersion == nil {
+			return errorutils.Invalidf("baseVersion is required to update a checklist item")
+		}
+		if item.Version != *mutation.BaseVersion {
+			return conflict(result, "checklist item was changed since the base version", nil, &item.ChecklistItem)
+		}
+
+		var req checklistitems.UpdateReq
+		if err := decodeData(mutation.Data, &req); err != nil {
+			return err
+		}
+		req.Version = mutation.BaseVersion
+
+		err := s.checklistService.Update(ctx, mutation.ID, req)
+		if errors.Is(err, constants.ErrPreconditionFailed) || errors.Is(err, constants.ErrNotFound) {
+			return s.itemConflict(ctx, userID, mutation.ID, result)
+		}
+		if err != nil {
+			return err
+		}
+
+		item, err := s.repo.GetItem(ctx, userID, mutation.ID)
+		if err != nil {
+			return err
+		}
+		return applied(result, nil, &item.ChecklistItem)
+
+	case constants.SyncOpDelete:
+		if item == nil {
+			result.Status = constants.SyncMutationApplied
+			result.Deleted = true
+			return nil
+		}
+		if mutation.BaseVersion != nil && item.Version != *mutation.BaseVersion {
+			return conflict(result, "checklist item was changed since the base version", nil, &item.ChecklistItem)
+		}
+
+		_, err := s.checklistService.Delete(ctx, userID, mutation.ID, mutation.BaseVersion)
+		if errors.Is(err, constants.ErrPreconditionFailed) {
+			return s.itemConflict(ctx, userID, mutation.ID, result)
+		}
+		if err != nil && !errors.Is(err, constants.ErrNotFound) {
+			return err
+		}
+
+		result.Status = constants.SyncMutationApplied
+		result.Deleted = true
+		return nil
+	}
+
+	return errorutils.Invalidf("op must be one of 'create', 'update' or 'delete'")
+}
+
+/**
+* Handles a mutation of an entity the user does not have. Creates go through unless the
+* id was removed before, changes to removed entities conflict and the rest are rejected.
+**/
+func (s *service) missing(ctx context.Context, userID uuid.UUID, mutation Mutation, result *MutationResult) error {
+	removed, err := s.repo.IsRemoved(ctx, userID, mutation.Entity, mutation.ID)
+	if err != nil {
+		return err
+	}
+
+	if removed {
+		return conflict(result, string(mutation.Entity)+" was deleted", nil, nil)
+	}
+
+	if mutation.Op != constants.SyncOpCreate {
+		return errorutils.Invalidf("%s %s does not exist", mutation.Entity, mutation.ID)
+	}
+
+	if mutation.Entity == constants.SyncEntityPlan {
+		return s.createPlan(ctx, userID, mutation, result)
+	}
+
+	return s.createItem(ctx, userID, mutation, result)
+}
+
+func (s *service) createPlan(ctx context.Context, userID uuid.UUID, mutation Mutation, result *MutationResult) error {
+	var req plans.CreatePlanReq
+	if err := decodeData(mutation.Data, &req); err != nil {
+		return err
+	}
+	plan, err := s.planService.CreateWithID(ctx, mutation.ID, req, userID)
+	if err != nil {
+		return err
+	}
+
+	return applied(result, plan, nil)
+}
+
+func (s *service) createItem(ctx context.Context, userID uuid.UUID, mutation Mutation, result *MutationResult) error {
+	if mutation.PlanID == nil {
+		return errorutils.Invalidf("planId is required to create a checklist item")
+	}
+
+	plan, err := s.findPlan(ctx, userID, *mutation.PlanID)
+	if err != nil {
+		return err
+	}
+
+	if plan == nil {
+		removed, err := s.repo.IsRemoved(ctx, userID, constants.SyncEntityPlan, *mutation.PlanID)
+		if err != nil {
+			return err
+		}
+		if removed {
+			return conflict(result, "plan of the checklist item was deleted", nil, nil)
+		}
+		return errorutils.Invalidf("plan %s does not exist", *mutation.PlanID)
+	}
+
+	var req checklistitems.CreateReq
+	if err := decodeData(mutation.Data, &req); err != nil {
+		return err
+	}
+	item, err := s.checklistService.CreateWithID(ctx, mutation.ID, req, plan.ID)
+	if err != nil {
+		return err
+	}
+
+	return applied(result, nil, item)
+}
+
+// reports the current state of a plan a write lost against
+func (s *service) planConflict(ctx context.Context, userID uuid.UUID, id uuid.UUID, result *MutationResult) error {
+	plan, err := s.findPlan(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if plan == nil {
+		return conflict(result, "plan was deleted", nil, nil)
+	}
+
+	return conflict(result, "plan was changed since the base version", plan, nil)
+}
+
+// reports the current state of a checklist item a write lost against
+func (s *service) itemConflict(ctx context.Context, userID uuid.UUID, id uuid.UUID, result *MutationResult) error {
+	item, err := s.findItem(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if item == nil || item.DeletedAt != nil {
+		return conflict(result, "checklist item was deleted", nil, nil)
+	}
+
+	return conflict(result, "checklist item was changed since the base version", nil, &item.ChecklistItem)
+}
+
+// returns the plan of a user, or nil when there is none
+func (s *service) findPlan(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*models.Plan, error) {
+	plan, err := s.repo.GetPlan(ctx, userID, id)
+	if errors.Is(err, constants.ErrNotFound) {
+		return nil, nil
+	}
+
+	return plan, err
+}
+
+// returns the checklist item of a user, or nil when there is none
+func (s *service) findItem(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*itemState, error) {
+	item, err := s.repo.GetItem(ctx, userID, id)
+	if errors.Is(err, constants.ErrNotFound) {
+		return nil, nil
+	}
+
+	return item, err
+}
+
+// PurgeTombstones removes the tombstones that are no longer needed, returning how many were removed
+func (s *service) PurgeTombstones(ctx context.Context) (int64, error) {
+	return s.repo.PurgeTombstones(ctx, time.Now().Add(-tombstoneRetention))
+}
+
+func applied(result *MutationResult, plan *models.Plan, item *models.ChecklistItem) error {
+	result.Status = constants.SyncMutationApplied
+	result.Plan = plan
+	result.ChecklistItem = item
+	return nil
+}
+
+// marks a conflict, the entity is reported as deleted when there is no server state
+func conflict(result *MutationResult, reason string, plan *models.Plan, item *models.ChecklistItem) error {
+	result.Status = constants.SyncMutationConflict
+	result.Reason = reason
+	result.Plan = plan
+	result.ChecklistItem = item
+	result.Deleted = plan == nil && item == nil
+	return nil
+}
+
+// decodes the data of a mutation the same way the endpoints bind their request bodies
+func decodeData(data json.RawMessage, dst interface{}) error {
+	if len(data) == 0 {
+		return errorutils.Invalidf("data is required")
+	}
+
+	if err := json.Unmarshal(data, dst); err != nil {
+		return errorutils.Invalidf("data is malformed: %s", err.Error())
+	}
+
+	if err := binding.Validator.ValidateStruct(dst); err != nil {
+		return errorutils.Invalidf("data is invalid: %s", err.Error())
+	}
+
+	return nil
+}
+
+// errors that a mutation will fail with no matter how often it is retried
+func isRejection(err error) bool {
+	return errors.Is(err, constants.ErrInvalidInput) ||
+		errors.Is(err, constants.ErrInvalidScope) ||
+		errors.Is(err, constants.ErrConstraintViolation) ||
+		errors.Is(err, constants.ErrDuplicateResource)
+}
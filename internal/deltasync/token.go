@@ -0,0 +1,88 @@
+package deltasync
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"regexp"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/google/uuid"
+)
+
+/**
+* Sync tokens are opaque to clients. They hold the database snapshot a client synced at,
+* and every change made by a transaction that was not yet visible in that snapshot is
+* sent next time, including transactions that were still running and commit later. While
+* a sync is paged, the token also holds the snapshot the sync started at, which becomes
+* the token once the last page was read, and where the previous page ended.
+**/
+type syncToken struct {
+	// snapshot the client synced at, empty for a full sync
+	Since string `json:"s,omitempty"`
+	// when the Since snapshot was taken, in unix seconds
+	SinceAt int64 `json:"st,omitempty"`
+	// snapshot the paged sync started at
+	Until   string `json:"u,omitempty"`
+	UntilAt int64  `json:"ut,omitempty"`
+	// where the previous page ended
+	After *changeCursor `json:"a,omitempty"`
+}
+
+// text form of a postgres snapshot, xmin:xmax:xip_list
+var snapshotPattern = regexp.MustCompile(`^\d+:\d+:(\d+(,\d+)*)?$`)
+
+var xidPattern = regexp.MustCompile(`^\d+$`)
+
+// position in the change feed, changes are ordered by transaction, entity and id
+type changeCursor struct {
+	ChangeXID string               `json:"x"`
+	Entity    constants.SyncEntity `json:"e"`
+	ID        uuid.UUID            `json:"i"`
+}
+
+func (t syncToken) encode() (string, error) {
+	raw, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodes a token, failing for tokens older than the tombstones are kept for
+func decodeToken(raw string, maxAge time.Duration) (*syncToken, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, errorutils.Invalidf("malformed sync token")
+	}
+
+	var token syncToken
+	if err := json.Unmarshal(decoded, &token); err != nil || !token.valid() {
+		return nil, errorutils.Invalidf("malformed sync token")
+	}
+
+	if token.Since != "" && time.Since(time.Unix(token.SinceAt, 0)) > maxAge {
+		return nil, constants.ErrSyncTokenExpired
+	}
+
+	return &token, nil
+}
+
+func (t syncToken) valid() bool {
+	if t.Since == "" && t.Until == "" {
+		return false
+	}
+	if t.Since != "" && !snapshotPattern.MatchString(t.Since) {
+		return false
+	}
+	if t.Until != "" && !snapshotPattern.MatchString(t.Until) {
+		return false
+	}
+	if t.After != nil && (t.Until == "" || !xidPattern.MatchString(t.After.ChangeXID)) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,91 @@
+package deltasync
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/google/uuid"
+)
+
+func TestTokenRoundTrip(t *testing.T) {
+	now := time.Now().Unix()
+	id := uuid.MustParse("6b1f0c1e-3d4b-4c55-9a0e-1f2d3c4b5a69")
+
+	tests := []struct {
+		name  string
+		token syncToken
+	}{
+		{"synced", syncToken{Since: "100:105:101,103", SinceAt: now}},
+		{"first page of a full sync", syncToken{Until: "100:100:", UntilAt: now}},
+		{
+			"paged sync",
+			syncToken{
+				Since:   "90:95:",
+				SinceAt: now,
+				Until:   "100:105:101",
+				UntilAt: now,
+				After:   &changeCursor{ChangeXID: "99", Entity: constants.SyncEntityPlan, ID: id},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := tt.token.encode()
+			if err != nil {
+				t.Fatalf("encode returned error: %v", err)
+			}
+
+			got, err := decodeToken(raw, time.Hour)
+			if err != nil {
+				t.Fatalf("decodeToken returned error: %v", err)
+			}
+
+			if !reflect.DeepEqual(*got, tt.token) {
+				t.Errorf("decoded %+v, want %+v", *got, tt.token)
+			}
+		})
+	}
+}
+
+func TestDecodeTokenInvalid(t *testing.T) {
+	now := time.Now().Unix()
+	old := time.Now().Add(-2 * time.Hour).Unix()
+
+	encode := func(token syncToken) string {
+		raw, err := token.encode()
+		if err != nil {
+			t.Fatalf("encode returned error: %v", err)
+		}
+		return raw
+	}
+
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr error
+	}{
+		{"not base64", "!!!", constants.ErrInvalidInput},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("nope")), constants.ErrInvalidInput},
+		{"empty", encode(syncToken{}), constants.ErrInvalidInput},
+		{"malformed snapshot", encode(syncToken{Since: "100;105", SinceAt: now}), constants.ErrInvalidInput},
+		{"snapshot with sql", encode(syncToken{Since: "1:2:'); DROP TABLE plans; --", SinceAt: now}), constants.ErrInvalidInput},
+		{"malformed until", encode(syncToken{Until: "abc", UntilAt: now}), constants.ErrInvalidInput},
+		{"cursor without until", encode(syncToken{Since: "1:2:", SinceAt: now, After: &changeCursor{ChangeXID: "1"}}), constants.ErrInvalidInput},
+		{"malformed cursor", encode(syncToken{Until: "1:2:", UntilAt: now, After: &changeCursor{ChangeXID: "x1"}}), constants.ErrInvalidInput},
+		{"expired", encode(syncToken{Since: "100:105:", SinceAt: old}), constants.ErrSyncTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeToken(tt.raw, time.Hour)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("decodeToken error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/darkphotonKN/fireplace/internal/utils/httputils"
+	"golang.org/x/net/html"
+)
+
+const (
+	maxMetadataBodyBytes = 1 << 20 // only the head of a page is needed
+	metadataUserAgent    = "Mozilla/5.0 (compatible; FireplaceBot/1.0; +link-preview)"
+)
+
+// metadata describing a linked web page
+type PageMetadata struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	SiteName    string `json:"siteName"`
+	ImageURL    string `json:"imageUrl"`
+}
+
+type MetadataFetcher struct {
+	client *http.Client
+}
+
+/**
+* Creates a fetcher for link previews. Only http(s) urls on public addresses are fetched,
+* following up to 3 redirects.
+**/
+func NewMetadataFetcher() *MetadataFetcher {
+	return &MetadataFetcher{
+		client: httputils.NewPublicClient(3, false),
+	}
+}
+
+/**
+* Fetches a page and reads its title, description, site name and preview image from the
+* open graph tags, falling back to the standard html tags.
+**/
+func (f *MetadataFetcher) FetchMetadata(ctx context.Context, rawURL string) (*PageMetadata, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http or https url")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", metadataUserAgent)
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching URL %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("non-200 status code: %d for URL %s", resp.StatusCode, rawURL)
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" && !strings.Contains(contentType, "html") {
+		return nil, fmt.Errorf("url does not point to an html page")
+	}
+
+	metadata := parseMetadata(io.LimitReader(resp.Body, maxMetadataBodyBytes))
+
+	// resolve relative preview images against the final page url
+	if metadata.ImageURL != "" {
+		if imageURL, err := resp.Request.URL.Parse(metadata.ImageURL); err == nil {
+			metadata.ImageURL = imageURL.String()
+		}
+	}
+
+	return metadata, nil
+}
+
+// walks the html tokens until the end of the head collecting title and meta tags
+func parseMetadata(body io.Reader) *PageMetadata {
+	metadata := &PageMetadata{}
+	tokenizer := html.NewTokenizer(body)
+
+	var title string
+	inTitle := false
+
+	for {
+		tokenType := tokenizer.Next()
+
+		switch tokenType {
+		case html.ErrorToken:
+			if metadata.Title == "" {
+				metadata.Title = strings.TrimSpace(title)
+			}
+			return metadata
+
+		case html.TextToken:
+			if inTitle {
+				title += string(tokenizer.Text())
+			}
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				if metadata.Title == "" {
+					metadata.Title = strings.TrimSpace(title)
+				}
+				return metadata
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+
+			switch token.Data {
+			case "title":
+				inTitle = tokenType == html.StartTagToken
+			case "meta":
+				applyMetaTag(metadata, token)
+			case "body":
+				if metadata.Title == "" {
+					metadata.Title = strings.TrimSpace(title)
+				}
+				return metadata
+			}
+		}
+	}
+}
+
+func applyMetaTag(metadata *PageMetadata, token html.Token) {
+	var key, content string
+
+	for _, attr := range token.Attr {
+		switch attr.Key {
+		case "property", "name":
+			key = strings.ToLower(attr.Val)
+		case "content":
+			content = strings.TrimSpace(attr.Val)
+		}
+	}
+
+	if content == "" {
+		return
+	}
+
+	switch key {
+	case "og:title":
+		metadata.Title = content
+	case "og:description":
+		metadata.Description = content
+	case "description":
+		if metadata.Description == "" {
+			metadata.Description = content
+		}
+	case "og:site_name":
+		metadata.SiteName = content
+	case "og:image":
+		metadata.ImageURL = content
+	}
+}
@@ -0,0 +1,162 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/google/uuid"
+)
+
+const (
+	// events claimed per dispatch
+	dispatchBatchSize = 100
+	// how long a claimed event is reserved for delivery before it can be claimed again
+	claimLease = 5 * time.Minute
+
+	// failed deliveries are retried after 10s, 20s, 40s, ... up to an hour apart
+	retryBaseDelay = 10 * time.Second
+	retryMaxDelay  = time.Hour
+	// events that failed this often are given up on
+	maxDeliveryAttempts = 12
+
+	// delivered and failed events are kept this long before they are purged
+	outboxRetention = 7 * 24 * time.Hour
+	// latest events of every user, and of every plan of a user, that are never purged so
+	// that realtime clients can resume from them
+	ReplayWindow = 1000
+)
+
+type Repository interface {
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*Event, error)
+	MarkDelivered(ctx context.Context, id uuid.UUID) error
+	ScheduleRetry(ctx context.Context, id uuid.UUID, nextAttemptAt time.Time, lastError string) error
+	MarkFailed(ctx context.Context, id uuid.UUID, lastError string) error
+	PurgeProcessed(ctx context.Context, before time.Time, keep int) (int64, error)
+}
+
+/**
+* Receives the events it subscribed to. Events are delivered at least once, so handling
+* the same event twice must be harmless, and the order is not guaranteed after retries.
+**/
+type Subscriber interface {
+	Handle(ctx context.Context, event *Event) error
+}
+
+/**
+* Delivers the events of the outbox to their subscribers. An event counts as delivered
+* once every subscriber handled it, otherwise it is retried with exponential backoff.
+**/
+type Dispatcher struct {
+	repo Repository
+
+	// subscribers by event type, the ones of all types under ""
+	subscribers map[constants.EventType][]Subscriber
+	mu          sync.RWMutex
+}
+
+func NewDispatcher(repo Repository) *Dispatcher {
+	return &Dispatcher{
+		repo:        repo,
+		subscribers: make(map[constants.EventType][]Subscriber),
+	}
+}
+
+// Subscribe registers a subscriber for the given event types, or for every type if none are given
+func (d *Dispatcher) Subscribe(subscriber Subscriber, eventTypes ...constants.EventType) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(eventTypes) == 0 {
+		eventTypes = []constants.EventType{""}
+	}
+
+	for _, eventType := range eventTypes {
+		d.subscribers[eventType] = append(d.subscribers[eventType], subscriber)
+	}
+}
+
+/**
+* Delivers the events that are due, run periodically by the outbox dispatch job. Returns
+* how many events were delivered, failed deliveries are scheduled for a retry.
+**/
+func (d *Dispatcher) DispatchPending(ctx context.Context) (int64, error) {
+	events, err := d.repo.ClaimDue(ctx, dispatchBatchSize, claimLease)
+	if err != nil {
+		return 0, err
+	}
+
+	var delivered int64
+	var errs []error
+	for _, event := range events {
+		if err := d.deliver(ctx, event); err != nil {
+			if err := d.retryLater(ctx, event, err); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		if err := d.repo.MarkDelivered(ctx, event.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delivered++
+	}
+
+	return delivered, errors.Join(errs...)
+}
+
+/**
+* Purges the delivered and failed events that are past the retention, run periodically by
+* the outbox purge job. One more event than the replay window is kept so that clients
+* that missed more than it can still tell. Returns how many events were purged.
+**/
+func (d *Dispatcher) PurgeProcessed(ctx context.Context) (int64, error) {
+	return d.repo.PurgeProcessed(ctx, time.Now().Add(-outboxRetention), ReplayWindow+1)
+}
+
+// hands an event to each of its subscribers
+func (d *Dispatcher) deliver(ctx context.Context, event *Event) error {
+	d.mu.RLock()
+	subscribers := append([]Subscriber{}, d.subscribers[constants.EventType(event.Type)]...)
+	subscribers = append(subscribers, d.subscribers[""]...)
+	d.mu.RUnlock()
+
+	var errs []error
+	for _, subscriber := range subscribers {
+		if err := subscriber.Handle(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (d *Dispatcher) retryLater(ctx context.Context, event *Event, deliveryErr error) error {
+	attempts := event.Attempts + 1
+
+	if attempts >= maxDeliveryAttempts {
+		fmt.Printf("Giving up on %s event %s after %d attempts: %s\n", event.Type, event.ID, attempts, deliveryErr.Error())
+		return d.repo.MarkFailed(ctx, event.ID, deliveryErr.Error())
+	}
+
+	fmt.Printf("Error delivering %s event %s, attempt %d: %s\n", event.Type, event.ID, attempts, deliveryErr.Error())
+
+	return d.repo.ScheduleRetry(ctx, event.ID, time.Now().Add(backoff(attempts)), deliveryErr.Error())
+}
+
+// delay before the next attempt after the given number of failed attempts
+func backoff(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+
+	return delay
+}
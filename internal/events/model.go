@@ -0,0 +1,33 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/google/uuid"
+)
+
+/**
+* A domain event to write to the outbox. UserID can be left out for events of a plan,
+* it is then taken from the plan.
+**/
+type NewEvent struct {
+	Type        constants.EventType
+	UserID      *uuid.UUID
+	PlanID      *uuid.UUID
+	AggregateID uuid.UUID
+	Payload     interface{}
+}
+
+// a domain event read back from the outbox
+type Event struct {
+	ID          uuid.UUID       `db:"id" json:"id"`
+	Type        string          `db:"type" json:"type"`
+	UserID      uuid.UUID       `db:"user_id" json:"userId"`
+	PlanID      *uuid.UUID      `db:"plan_id" json:"planId,omitempty"`
+	AggregateID uuid.UUID       `db:"aggregate_id" json:"aggregateId"`
+	Payload     json.RawMessage `db:"payload" json:"payload"`
+	OccurredAt  time.Time       `db:"occurred_at" json:"occurredAt"`
+	Attempts    int             `db:"attempts" json:"attempts"`
+}
@@ -0,0 +1,41 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/jmoiron/sqlx"
+)
+
+/**
+* Writes domain events to the outbox within the transaction of the change they describe,
+* so that an event is recorded if and only if the change is committed. Call it from within
+* dbutils.ExecTx.
+**/
+func Enqueue(ctx context.Context, tx *sqlx.Tx, events ...NewEvent) error {
+	query := `
+	INSERT INTO outbox_events (type, user_id, plan_id, aggregate_id, payload)
+	VALUES (
+		$1,
+		COALESCE($2, (SELECT user_id FROM plans WHERE id = $3)),
+		$3,
+		$4,
+		$5
+	)
+	`
+
+	for _, event := range events {
+		payload, err := json.Marshal(event.Payload)
+		if err != nil {
+			return fmt.Errorf("encoding payload of %s event: %w", event.Type, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, query, event.Type, event.UserID, event.PlanID, event.AggregateID, payload); err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+	}
+
+	return nil
+}
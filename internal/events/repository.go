@@ -0,0 +1,134 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{
+		db: db,
+	}
+}
+
+/**
+* Claims up to limit events that are due for delivery, oldest first. Claimed events are
+* leased until the lease passes, after which they are claimed again unless their delivery
+* was recorded.
+**/
+func (r *repository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*Event, error) {
+	query := `
+	UPDATE outbox_events SET
+		next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+	WHERE id IN (
+		SELECT id
+		FROM outbox_events
+		WHERE delivered_at IS NULL
+		AND failed_at IS NULL
+		AND next_attempt_at <= NOW()
+		ORDER BY occurred_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, type, user_id, plan_id, aggregate_id, payload, occurred_at, attempts
+	`
+
+	events := []*Event{}
+	if err := r.db.SelectContext(ctx, &events, query, limit, lease.Milliseconds()); err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return events, nil
+}
+
+func (r *repository) MarkDelivered(ctx context.Context, id uuid.UUID) error {
+	query := `
+	UPDATE outbox_events SET
+		attempts = attempts + 1,
+		delivered_at = NOW(),
+		last_error = NULL
+	WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+
+	return errorutils.AnalyzeDBResults(err, result)
+}
+
+// ScheduleRetry records a failed delivery attempt and when to attempt it again
+func (r *repository) ScheduleRetry(ctx context.Context, id uuid.UUID, nextAttemptAt time.Time, lastError string) error {
+	query := `
+	UPDATE outbox_events SET
+		attempts = attempts + 1,
+		next_attempt_at = $2,
+		last_error = $3
+	WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, nextAttemptAt, lastError)
+
+	return errorutils.AnalyzeDBResults(err, result)
+}
+
+// MarkFailed gives up on an event that ran out of delivery attempts
+func (r *repository) MarkFailed(ctx context.Context, id uuid.UUID, lastError string) error {
+	query := `
+	UPDATE outbox_events SET
+		attempts = attempts + 1,
+		failed_at = NOW(),
+		last_error = $2
+	WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, lastError)
+
+	return errorutils.AnalyzeDBResults(err, result)
+}
+
+/**
+* Deletes the delivered and failed events that occurred before a time, except for the
+* latest keep events of every user and of every plan of a user. Pending events are never
+* deleted.
+**/
+func (r *repository) PurgeProcessed(ctx context.Context, before time.Time, keep int) (int64, error) {
+	query := `
+	DELETE FROM outbox_events
+	WHERE id IN (
+		SELECT id
+		FROM (
+			SELECT
+				id,
+				occurred_at,
+				delivered_at,
+				failed_at,
+				ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY seq DESC) AS user_rank,
+				ROW_NUMBER() OVER (PARTITION BY user_id, plan_id ORDER BY seq DESC) AS plan_rank
+			FROM outbox_events
+		) ranked
+		WHERE (delivered_at IS NOT NULL OR failed_at IS NOT NULL)
+		AND occurred_at < $1
+		AND user_rank > $2
+		AND plan_rank > $2
+	)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, before, keep)
+	if err != nil {
+		return 0, errorutils.AnalyzeDBErr(err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, errorutils.AnalyzeDBErr(err)
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,197 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	keyTTL       = 24 * time.Hour
+	maxKeyLength = 255
+	// bodies are read into memory to be hashed
+	maxBodySize = 1 << 20
+)
+
+type Middleware struct {
+	repo Repository
+}
+
+type Repository interface {
+	Reserve(ctx context.Context, record Record) (bool, error)
+	Get(ctx context.Context, userID uuid.UUID, key string) (*Record, error)
+	SaveResponse(ctx context.Context, userID uuid.UUID, key string, statusCode int, contentType string, body []byte) error
+	Release(ctx context.Context, userID uuid.UUID, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+func NewMiddleware(repo Repository) *Middleware {
+	return &Middleware{
+		repo: repo,
+	}
+}
+
+/**
+* Makes a create endpoint safe to retry. Requests carrying an Idempotency-Key header are
+* recorded with a hash of their body, and a retry with the same key replays the stored
+* response for 24 hours instead of running the handler again. Reusing a key for a
+* different request is rejected, as is a retry while the original is still running.
+**/
+func (m *Middleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderKey)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": fmt.Sprintf("%s cannot be longer than %d characters", HeaderKey, maxKeyLength)})
+			return
+		}
+
+		// TODO: static now, will come from jwt in future
+		userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"statusCode": http.StatusRequestEntityTooLarge, "message": fmt.Sprintf("Request body cannot be larger than %d bytes", maxBodySize)})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Failed to read request body"})
+			return
+		}
+		// let the handler read the body again
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		record := Record{
+			UserID:         userId,
+			IdempotencyKey: key,
+			Method:         c.Request.Method,
+			Path:           c.Request.URL.Path,
+			RequestHash:    hashRequest(c.Request.Method, c.Request.URL.Path, body),
+			ExpiresAt:      time.Now().Add(keyTTL),
+		}
+
+		reserved, err := m.repo.Reserve(ctx, record)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to check idempotency key", "error": err.Error()})
+			return
+		}
+
+		if !reserved {
+			m.replay(c, record)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// a panicking handler must not hold on to the key until it expires
+		defer func() {
+			if p := recover(); p != nil {
+				m.repo.Release(context.Background(), userId, key)
+				panic(p)
+			}
+		}()
+
+		c.Next()
+
+		// server errors are not stored so that the request can be retried with the same key
+		if recorder.Status() >= http.StatusInternalServerError {
+			if err := m.repo.Release(context.Background(), userId, key); err != nil {
+				fmt.Printf("Error releasing idempotency key %s: %s\n", key, err.Error())
+			}
+			return
+		}
+
+		err = m.repo.SaveResponse(context.Background(), userId, key, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+		if err != nil {
+			fmt.Printf("Error saving response for idempotency key %s: %s\n", key, err.Error())
+
+			// without a stored response retries would be told the request is still running
+			if err := m.repo.Release(context.Background(), userId, key); err != nil {
+				fmt.Printf("Error releasing idempotency key %s: %s\n", key, err.Error())
+			}
+		}
+	}
+}
+
+// answers a request whose key is already in use
+func (m *Middleware) replay(c *gin.Context, request Record) {
+	existing, err := m.repo.Get(c.Request.Context(), request.UserID, request.IdempotencyKey)
+	if errors.Is(err, constants.ErrNotFound) {
+		// released by a failed original in the meantime
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"statusCode": http.StatusConflict, "message": "The original request with this Idempotency-Key failed, please retry"})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to check idempotency key", "error": err.Error()})
+		return
+	}
+
+	if existing.Method != request.Method || existing.Path != request.Path || existing.RequestHash != request.RequestHash {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"statusCode": http.StatusUnprocessableEntity, "message": "Idempotency-Key was already used for a different request"})
+		return
+	}
+
+	if existing.StatusCode == nil {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"statusCode": http.StatusConflict, "message": "A request with this Idempotency-Key is still being processed"})
+		return
+	}
+
+	contentType := "application/json; charset=utf-8"
+	if existing.ContentType != nil && *existing.ContentType != "" {
+		contentType = *existing.ContentType
+	}
+
+	c.Header(HeaderReplayed, "true")
+	c.Data(*existing.StatusCode, contentType, existing.ResponseBody)
+	c.Abort()
+}
+
+/**
+* Removes expired keys, run periodically by the idempotency cleanup job.
+**/
+func (m *Middleware) PurgeExpired(ctx context.Context) (int64, error) {
+	return m.repo.DeleteExpired(ctx, time.Now())
+}
+
+func hashRequest(method string, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// keeps a copy of the response body while writing it out
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
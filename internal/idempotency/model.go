@@ -0,0 +1,21 @@
+package idempotency
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Record struct {
+	ID             uuid.UUID `db:"id"`
+	UserID         uuid.UUID `db:"user_id"`
+	IdempotencyKey string    `db:"idempotency_key"`
+	Method         string    `db:"method"`
+	Path           string    `db:"path"`
+	RequestHash    string    `db:"request_hash"`
+	StatusCode     *int      `db:"status_code"`
+	ContentType    *string   `db:"content_type"`
+	ResponseBody   []byte    `db:"response_body"`
+	CreatedAt      time.Time `db:"created_at"`
+	ExpiresAt      time.Time `db:"expires_at"`
+}
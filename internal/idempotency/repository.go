@@ -0,0 +1,115 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{
+		db: db,
+	}
+}
+
+/**
+* Reserves a key for a request. Returns false when the key is already held by an
+* unexpired record, in which case nothing is written. Expired records are taken over.
+**/
+func (r *repository) Reserve(ctx context.Context, record Record) (bool, error) {
+	query := `
+	INSERT INTO idempotency_keys (user_id, idempotency_key, method, path, request_hash, expires_at)
+	VALUES (:user_id, :idempotency_key, :method, :path, :request_hash, :expires_at)
+	ON CONFLICT (user_id, idempotency_key) DO UPDATE SET
+		method = EXCLUDED.method,
+		path = EXCLUDED.path,
+		request_hash = EXCLUDED.request_hash,
+		status_code = NULL,
+		content_type = NULL,
+		response_body = NULL,
+		created_at = NOW(),
+		expires_at = EXCLUDED.expires_at
+	WHERE idempotency_keys.expires_at <= NOW()
+	`
+
+	result, err := r.db.NamedExecContext(ctx, query, record)
+	if err != nil {
+		return false, errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, errorutils.AnalyzeDBErr(err)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *repository) Get(ctx context.Context, userID uuid.UUID, key string) (*Record, error) {
+	query := `
+	SELECT id, user_id, idempotency_key, method, path, request_hash, status_code, content_type, response_body, created_at, expires_at
+	FROM idempotency_keys
+	WHERE user_id = $1
+	AND idempotency_key = $2
+	`
+
+	var record Record
+	err := r.db.GetContext(ctx, &record, query, userID, key)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &record, nil
+}
+
+// SaveResponse stores the response of a reserved key so that retries can replay it
+func (r *repository) SaveResponse(ctx context.Context, userID uuid.UUID, key string, statusCode int, contentType string, body []byte) error {
+	query := `
+	UPDATE idempotency_keys
+	SET status_code = $3, content_type = $4, response_body = $5
+	WHERE user_id = $1
+	AND idempotency_key = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, userID, key, statusCode, contentType, body)
+
+	return errorutils.AnalyzeDBResults(err, result)
+}
+
+// Release frees a reserved key, letting the request be retried
+func (r *repository) Release(ctx context.Context, userID uuid.UUID, key string) error {
+	query := `
+	DELETE FROM idempotency_keys
+	WHERE user_id = $1
+	AND idempotency_key = $2
+	AND status_code IS NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query, userID, key)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	query := `
+	DELETE FROM idempotency_keys
+	WHERE expires_at <= $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, now)
+	if err != nil {
+		return 0, errorutils.AnalyzeDBErr(err)
+	}
+
+	return result.RowsAffected()
+}
@@ -7,11 +7,13 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/darkphotonKN/fireplace/internal/checklistitems"
 	"github.com/darkphotonKN/fireplace/internal/concepts"
 	"github.com/darkphotonKN/fireplace/internal/discovery"
 	"github.com/darkphotonKN/fireplace/internal/interfaces"
 	"github.com/darkphotonKN/fireplace/internal/models"
 	"github.com/darkphotonKN/fireplace/internal/plans"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
 )
 
 type service struct {
@@ -24,7 +26,7 @@ type service struct {
 }
 
 type InsightsChecklistService interface {
-	GetAllByPlanId(ctx context.Context, planId uuid.UUID, scope *string, upcoming *string) ([]*models.ChecklistItem, error)
+	GetAllByPlanId(ctx context.Context, planId uuid.UUID, opts checklistitems.ListOptions) ([]*models.ChecklistItem, *pageutils.Page, error)
 }
 
 type InsightsYoutubeVideoFinder interface {
@@ -54,7 +56,7 @@ func (s *service) GenerateSuggestions(ctx context.Context, planId uuid.UUID) (st
 		return "", err
 	}
 
-	res, err := s.contentGen.Generate(prompt)
+	res, err := s.contentGen.Generate(ctx, prompt)
 
 	if err != nil {
 		return "", err
@@ -68,7 +70,7 @@ func (s *service) GenerateSuggestions(ctx context.Context, planId uuid.UUID) (st
 **/
 func (s *service) GenerateDailySuggestions(ctx context.Context, planId uuid.UUID) ([]string, error) {
 	// TODO: add default rules for daily suggestion to additional prompt argument.
-	prompt, err := s.generatePromptWithChecklist(ctx, planId, "focus on tasks that are marked as \"longterm\" and breaking them down when you make your suggestions.")
+	prompt, err := s.generatePromptWithChecklist(ctx, planId, "focus on tasks that are marked as \"longterm\" and breaking them down when you make your suggestions. Don't suggest tasks already covered by the daily, weekly or monthly routines.")
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +86,7 @@ func (s *service) GenerateDailySuggestions(ctx context.Context, planId uuid.UUID
 			prompt = fmt.Sprintf("%sAlso, don't choose one closely related to this specific action item as this has already been added to the list too:%s", prompt, suggestions[i-1])
 		}
 		fmt.Println("updated prompt:", prompt)
-		res, err := s.contentGen.Generate(prompt)
+		res, err := s.contentGen.Generate(ctx, prompt)
 		if err != nil {
 			return nil, err
 		}
@@ -125,6 +127,8 @@ func (s *service) generatePromptWithChecklist(ctx context.Context, planId uuid.U
     - Use technical terminology accurately if applicable
     - Be 4-20 words in length
 
+    Each existing task is marked with its scope: "daily", "weekly" and "monthly" tasks are routines that repeat every day, week or month, while "longterm" tasks are done once.
+
     Format your response as a single task item with no additional commentary, explanation or punctuation at the end.
 		`
 
@@ -162,7 +166,7 @@ func (s *service) AcquireGenRelevantData(ctx context.Context, planId uuid.UUID)
 	}
 
 	// get entire checklist as context
-	checklistItems, err := s.checklistService.GetAllByPlanId(ctx, planId, nil, nil)
+	checklistItems, _, err := s.checklistService.GetAllByPlanId(ctx, planId, checklistitems.ListOptions{})
 
 	if err != nil {
 		fmt.Println("Error when retrieving all checklist item for generating checklist suggestion.")
@@ -203,7 +207,7 @@ func (s *service) GenerateSuggestedVideoLinks(ctx context.Context, planId uuid.U
 	Please use this information to now provide exactly 3 relevant search terms.
 	`, focus, checklistPrompt)
 
-	searchTermsStr, err := s.contentGen.Generate(message)
+	searchTermsStr, err := s.contentGen.Generate(ctx, message)
 
 	if err != nil {
 		return nil, err
@@ -1,5 +1,7 @@
 package interfaces
 
+import "context"
+
 type ContentGenerator interface {
-	Generate(message string) (string, error)
+	Generate(ctx context.Context, message string) (string, error)
 }
@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"context"
+)
+
+type AutoArchiveJob struct {
+	checklistService ChecklistAutoArchiveService
+}
+
+type ChecklistAutoArchiveService interface {
+	AutoArchiveAllPlans(ctx context.Context) (int64, error)
+}
+
+func NewAutoArchiveJob(checklistService ChecklistAutoArchiveService) *AutoArchiveJob {
+	return &AutoArchiveJob{
+		checklistService: checklistService,
+	}
+}
+
+func (j *AutoArchiveJob) Name() string {
+	return "autoArchive"
+}
+
+// runs at the start of every hour
+func (j *AutoArchiveJob) Schedule() string {
+	return "0 0 * * * *"
+}
+
+// Run applies the auto archive policies of all plans, returning how many items were archived
+func (j *AutoArchiveJob) Run(ctx context.Context) (int64, error) {
+	return j.checklistService.AutoArchiveAllPlans(ctx)
+}
@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// how a job is scheduled, by default on its own schedule without jitter
+type Config struct {
+	Schedule string
+	Enabled  bool
+	Jitter   time.Duration
+}
+
+/**
+* Reads the config of a job from the environment, falling back to the job's own schedule.
+* For a job named dailyReset these are:
+*   JOB_DAILY_RESET_SCHEDULE  cron spec including seconds, e.g. "0 0 * * * *"
+*   JOB_DAILY_RESET_ENABLED   false to not run the job on a schedule
+*   JOB_DAILY_RESET_JITTER    maximum random delay of each run, e.g. "30s"
+**/
+func LoadConfig(job Job, getenv func(string) string, now time.Time) (Config, error) {
+	prefix := "JOB_" + envName(job.Name()) + "_"
+
+	config := Config{
+		Schedule: job.Schedule(),
+		Enabled:  true,
+	}
+
+	if spec := getenv(prefix + "SCHEDULE"); spec != "" {
+		config.Schedule = spec
+	}
+
+	if enabled := getenv(prefix + "ENABLED"); enabled != "" {
+		parsed, err := strconv.ParseBool(enabled)
+		if err != nil {
+			return config, fmt.Errorf("job %s: %sENABLED must be true or false", job.Name(), prefix)
+		}
+		config.Enabled = parsed
+	}
+
+	if jitter := getenv(prefix + "JITTER"); jitter != "" {
+		parsed, err := time.ParseDuration(jitter)
+		if err != nil {
+			return config, fmt.Errorf("job %s: %sJITTER must be a duration such as '30s'", job.Name(), prefix)
+		}
+		config.Jitter = parsed
+	}
+
+	if err := config.validate(now); err != nil {
+		return config, fmt.Errorf("job %s: %w", job.Name(), err)
+	}
+
+	return config, nil
+}
+
+/**
+* Checks that the schedule parses and that the jitter is shorter than the time between
+* runs, otherwise a delayed run could overtake the next one.
+**/
+func (c Config) validate(now time.Time) error {
+	schedule, err := scheduleParser.Parse(c.Schedule)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q: %w", c.Schedule, err)
+	}
+
+	if c.Jitter < 0 {
+		return fmt.Errorf("jitter must not be negative")
+	}
+
+	// the shortest gap between the upcoming runs
+	first := schedule.Next(now)
+	if first.IsZero() {
+		return fmt.Errorf("schedule %q never runs", c.Schedule)
+	}
+
+	shortest := time.Duration(0)
+	for i := 0; i < 10; i++ {
+		next := schedule.Next(first)
+		if gap := next.Sub(first); shortest == 0 || gap < shortest {
+			shortest = gap
+		}
+		first = next
+	}
+
+	if c.Jitter >= shortest {
+		return fmt.Errorf("jitter %s must be shorter than the %s between runs", c.Jitter, shortest)
+	}
+
+	return nil
+}
+
+// dailyReset becomes DAILY_RESET
+func envName(name string) string {
+	var b strings.Builder
+
+	for i, r := range name {
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteRune('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+
+	return b.String()
+}
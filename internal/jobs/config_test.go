@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	job := newBlockingJob("dailyReset")
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Config
+		wantErr bool
+	}{
+		{"defaults", nil, Config{Schedule: "0 0 * * * *", Enabled: true}, false},
+		{
+			"overrides",
+			map[string]string{"JOB_DAILY_RESET_SCHEDULE": "0 */10 * * * *", "JOB_DAILY_RESET_ENABLED": "false", "JOB_DAILY_RESET_JITTER": "30s"},
+			Config{Schedule: "0 */10 * * * *", Enabled: false, Jitter: 30 * time.Second},
+			false,
+		},
+		{"descriptor", map[string]string{"JOB_DAILY_RESET_SCHEDULE": "@daily"}, Config{Schedule: "@daily", Enabled: true}, false},
+		{"invalid schedule", map[string]string{"JOB_DAILY_RESET_SCHEDULE": "* * * * *"}, Config{}, true},
+		{"invalid enabled", map[string]string{"JOB_DAILY_RESET_ENABLED": "sometimes"}, Config{}, true},
+		{"invalid jitter", map[string]string{"JOB_DAILY_RESET_JITTER": "30"}, Config{}, true},
+		{"negative jitter", map[string]string{"JOB_DAILY_RESET_JITTER": "-1s"}, Config{}, true},
+		{"jitter as long as the gap between runs", map[string]string{"JOB_DAILY_RESET_JITTER": "1h"}, Config{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadConfig(job, func(key string) string { return tt.env[key] }, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("LoadConfig returned %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("LoadConfig = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"dailyReset":         "DAILY_RESET",
+		"autoArchive":        "AUTO_ARCHIVE",
+		"outbox":             "OUTBOX",
+		"syncTombstonePurge": "SYNC_TOMBSTONE_PURGE",
+		"outboxPurge":        "OUTBOX_PURGE",
+	}
+
+	for name, want := range tests {
+		if got := envName(name); got != want {
+			t.Errorf("envName(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
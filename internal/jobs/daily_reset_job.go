@@ -2,54 +2,34 @@ package jobs
 
 import (
 	"context"
-	"fmt"
-
-	"github.com/robfig/cron/v3"
 )
 
 type DailyResetJob struct {
 	checklistService ChecklistDailyResetService
-	cron             *cron.Cron
-	jobID            cron.EntryID
 }
 
 type ChecklistDailyResetService interface {
-	ResetDailyItems(ctx context.Context) error
+	ResetRepeatingItems(ctx context.Context) (int64, error)
 }
 
 func NewDailyResetJob(checklistService ChecklistDailyResetService) *DailyResetJob {
-	c := cron.New(cron.WithSeconds())
-
 	return &DailyResetJob{
 		checklistService: checklistService,
-		cron:             c,
 	}
 }
 
-func (j *DailyResetJob) Start() {
-	fmt.Println("Starting daily reset jobs.")
-
-	jobID, err := j.cron.AddFunc("0 0 14 * * *", func() {
-		fmt.Println("Running daily job...")
-		ctx := context.Background()
-		err := j.checklistService.ResetDailyItems(ctx)
-		if err != nil {
-			fmt.Printf("Error resetting daily items: %s\n", err.Error())
-		}
-	})
-
-	if err != nil {
-		fmt.Printf("Error scheduling daily reset job: %s\n", err.Error())
-		return
-	}
+func (j *DailyResetJob) Name() string {
+	return "dailyReset"
+}
 
-	j.jobID = jobID
-	j.cron.Start()
+// plans reset at the local reset hour of their user, checked every 5 minutes as the
+// reset hours are spread across timezones
+func (j *DailyResetJob) Schedule() string {
+	return "0 */5 * * * *"
 }
 
-func (j *DailyResetJob) Stop() {
-	fmt.Println("Stopping daily reset job.")
-	ctx := j.cron.Stop()
-	// Wait for jobs to finish
-	<-ctx.Done()
+// Run resets the repeating items of the plans that are due, returning how many plans were reset.
+// Plans not reset on their local date yet are due, so a single run also catches up on missed ones.
+func (j *DailyResetJob) Run(ctx context.Context) (int64, error) {
+	return j.checklistService.ResetRepeatingItems(ctx)
 }
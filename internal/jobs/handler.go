@@ -0,0 +1,107 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+type Service interface {
+	ListJobs(ctx context.Context) ([]*Info, error)
+	GetRuns(ctx context.Context, name string, page *pageutils.Params) ([]*Run, *pageutils.Page, error)
+	Trigger(ctx context.Context, name string) (*Run, error)
+	Pause(ctx context.Context, name string) error
+	Resume(ctx context.Context, name string) error
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// ListJobs lists every background job with its schedule, state and latest run
+func (h *Handler) ListJobs(c *gin.Context) {
+	jobs, err := h.service.ListJobs(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to get jobs", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved jobs", "result": jobs})
+}
+
+// GetRuns lists the run history of a job, newest first
+func (h *Handler) GetRuns(c *gin.Context) {
+	page, err := pageutils.ParseParams(c.Query("limit"), c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid pagination parameters", "error": err.Error()})
+		return
+	}
+
+	runs, pageInfo, err := h.service.GetRuns(c.Request.Context(), c.Param("name"), page)
+	if err != nil {
+		status := statusFor(err)
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to get job runs", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved job runs", "result": runs, "page": pageInfo})
+}
+
+// Trigger starts a run of a job right away
+func (h *Handler) Trigger(c *gin.Context) {
+	run, err := h.service.Trigger(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		status := statusFor(err)
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to trigger job", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"statusCode": http.StatusAccepted, "message": "Successfully triggered job", "result": run})
+}
+
+// Pause stops the scheduled runs of a job until it is resumed
+func (h *Handler) Pause(c *gin.Context) {
+	if err := h.service.Pause(c.Request.Context(), c.Param("name")); err != nil {
+		status := statusFor(err)
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to pause job", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully paused job"})
+}
+
+// Resume continues the scheduled runs of a paused job
+func (h *Handler) Resume(c *gin.Context) {
+	if err := h.service.Resume(c.Request.Context(), c.Param("name")); err != nil {
+		status := statusFor(err)
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to resume job", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully resumed job"})
+}
+
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, constants.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, pageutils.ErrInvalidCursor):
+		return http.StatusBadRequest
+	case errors.Is(err, constants.ErrJobRunning):
+		return http.StatusConflict
+	case errors.Is(err, constants.ErrJobsStopped):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"context"
+)
+
+type IdempotencyCleanupJob struct {
+	idempotencyService IdempotencyCleanupService
+}
+
+type IdempotencyCleanupService interface {
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+func NewIdempotencyCleanupJob(idempotencyService IdempotencyCleanupService) *IdempotencyCleanupJob {
+	return &IdempotencyCleanupJob{
+		idempotencyService: idempotencyService,
+	}
+}
+
+func (j *IdempotencyCleanupJob) Name() string {
+	return "idempotencyCleanup"
+}
+
+// runs at half past every hour
+func (j *IdempotencyCleanupJob) Schedule() string {
+	return "0 30 * * * *"
+}
+
+// Run removes the expired idempotency keys, returning how many were removed
+func (j *IdempotencyCleanupJob) Run(ctx context.Context) (int64, error) {
+	return j.idempotencyService.PurgeExpired(ctx)
+}
@@ -1,60 +1,552 @@
 package jobs
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"sync"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
+	"github.com/google/uuid"
 )
 
+/**
+* A named background job. The manager runs it on its schedule, records every run in the
+* job history and lets it be triggered, paused and resumed by name. Runs hold an advisory
+* lock of the job so that with several instances of the API each run executes once.
+**/
+type Job interface {
+	// unique name the job is recorded and controlled by
+	Name() string
+	// default cron spec including seconds, e.g. "0 30 * * * *", see LoadConfig
+	Schedule() string
+	// runs the job once, returning how many items it affected
+	Run(ctx context.Context) (int64, error)
+}
+
+/**
+* A job that needs to know since when it missed runs to catch up on them, e.g. to still
+* send the reminders that were due while no instance was up. Other jobs catch up with a
+* single regular run.
+**/
+type CatchUpJob interface {
+	Job
+	CatchUp(ctx context.Context, since time.Time) (int64, error)
+}
+
+type Repository interface {
+	CreateRun(ctx context.Context, jobName string, trigger constants.JobTrigger, instanceID string, scheduledFor *time.Time) (*Run, error)
+	FinishRun(ctx context.Context, id uuid.UUID, status constants.JobRunStatus, runErr *string, itemsAffected int64) error
+	GetRuns(ctx context.Context, jobName string, page *pageutils.Params) ([]*Run, *pageutils.Page, error)
+	GetLastRuns(ctx context.Context) (map[string]*Run, error)
+	GetPausedJobs(ctx context.Context) ([]string, error)
+	IsPaused(ctx context.Context, jobName string) (bool, error)
+	SetPaused(ctx context.Context, jobName string, paused bool) error
+	TryLock(ctx context.Context, jobName string) (func(), bool, error)
+	RecordSuccess(ctx context.Context, jobName string, startedAt time.Time) error
+	GetLastSuccess(ctx context.Context, jobName string) (*time.Time, error)
+}
+
 type Manager struct {
-	jobs     []Job
-	stopChan chan bool
+	repo      Repository
+	jobs      []Job
+	scheduler *Scheduler
+	clock     Clock
+	getenv    func(string) string
+
+	// config of each started job, by name
+	configs map[string]Config
+
+	// identifies this instance in the job history
+	instanceID string
+
+	// jobs currently running on this instance, by name
+	running map[string]bool
+
+	// context of every run, cancelled when the manager stops
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// runs in progress, scheduled or triggered
+	inFlight sync.WaitGroup
+	started  bool
+	stopped  bool
 	mu       sync.Mutex
 }
 
-type Job interface {
-	Start()
-	Stop()
-}
+/**
+* Creates a manager timing its jobs with clock and reading their configs through getenv,
+* usually SystemClock and os.Getenv.
+**/
+func NewManager(repo Repository, clock Clock, getenv func(string) string) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
 
-func NewManager() *Manager {
 	return &Manager{
-		jobs:     make([]Job, 0),
-		stopChan: make(chan bool),
+		repo:      repo,
+		jobs:      make([]Job, 0),
+		scheduler: NewScheduler(clock),
+		clock:     clock,
+		getenv:    getenv,
+		configs:   make(map[string]Config),
+		running:   make(map[string]bool),
+		ctx:       ctx,
+		cancel:    cancel,
+
+		instanceID: instanceID(getenv),
 	}
 }
 
-// starts all jobs
-func (m *Manager) StartAll() {
+// INSTANCE_ID when set, otherwise the hostname and process id
+func instanceID(getenv func(string) string) string {
+	if id := getenv("INSTANCE_ID"); id != "" {
+		return id
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+/**
+* Loads the config of every job and starts running the enabled ones on their schedule.
+* Nothing is started when a config is invalid. Runs missed while no instance was up are
+* caught up first, in the background, before the regular schedule resumes.
+**/
+func (m *Manager) StartAll() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	configs := make(map[string]Config, len(m.jobs))
+	var errs []error
 	for _, job := range m.jobs {
-		j := job // prevent variable capture issue
-		go func() {
-			j.Start()
-			<-m.stopChan
-			fmt.Println("stopping ongoing channels")
-			j.Stop()
-			return
-		}()
+		config, err := LoadConfig(job, m.getenv, m.clock.Now())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		configs[job.Name()] = config
 	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	enabled := make([]Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		m.configs[job.Name()] = configs[job.Name()]
+
+		if !configs[job.Name()].Enabled {
+			fmt.Printf("Job %s is disabled.\n", job.Name())
+			continue
+		}
+
+		if err := m.schedule(job); err != nil {
+			return err
+		}
+		enabled = append(enabled, job)
+	}
+
+	m.started = true
+
+	go func() {
+		for _, job := range enabled {
+			m.catchUp(job)
+		}
+
+		m.scheduler.Start()
+	}()
+
+	return nil
 }
 
-// starts all jobs
-func (m *Manager) StopAll() {
-	close(m.stopChan)
+/**
+* Stops all jobs and waits for their running functions to finish. When ctx is done before
+* every run finished, the context of the runs is cancelled and ctx's error is returned.
+* Stopping more than once is safe.
+**/
+func (m *Manager) StopAll(ctx context.Context) error {
+	m.mu.Lock()
+	m.stopped = true
+	m.scheduler.Stop()
+	m.mu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		m.inFlight.Wait()
+		close(stopped)
+	}()
+
+	defer m.cancel()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (m *Manager) AddJob(job Job) {
+/**
+* Adds a job, which is started right away when the manager already started.
+**/
+func (m *Manager) AddJob(job Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.started {
+		config, err := LoadConfig(job, m.getenv, m.clock.Now())
+		if err != nil {
+			return err
+		}
+		m.configs[job.Name()] = config
+
+		if config.Enabled {
+			if err := m.schedule(job); err != nil {
+				return err
+			}
+		}
+	}
+
 	m.jobs = append(m.jobs, job)
+
+	return nil
 }
 
 func (m *Manager) RemoveJob(job Job) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	filteredJobs := make([]Job, 0)
 
 	for _, j := range m.jobs {
 		if j == job {
 			continue
 		}
-		filteredJobs = append(filteredJobs, job)
+		filteredJobs = append(filteredJobs, j)
 	}
 
 	m.jobs = filteredJobs
+
+	m.scheduler.Remove(job.Name())
+	delete(m.configs, job.Name())
+}
+
+// ListJobs returns every registered job along with its state and latest run
+func (m *Manager) ListJobs(ctx context.Context) ([]*Info, error) {
+	lastRuns, err := m.repo.GetLastRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pausedJobs, err := m.repo.GetPausedJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	paused := make(map[string]bool, len(pausedJobs))
+	for _, name := range pausedJobs {
+		paused[name] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	infos := make([]*Info, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		config := m.config(job)
+
+		info := &Info{
+			Name:     job.Name(),
+			Schedule: config.Schedule,
+			Enabled:  config.Enabled,
+			Paused:   paused[job.Name()],
+			Running:  m.running[job.Name()],
+			LastRun:  lastRuns[job.Name()],
+		}
+
+		if config.Jitter > 0 {
+			info.Jitter = config.Jitter.String()
+		}
+
+		if next, ok := m.scheduler.Next(job.Name()); ok && !info.Paused {
+			info.NextRun = &next
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// GetRuns returns the run history of a job
+func (m *Manager) GetRuns(ctx context.Context, name string, page *pageutils.Params) ([]*Run, *pageutils.Page, error) {
+	if _, err := m.find(name); err != nil {
+		return nil, nil, err
+	}
+
+	return m.repo.GetRuns(ctx, name, page)
+}
+
+/**
+* Runs a job right away in the background, regardless of whether it is paused. Returns the
+* recorded run, which is still in progress, or ErrJobRunning when the job is running on
+* this or another instance.
+**/
+func (m *Manager) Trigger(ctx context.Context, name string) (*Run, error) {
+	job, err := m.find(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.begin(name); err != nil {
+		return nil, err
+	}
+
+	release, acquired, err := m.repo.TryLock(ctx, name)
+	if err != nil {
+		m.end(name)
+		return nil, err
+	}
+
+	if !acquired {
+		m.end(name)
+		return nil, constants.ErrJobRunning
+	}
+
+	run, err := m.repo.CreateRun(ctx, name, constants.JobTriggerManual, m.instanceID, nil)
+	if err != nil {
+		release()
+		m.end(name)
+		return nil, err
+	}
+
+	go func() {
+		defer m.end(name)
+		defer release()
+		m.execute(job, run, nil)
+	}()
+
+	return run, nil
+}
+
+// Pause skips the scheduled runs of a job until it is resumed
+func (m *Manager) Pause(ctx context.Context, name string) error {
+	return m.setPaused(ctx, name, true)
+}
+
+// Resume continues the scheduled runs of a paused job
+func (m *Manager) Resume(ctx context.Context, name string) error {
+	return m.setPaused(ctx, name, false)
+}
+
+func (m *Manager) setPaused(ctx context.Context, name string, paused bool) error {
+	if _, err := m.find(name); err != nil {
+		return err
+	}
+
+	return m.repo.SetPaused(ctx, name, paused)
+}
+
+// adds a job to the scheduler with its config, the lock must be held
+func (m *Manager) schedule(job Job) error {
+	config := m.configs[job.Name()]
+
+	err := m.scheduler.Add(job.Name(), config.Schedule, config.Jitter, func(scheduledFor time.Time) {
+		m.runScheduled(job, scheduledFor)
+	})
+	if err != nil {
+		return fmt.Errorf("job %s: %w", job.Name(), err)
+	}
+
+	return nil
+}
+
+// config of a job, its defaults before the manager started, the lock must be held
+func (m *Manager) config(job Job) Config {
+	if config, ok := m.configs[job.Name()]; ok {
+		return config
+	}
+
+	return Config{Schedule: job.Schedule(), Enabled: true}
+}
+
+// runs a job on its schedule
+func (m *Manager) runScheduled(job Job, scheduledFor time.Time) {
+	m.runOnce(job, constants.JobTriggerSchedule, scheduledFor, nil)
+}
+
+/**
+* Catches up on the runs of a job scheduled since its last successful run that were
+* missed while no instance was up. Missed runs are coalesced into a single run, recorded
+* for the latest missed schedule time so that only one instance catches up.
+**/
+func (m *Manager) catchUp(job Job) {
+	name := job.Name()
+
+	lastSuccess, err := m.repo.GetLastSuccess(m.ctx, name)
+	if err != nil {
+		fmt.Printf("Error getting last successful run of job %s: %s\n", name, err.Error())
+		return
+	}
+
+	// nothing to catch up on for a job that never ran
+	if lastSuccess == nil {
+		return
+	}
+
+	m.mu.Lock()
+	config := m.config(job)
+	m.mu.Unlock()
+
+	schedule, err := scheduleParser.Parse(config.Schedule)
+	if err != nil {
+		return
+	}
+
+	now := m.clock.Now()
+	missed := 0
+	var latestMissed time.Time
+	for next := schedule.Next(*lastSuccess); !next.After(now); next = schedule.Next(next) {
+		missed++
+		latestMissed = next
+	}
+
+	if missed == 0 {
+		return
+	}
+
+	fmt.Printf("Catching up on %d missed runs of job %s since %s.\n", missed, name, lastSuccess.Format(time.RFC3339))
+
+	m.runOnce(job, constants.JobTriggerCatchUp, latestMissed, lastSuccess)
+}
+
+/**
+* Runs a job for a schedule time unless it is paused, still running or the run was
+* already executed by another instance. With since the job catches up on the runs missed
+* since then.
+**/
+func (m *Manager) runOnce(job Job, trigger constants.JobTrigger, scheduledFor time.Time, since *time.Time) {
+	ctx := m.ctx
+	name := job.Name()
+
+	paused, err := m.repo.IsPaused(ctx, name)
+	if err != nil {
+		fmt.Printf("Error checking whether job %s is paused: %s\n", name, err.Error())
+		return
+	}
+
+	if paused {
+		return
+	}
+
+	if err := m.begin(name); err != nil {
+		fmt.Printf("Skipping %s run of job %s: %s\n", trigger, name, err.Error())
+		return
+	}
+	defer m.end(name)
+
+	release, acquired, err := m.repo.TryLock(ctx, name)
+	if err != nil {
+		fmt.Printf("Error locking job %s: %s\n", name, err.Error())
+		return
+	}
+
+	// another instance is running the job
+	if !acquired {
+		return
+	}
+	defer release()
+
+	run, err := m.repo.CreateRun(ctx, name, trigger, m.instanceID, &scheduledFor)
+	if errors.Is(err, constants.ErrDuplicateResource) {
+		// another instance already ran it
+		return
+	}
+	if err != nil {
+		fmt.Printf("Error recording run of job %s: %s\n", name, err.Error())
+		return
+	}
+
+	m.execute(job, run, since)
+}
+
+// runs a job and records how the run went, catching up since the given time if provided
+func (m *Manager) execute(job Job, run *Run, since *time.Time) {
+	start := m.clock.Now()
+
+	var itemsAffected int64
+	var err error
+	if catchUpJob, ok := job.(CatchUpJob); ok && since != nil {
+		itemsAffected, err = catchUpJob.CatchUp(m.ctx, *since)
+	} else {
+		itemsAffected, err = job.Run(m.ctx)
+	}
+
+	status := constants.JobRunSucceeded
+	var runErr *string
+	if err != nil {
+		status = constants.JobRunFailed
+		message := err.Error()
+		runErr = &message
+		fmt.Printf("Error running job %s: %s\n", job.Name(), message)
+	}
+
+	// recorded even when the run was cancelled by stopping the manager
+	if err := m.repo.FinishRun(context.Background(), run.ID, status, runErr, itemsAffected); err != nil {
+		fmt.Printf("Error recording result of job %s: %s\n", job.Name(), err.Error())
+	}
+
+	if status == constants.JobRunSucceeded {
+		if err := m.repo.RecordSuccess(context.Background(), job.Name(), run.StartedAt); err != nil {
+			fmt.Printf("Error recording success of job %s: %s\n", job.Name(), err.Error())
+		}
+	}
+
+	fmt.Printf("Job %s %s in %s, %d items affected.\n", job.Name(), status, m.clock.Now().Sub(start), itemsAffected)
+}
+
+// marks a job as running, failing if it already is or the manager was stopped
+func (m *Manager) begin(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopped {
+		return constants.ErrJobsStopped
+	}
+
+	if m.running[name] {
+		return constants.ErrJobRunning
+	}
+
+	m.running[name] = true
+	m.inFlight.Add(1)
+
+	return nil
+}
+
+func (m *Manager) end(name string) {
+	m.mu.Lock()
+	delete(m.running, name)
+	m.mu.Unlock()
+
+	m.inFlight.Done()
+}
+
+func (m *Manager) find(name string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range m.jobs {
+		if job.Name() == name {
+			return job, nil
+		}
+	}
+
+	return nil, constants.ErrNotFound
 }
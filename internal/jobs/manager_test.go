@@ -0,0 +1,317 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
+	"github.com/google/uuid"
+)
+
+/**
+* A clock that only moves when told to. Every call to After is handed to the test, which
+* decides when the time is delivered.
+**/
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers chan fakeTimer
+}
+
+type fakeTimer struct {
+	d time.Duration
+	c chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{
+		now:    now,
+		timers: make(chan fakeTimer, 10),
+	}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	timer := fakeTimer{d: d, c: make(chan time.Time, 1)}
+	c.timers <- timer
+	return timer.c
+}
+
+// keeps job runs in memory, the advisory lock is held by one run at a time
+type fakeRepository struct {
+	mu       sync.Mutex
+	locked   map[string]bool
+	paused   map[string]bool
+	finished chan finishedRun
+}
+
+type finishedRun struct {
+	id     uuid.UUID
+	status constants.JobRunStatus
+	err    *string
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		locked:   make(map[string]bool),
+		paused:   make(map[string]bool),
+		finished: make(chan finishedRun, 10),
+	}
+}
+
+func (r *fakeRepository) CreateRun(ctx context.Context, jobName string, trigger constants.JobTrigger, instanceID string, scheduledFor *time.Time) (*Run, error) {
+	return &Run{ID: uuid.New(), JobName: jobName, Trigger: string(trigger), Status: string(constants.JobRunRunning), StartedAt: time.Now()}, nil
+}
+
+func (r *fakeRepository) FinishRun(ctx context.Context, id uuid.UUID, status constants.JobRunStatus, runErr *string, itemsAffected int64) error {
+	r.finished <- finishedRun{id: id, status: status, err: runErr}
+	return nil
+}
+
+func (r *fakeRepository) GetRuns(ctx context.Context, jobName string, page *pageutils.Params) ([]*Run, *pageutils.Page, error) {
+	return nil, nil, nil
+}
+
+func (r *fakeRepository) GetLastRuns(ctx context.Context) (map[string]*Run, error) {
+	return map[string]*Run{}, nil
+}
+
+func (r *fakeRepository) GetPausedJobs(ctx context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0)
+	for name, paused := range r.paused {
+		if paused {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (r *fakeRepository) IsPaused(ctx context.Context, jobName string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.paused[jobName], nil
+}
+
+func (r *fakeRepository) SetPaused(ctx context.Context, jobName string, paused bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paused[jobName] = paused
+	return nil
+}
+
+func (r *fakeRepository) TryLock(ctx context.Context, jobName string) (func(), bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.locked[jobName] {
+		return nil, false, nil
+	}
+	r.locked[jobName] = true
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.locked, jobName)
+	}, true, nil
+}
+
+func (r *fakeRepository) RecordSuccess(ctx context.Context, jobName string, startedAt time.Time) error {
+	return nil
+}
+
+func (r *fakeRepository) GetLastSuccess(ctx context.Context, jobName string) (*time.Time, error) {
+	return nil, nil
+}
+
+// a job whose runs last until they are released or their context is cancelled
+type blockingJob struct {
+	name    string
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingJob(name string) *blockingJob {
+	return &blockingJob{
+		name:    name,
+		started: make(chan struct{}, 10),
+		release: make(chan struct{}),
+	}
+}
+
+func (j *blockingJob) Name() string {
+	return j.name
+}
+
+func (j *blockingJob) Schedule() string {
+	return "0 0 * * * *"
+}
+
+func (j *blockingJob) Run(ctx context.Context) (int64, error) {
+	j.started <- struct{}{}
+
+	select {
+	case <-j.release:
+		return 1, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func newTestManager(t *testing.T, repo Repository, jobs ...Job) *Manager {
+	t.Helper()
+
+	manager := NewManager(repo, newFakeClock(time.Now()), func(string) string { return "" })
+	for _, job := range jobs {
+		if err := manager.AddJob(job); err != nil {
+			t.Fatalf("AddJob returned error: %v", err)
+		}
+	}
+	return manager
+}
+
+func waitFinished(t *testing.T, repo *fakeRepository) finishedRun {
+	t.Helper()
+
+	select {
+	case run := <-repo.finished:
+		return run
+	case <-time.After(time.Second):
+		t.Fatal("the run was not finished")
+		return finishedRun{}
+	}
+}
+
+func TestManagerTrigger(t *testing.T) {
+	repo := newFakeRepository()
+	job := newBlockingJob("blocking")
+	manager := newTestManager(t, repo, job)
+
+	run, err := manager.Trigger(context.Background(), "blocking")
+	if err != nil {
+		t.Fatalf("Trigger returned error: %v", err)
+	}
+	<-job.started
+
+	tests := []struct {
+		name    string
+		job     string
+		wantErr error
+	}{
+		{"unknown job", "missing", constants.ErrNotFound},
+		{"running job", "blocking", constants.ErrJobRunning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := manager.Trigger(context.Background(), tt.job); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Trigger(%q) error = %v, want %v", tt.job, err, tt.wantErr)
+			}
+		})
+	}
+
+	close(job.release)
+
+	finished := waitFinished(t, repo)
+	if finished.id != run.ID || finished.status != constants.JobRunSucceeded {
+		t.Errorf("finished run %s as %s, want %s as %s", finished.id, finished.status, run.ID, constants.JobRunSucceeded)
+	}
+
+	if err := manager.StopAll(context.Background()); err != nil {
+		t.Fatalf("StopAll returned error: %v", err)
+	}
+
+	if _, err := manager.Trigger(context.Background(), "blocking"); !errors.Is(err, constants.ErrJobsStopped) {
+		t.Errorf("Trigger after StopAll error = %v, want %v", err, constants.ErrJobsStopped)
+	}
+}
+
+func TestManagerStopAllWaitsForRuns(t *testing.T) {
+	repo := newFakeRepository()
+	job := newBlockingJob("blocking")
+	manager := newTestManager(t, repo, job)
+
+	if _, err := manager.Trigger(context.Background(), "blocking"); err != nil {
+		t.Fatalf("Trigger returned error: %v", err)
+	}
+	<-job.started
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- manager.StopAll(context.Background())
+	}()
+
+	select {
+	case err := <-stopped:
+		t.Fatalf("StopAll returned %v before the run finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(job.release)
+
+	if err := <-stopped; err != nil {
+		t.Errorf("StopAll returned error: %v", err)
+	}
+	if status := waitFinished(t, repo).status; status != constants.JobRunSucceeded {
+		t.Errorf("run finished as %s, want %s", status, constants.JobRunSucceeded)
+	}
+}
+
+func TestManagerStopAllCancelsRunsAfterTimeout(t *testing.T) {
+	repo := newFakeRepository()
+	job := newBlockingJob("blocking")
+	manager := newTestManager(t, repo, job)
+
+	if _, err := manager.Trigger(context.Background(), "blocking"); err != nil {
+		t.Fatalf("Trigger returned error: %v", err)
+	}
+	<-job.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := manager.StopAll(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("StopAll error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	finished := waitFinished(t, repo)
+	if finished.status != constants.JobRunFailed || finished.err == nil || *finished.err != context.Canceled.Error() {
+		t.Errorf("run finished as %s with %v, want it cancelled", finished.status, finished.err)
+	}
+}
+
+func TestManagerPausedJobsAreListed(t *testing.T) {
+	repo := newFakeRepository()
+	manager := newTestManager(t, repo, newBlockingJob("first"), newBlockingJob("second"))
+
+	if err := manager.Pause(context.Background(), "second"); err != nil {
+		t.Fatalf("Pause returned error: %v", err)
+	}
+	if err := manager.Pause(context.Background(), "missing"); !errors.Is(err, constants.ErrNotFound) {
+		t.Errorf("Pause of an unknown job error = %v, want %v", err, constants.ErrNotFound)
+	}
+
+	infos, err := manager.ListJobs(context.Background())
+	if err != nil {
+		t.Fatalf("ListJobs returned error: %v", err)
+	}
+
+	paused := make(map[string]bool)
+	for _, info := range infos {
+		paused[info.Name] = info.Paused
+	}
+	if len(infos) != 2 || paused["first"] || !paused["second"] {
+		t.Errorf("ListJobs paused = %v, want only second", paused)
+	}
+}
@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// a single run of a background job
+type Run struct {
+	ID            uuid.UUID  `db:"id" json:"id"`
+	JobName       string     `db:"job_name" json:"jobName"`
+	Trigger       string     `db:"trigger" json:"trigger"`
+	Status        string     `db:"status" json:"status"`
+	StartedAt     time.Time  `db:"started_at" json:"startedAt"`
+	FinishedAt    *time.Time `db:"finished_at" json:"finishedAt,omitempty"`
+	Error         *string    `db:"error" json:"error,omitempty"`
+	ItemsAffected int64      `db:"items_affected" json:"itemsAffected"`
+
+	// instance that held the lock of the job while running it
+	InstanceID   *string    `db:"instance_id" json:"instanceId,omitempty"`
+	ScheduledFor *time.Time `db:"scheduled_for" json:"scheduledFor,omitempty"`
+}
+
+// a registered job along with its current state
+type Info struct {
+	Name     string     `json:"name"`
+	Schedule string     `json:"schedule"`
+	Enabled  bool       `json:"enabled"`
+	Jitter   string     `json:"jitter,omitempty"`
+	Paused   bool       `json:"paused"`
+	Running  bool       `json:"running"`
+	NextRun  *time.Time `json:"nextRun,omitempty"`
+	LastRun  *Run       `json:"lastRun,omitempty"`
+}
@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"context"
+)
+
+type OutboxDispatchJob struct {
+	eventDispatcher OutboxDispatcher
+}
+
+type OutboxDispatcher interface {
+	DispatchPending(ctx context.Context) (int64, error)
+}
+
+func NewOutboxDispatchJob(eventDispatcher OutboxDispatcher) *OutboxDispatchJob {
+	return &OutboxDispatchJob{
+		eventDispatcher: eventDispatcher,
+	}
+}
+
+func (j *OutboxDispatchJob) Name() string {
+	return "outboxDispatch"
+}
+
+// runs every 10 seconds
+func (j *OutboxDispatchJob) Schedule() string {
+	return "*/10 * * * * *"
+}
+
+// Run delivers the domain events that are due, returning how many were delivered
+func (j *OutboxDispatchJob) Run(ctx context.Context) (int64, error) {
+	return j.eventDispatcher.DispatchPending(ctx)
+}
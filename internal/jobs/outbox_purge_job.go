@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"context"
+)
+
+type OutboxPurgeJob struct {
+	eventDispatcher OutboxPurger
+}
+
+type OutboxPurger interface {
+	PurgeProcessed(ctx context.Context) (int64, error)
+}
+
+func NewOutboxPurgeJob(eventDispatcher OutboxPurger) *OutboxPurgeJob {
+	return &OutboxPurgeJob{
+		eventDispatcher: eventDispatcher,
+	}
+}
+
+func (j *OutboxPurgeJob) Name() string {
+	return "outboxPurge"
+}
+
+// runs every day at 4:15 AM
+func (j *OutboxPurgeJob) Schedule() string {
+	return "0 15 4 * * *"
+}
+
+// Run purges the delivered and failed outbox events past their retention, returning how many were purged
+func (j *OutboxPurgeJob) Run(ctx context.Context) (int64, error) {
+	return j.eventDispatcher.PurgeProcessed(ctx)
+}
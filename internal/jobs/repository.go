@@ -0,0 +1,241 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{
+		db: db,
+	}
+}
+
+const runColumns = `id, job_name, trigger, status, started_at, finished_at, error, items_affected, instance_id, scheduled_for`
+
+// first key of the advisory locks of jobs, keeping them apart from other advisory locks
+const jobLockNamespace = 4201
+
+/**
+* Records the start of a run. A scheduled run is only recorded once per scheduled time
+* across all instances, ErrDuplicateResource is returned when another instance already
+* recorded it.
+**/
+func (r *repository) CreateRun(ctx context.Context, jobName string, trigger constants.JobTrigger, instanceID string, scheduledFor *time.Time) (*Run, error) {
+	query := `
+	INSERT INTO job_runs (job_name, trigger, instance_id, scheduled_for)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (job_name, scheduled_for) WHERE scheduled_for IS NOT NULL DO NOTHING
+	RETURNING ` + runColumns
+
+	runs := []*Run{}
+	if err := r.db.SelectContext(ctx, &runs, query, jobName, trigger, instanceID, scheduledFor); err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	if len(runs) == 0 {
+		return nil, constants.ErrDuplicateResource
+	}
+
+	return runs[0], nil
+}
+
+/**
+* Takes the advisory lock of a job on a dedicated connection, so that only one instance
+* runs the job at a time. Returns false without waiting when another instance holds it,
+* otherwise the returned function releases it.
+**/
+func (r *repository) TryLock(ctx context.Context, jobName string) (func(), bool, error) {
+	conn, err := r.db.Connx(ctx)
+	if err != nil {
+		return nil, false, errorutils.AnalyzeDBErr(err)
+	}
+
+	var acquired bool
+	err = conn.GetContext(ctx, &acquired, `SELECT pg_try_advisory_lock($1, hashtext($2))`, jobLockNamespace, jobName)
+	if err != nil {
+		conn.Close()
+		return nil, false, errorutils.AnalyzeDBErr(err)
+	}
+
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release := func() {
+		_, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1, hashtext($2))`, jobLockNamespace, jobName)
+		if err != nil {
+			fmt.Printf("Error releasing lock of job %s: %s\n", jobName, err.Error())
+
+			// discard the connection rather than returning it to the pool still holding the
+			// lock, ending its session releases the lock
+			conn.Raw(func(interface{}) error {
+				return driver.ErrBadConn
+			})
+		}
+		conn.Close()
+	}
+
+	return release, true, nil
+}
+
+func (r *repository) FinishRun(ctx context.Context, id uuid.UUID, status constants.JobRunStatus, runErr *string, itemsAffected int64) error {
+	query := `
+	UPDATE job_runs SET
+		status = $2,
+		error = $3,
+		items_affected = $4,
+		finished_at = NOW()
+	WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, status, runErr, itemsAffected)
+
+	return errorutils.AnalyzeDBResults(err, result)
+}
+
+// GetRuns returns the run history of a job, newest first
+func (r *repository) GetRuns(ctx context.Context, jobName string, page *pageutils.Params) ([]*Run, *pageutils.Page, error) {
+	query := `
+	SELECT ` + runColumns + `
+	FROM job_runs
+	WHERE job_name = $1
+	`
+
+	keys := []pageutils.Key{{Column: "started_at", Desc: true}}
+
+	query, args, err := pageutils.Apply(query, []interface{}{jobName}, keys, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	runs := []*Run{}
+	if err := r.db.SelectContext(ctx, &runs, query, args...); err != nil {
+		return nil, nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	runs, pageInfo := pageutils.Paginate(runs, page, func(run *Run) (uuid.UUID, []interface{}) {
+		return run.ID, []interface{}{run.StartedAt}
+	})
+
+	return runs, pageInfo, nil
+}
+
+// GetLastRuns returns the latest run of every job that ran, by job name
+func (r *repository) GetLastRuns(ctx context.Context) (map[string]*Run, error) {
+	query := `
+	SELECT DISTINCT ON (job_name) ` + runColumns + `
+	FROM job_runs
+	ORDER BY job_name, started_at DESC
+	`
+
+	runs := []*Run{}
+	if err := r.db.SelectContext(ctx, &runs, query); err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	lastRuns := make(map[string]*Run, len(runs))
+	for _, run := range runs {
+		lastRuns[run.JobName] = run
+	}
+
+	return lastRuns, nil
+}
+
+func (r *repository) GetPausedJobs(ctx context.Context) ([]string, error) {
+	query := `
+	SELECT job_name
+	FROM job_states
+	WHERE paused = true
+	`
+
+	names := []string{}
+	if err := r.db.SelectContext(ctx, &names, query); err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return names, nil
+}
+
+func (r *repository) SetPaused(ctx context.Context, jobName string, paused bool) error {
+	query := `
+	INSERT INTO job_states (job_name, paused)
+	VALUES ($1, $2)
+	ON CONFLICT (job_name) DO UPDATE SET
+		paused = EXCLUDED.paused
+	`
+
+	_, err := r.db.ExecContext(ctx, query, jobName, paused)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) IsPaused(ctx context.Context, jobName string) (bool, error) {
+	query := `
+	SELECT EXISTS (
+		SELECT 1 FROM job_states WHERE job_name = $1 AND paused = true
+	)
+	`
+
+	var paused bool
+	if err := r.db.GetContext(ctx, &paused, query, jobName); err != nil {
+		return false, errorutils.AnalyzeDBErr(err)
+	}
+
+	return paused, nil
+}
+
+// RecordSuccess keeps the start of the latest successful run of a job
+func (r *repository) RecordSuccess(ctx context.Context, jobName string, startedAt time.Time) error {
+	query := `
+	INSERT INTO job_states (job_name, last_succeeded_at)
+	VALUES ($1, $2)
+	ON CONFLICT (job_name) DO UPDATE SET
+		last_succeeded_at = GREATEST(job_states.last_succeeded_at, EXCLUDED.last_succeeded_at)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, jobName, startedAt)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+// GetLastSuccess returns the start of the latest successful run of a job, nil if it never succeeded
+func (r *repository) GetLastSuccess(ctx context.Context, jobName string) (*time.Time, error) {
+	query := `
+	SELECT last_succeeded_at
+	FROM job_states
+	WHERE job_name = $1
+	`
+
+	var lastSucceededAt *time.Time
+	err := r.db.GetContext(ctx, &lastSucceededAt, query, jobName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return lastSucceededAt, nil
+}
@@ -2,55 +2,39 @@ package jobs
 
 import (
 	"context"
-	"fmt"
-	"log"
-
-	"github.com/robfig/cron/v3"
+	"time"
 )
 
 type ScheduledItemsJob struct {
 	checklistService ChecklistScheduledItemsService
-	cron             *cron.Cron
-	jobID            cron.EntryID
 }
 
 type ChecklistScheduledItemsService interface {
-	TriggerScheduledReminder(ctx context.Context) error
-	CheckAllScheduledItems(ctx context.Context) error
+	CheckAllScheduledItems(ctx context.Context) (int64, error)
+	CatchUpScheduledItems(ctx context.Context, since time.Time) (int64, error)
 }
 
 func NewScheduledItemsJob(checklistService ChecklistScheduledItemsService) *ScheduledItemsJob {
-	c := cron.New(cron.WithSeconds())
 	return &ScheduledItemsJob{
 		checklistService: checklistService,
-		cron:             c,
 	}
 }
 
-func (j *ScheduledItemsJob) Start() {
-	fmt.Println("Starting scheduled reminder checker job.")
-	// Run every minute (second minute hour day month weekday)
-	jobID, err := j.cron.AddFunc("0 * * * * *", func() {
-		ctx := context.Background()
-		err := j.checklistService.CheckAllScheduledItems(ctx)
-		if err != nil {
-			log.Printf("error when checking scheduled checklist items in job.: %s\n", err.Error())
-		}
-	})
-
-	if err != nil {
-		log.Printf("Error scheduling checklist items job: %s\n", err.Error())
-		return
-	}
+func (j *ScheduledItemsJob) Name() string {
+	return "scheduledItems"
+}
 
-	j.jobID = jobID
-	j.cron.Start()
+// runs every minute (second minute hour day month weekday)
+func (j *ScheduledItemsJob) Schedule() string {
+	return "0 * * * * *"
 }
 
-func (j *ScheduledItemsJob) Stop() {
-	fmt.Println("Stopping scheduled items job.")
+// Run sends the reminders that are due, returning how many were sent
+func (j *ScheduledItemsJob) Run(ctx context.Context) (int64, error) {
+	return j.checklistService.CheckAllScheduledItems(ctx)
+}
 
-	ctx := j.cron.Stop()
-	// Wait for jobs to finish
-	<-ctx.Done()
+// CatchUp sends the reminders that became due since the last successful run
+func (j *ScheduledItemsJob) CatchUp(ctx context.Context, since time.Time) (int64, error) {
+	return j.checklistService.CatchUpScheduledItems(ctx, since)
 }
@@ -0,0 +1,176 @@
+package jobs
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// parses cron specs including seconds, e.g. "0 30 * * * *", or descriptors such as "@hourly"
+var scheduleParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+/**
+* Tells the time to the scheduler and the manager, so that job timing can be tested
+* deterministically with a fake clock.
+**/
+type Clock interface {
+	Now() time.Time
+	// delivers the time once d has passed
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// the clock of the system
+var SystemClock Clock = systemClock{}
+
+/**
+* Runs functions on cron schedules, each delayed by a random jitter up to a maximum so
+* that instances started at the same time do not all hit the database at once. Runs are
+* passed the time they were scheduled for, without the jitter.
+**/
+type Scheduler struct {
+	clock   Clock
+	entries map[string]*entry
+	running bool
+	stopped bool
+	mu      sync.Mutex
+}
+
+type entry struct {
+	schedule cron.Schedule
+	jitter   time.Duration
+	run      func(scheduledFor time.Time)
+	next     time.Time
+	stop     chan struct{}
+}
+
+func NewScheduler(clock Clock) *Scheduler {
+	return &Scheduler{
+		clock:   clock,
+		entries: make(map[string]*entry),
+	}
+}
+
+/**
+* Schedules run under a name, replacing what was scheduled under it before. Entries added
+* while the scheduler is running start right away.
+**/
+func (s *Scheduler) Add(name string, spec string, jitter time.Duration, run func(scheduledFor time.Time)) error {
+	schedule, err := scheduleParser.Parse(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(name)
+
+	e := &entry{
+		schedule: schedule,
+		jitter:   jitter,
+		run:      run,
+		stop:     make(chan struct{}),
+	}
+	s.entries[name] = e
+
+	if s.running {
+		go s.loop(e)
+	}
+
+	return nil
+}
+
+func (s *Scheduler) Remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(name)
+}
+
+// removes an entry, the lock must be held
+func (s *Scheduler) remove(name string) {
+	if e, ok := s.entries[name]; ok {
+		close(e.stop)
+		delete(s.entries, name)
+	}
+}
+
+// Next returns when the entry of a name runs next, without the jitter
+func (s *Scheduler) Next(name string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[name]
+	if !ok || e.next.IsZero() {
+		return time.Time{}, false
+	}
+
+	return e.next, true
+}
+
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running || s.stopped {
+		return
+	}
+
+	s.running = true
+	for _, e := range s.entries {
+		go s.loop(e)
+	}
+}
+
+// Stop stops scheduling new runs for good, runs already started are not waited for
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name := range s.entries {
+		s.remove(name)
+	}
+
+	s.running = false
+	s.stopped = true
+}
+
+// waits for each scheduled time of an entry and starts its run
+func (s *Scheduler) loop(e *entry) {
+	for {
+		now := s.clock.Now()
+		next := e.schedule.Next(now)
+		if next.IsZero() {
+			return
+		}
+
+		s.mu.Lock()
+		e.next = next
+		s.mu.Unlock()
+
+		delay := next.Sub(now)
+		if e.jitter > 0 {
+			delay += time.Duration(rand.Int64N(int64(e.jitter)))
+		}
+
+		select {
+		case <-e.stop:
+			return
+		case <-s.clock.After(delay):
+			go e.run(next)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+// waits for the next timer, moves the clock past it and delivers it
+func (c *fakeClock) fireNext(t *testing.T) time.Duration {
+	t.Helper()
+
+	select {
+	case timer := <-c.timers:
+		c.mu.Lock()
+		c.now = c.now.Add(timer.d)
+		now := c.now
+		c.mu.Unlock()
+
+		timer.c <- now
+		return timer.d
+	case <-time.After(time.Second):
+		t.Fatal("no timer was started")
+		return 0
+	}
+}
+
+func TestSchedulerRunsOnSchedule(t *testing.T) {
+	start := time.Date(2026, 3, 10, 12, 0, 30, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		spec      string
+		wantDelay []time.Duration
+		wantRuns  []time.Time
+	}{
+		{
+			"every minute",
+			"0 * * * * *",
+			[]time.Duration{30 * time.Second, time.Minute},
+			[]time.Time{start.Add(30 * time.Second), start.Add(90 * time.Second)},
+		},
+		{
+			"hourly descriptor",
+			"@hourly",
+			[]time.Duration{59*time.Minute + 30*time.Second, time.Hour},
+			[]time.Time{time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC), time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock(start)
+			scheduler := NewScheduler(clock)
+
+			runs := make(chan time.Time, len(tt.wantRuns))
+			if err := scheduler.Add("job", tt.spec, 0, func(scheduledFor time.Time) { runs <- scheduledFor }); err != nil {
+				t.Fatalf("Add returned error: %v", err)
+			}
+
+			scheduler.Start()
+			defer scheduler.Stop()
+
+			for i, want := range tt.wantRuns {
+				if delay := clock.fireNext(t); delay != tt.wantDelay[i] {
+					t.Errorf("run %d waited %s, want %s", i, delay, tt.wantDelay[i])
+				}
+
+				select {
+				case got := <-runs:
+					if !got.Equal(want) {
+						t.Errorf("run %d scheduled for %s, want %s", i, got, want)
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("run %d did not start", i)
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerJitterDelaysRunsOnly(t *testing.T) {
+	start := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	scheduler := NewScheduler(clock)
+
+	runs := make(chan time.Time, 1)
+	if err := scheduler.Add("job", "0 * * * * *", 10*time.Second, func(scheduledFor time.Time) { runs <- scheduledFor }); err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	delay := clock.fireNext(t)
+	if delay < time.Minute || delay >= time.Minute+10*time.Second {
+		t.Errorf("waited %s, want between 1m and 1m10s", delay)
+	}
+
+	if got := <-runs; !got.Equal(start.Add(time.Minute)) {
+		t.Errorf("scheduled for %s, want %s without the jitter", got, start.Add(time.Minute))
+	}
+}
+
+func TestSchedulerNext(t *testing.T) {
+	start := time.Date(2026, 3, 10, 12, 0, 30, 0, time.UTC)
+	clock := newFakeClock(start)
+	scheduler := NewScheduler(clock)
+
+	if err := scheduler.Add("job", "0 * * * * *", 0, func(time.Time) {}); err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+
+	if _, ok := scheduler.Next("job"); ok {
+		t.Error("Next reported a run before the scheduler started")
+	}
+
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	// the loop has computed its next run once it waits for it
+	timer := <-clock.timers
+	clock.timers <- timer
+
+	next, ok := scheduler.Next("job")
+	if !ok || !next.Equal(start.Add(30*time.Second)) {
+		t.Errorf("Next = %s, %v, want %s", next, ok, start.Add(30*time.Second))
+	}
+
+	scheduler.Remove("job")
+	if _, ok := scheduler.Next("job"); ok {
+		t.Error("Next reported a run of a removed entry")
+	}
+}
+
+func TestSchedulerAddInvalidSpec(t *testing.T) {
+	scheduler := NewScheduler(newFakeClock(time.Now()))
+
+	for _, spec := range []string{"", "* * * * *", "every minute", "61 * * * * *"} {
+		if err := scheduler.Add("job", spec, 0, func(time.Time) {}); err == nil {
+			t.Errorf("Add(%q) returned no error", spec)
+		}
+	}
+}
+
+func TestSchedulerStartAfterStop(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	scheduler := NewScheduler(clock)
+
+	scheduler.Stop()
+	if err := scheduler.Add("job", "* * * * * *", 0, func(time.Time) {}); err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	scheduler.Start()
+
+	select {
+	case <-clock.timers:
+		t.Error("a stopped scheduler started waiting for a run")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
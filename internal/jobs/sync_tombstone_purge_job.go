@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"context"
+)
+
+type SyncTombstonePurgeJob struct {
+	syncService SyncTombstonePurgeService
+}
+
+type SyncTombstonePurgeService interface {
+	PurgeTombstones(ctx context.Context) (int64, error)
+}
+
+func NewSyncTombstonePurgeJob(syncService SyncTombstonePurgeService) *SyncTombstonePurgeJob {
+	return &SyncTombstonePurgeJob{
+		syncService: syncService,
+	}
+}
+
+func (j *SyncTombstonePurgeJob) Name() string {
+	return "syncTombstonePurge"
+}
+
+// runs every day at 3:45 AM
+func (j *SyncTombstonePurgeJob) Schedule() string {
+	return "0 45 3 * * *"
+}
+
+// Run purges the tombstones no sync token can need anymore, returning how many were purged
+func (j *SyncTombstonePurgeJob) Run(ctx context.Context) (int64, error) {
+	return j.syncService.PurgeTombstones(ctx)
+}
@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"context"
+)
+
+type UndoPurgeJob struct {
+	checklistService ChecklistUndoPurgeService
+}
+
+type ChecklistUndoPurgeService interface {
+	PurgeDeleted(ctx context.Context) (int64, error)
+}
+
+func NewUndoPurgeJob(checklistService ChecklistUndoPurgeService) *UndoPurgeJob {
+	return &UndoPurgeJob{
+		checklistService: checklistService,
+	}
+}
+
+func (j *UndoPurgeJob) Name() string {
+	return "undoPurge"
+}
+
+// runs every hour at quarter past
+func (j *UndoPurgeJob) Schedule() string {
+	return "0 15 * * * *"
+}
+
+// Run purges the deleted items that can no longer be restored, returning how many were purged
+func (j *UndoPurgeJob) Run(ctx context.Context) (int64, error) {
+	return j.checklistService.PurgeDeleted(ctx)
+}
@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"context"
+)
+
+type WebhookDeliveryJob struct {
+	webhookService WebhookDeliverer
+}
+
+type WebhookDeliverer interface {
+	DeliverPending(ctx context.Context) (int64, error)
+}
+
+func NewWebhookDeliveryJob(webhookService WebhookDeliverer) *WebhookDeliveryJob {
+	return &WebhookDeliveryJob{
+		webhookService: webhookService,
+	}
+}
+
+func (j *WebhookDeliveryJob) Name() string {
+	return "webhookDelivery"
+}
+
+// runs every 10 seconds, in between the runs of the outbox dispatch
+func (j *WebhookDeliveryJob) Schedule() string {
+	return "5/10 * * * * *"
+}
+
+// Run sends the webhook deliveries that are due, returning how many were delivered
+func (j *WebhookDeliveryJob) Run(ctx context.Context) (int64, error) {
+	return j.webhookService.DeliverPending(ctx)
+}
@@ -0,0 +1,124 @@
+package links
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+}
+
+type Service interface {
+	Create(ctx context.Context, planID uuid.UUID, itemID uuid.UUID, req CreateLinkReq) (*Link, error)
+	GetAllByItemId(ctx context.Context, itemID uuid.UUID) ([]*Link, error)
+	RefreshMetadata(ctx context.Context, id uuid.UUID, itemID uuid.UUID) (*Link, error)
+	Delete(ctx context.Context, id uuid.UUID, itemID uuid.UUID) error
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	planID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect uuid format provided plan id in the param."})
+		return
+	}
+
+	itemID, err := uuid.Parse(c.Param("checklist_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect uuid format provided checklist id in the param."})
+		return
+	}
+
+	var req CreateLinkReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body. Error was: " + err.Error()})
+		return
+	}
+
+	link, err := h.service.Create(c.Request.Context(), planID, itemID, req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, constants.ErrInvalidInput):
+			status = http.StatusBadRequest
+		case errors.Is(err, constants.ErrNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, constants.ErrDuplicateResource):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": "Failed to attach link. Error: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"statusCode": http.StatusCreated, "message": "Successfully attached link.", "result": link})
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	itemID, err := uuid.Parse(c.Param("checklist_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect uuid format."})
+		return
+	}
+
+	links, err := h.service.GetAllByItemId(c.Request.Context(), itemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get links. Error: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved links.", "result": links})
+}
+
+func (h *Handler) RefreshMetadata(c *gin.Context) {
+	itemID, err := uuid.Parse(c.Param("checklist_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect uuid format."})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("link_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	link, err := h.service.RefreshMetadata(c.Request.Context(), id, itemID)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to refresh link metadata. Error: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully refreshed link metadata.", "result": link})
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	itemID, err := uuid.Parse(c.Param("checklist_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect uuid format.", "result": constants.UpdateStatusFailure})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("link_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format", "result": constants.UpdateStatusFailure})
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id, itemID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete link"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully deleted link.", "result": constants.UpdateStatusSuccess})
+}
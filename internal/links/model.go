@@ -0,0 +1,32 @@
+package links
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Link struct {
+	ID                uuid.UUID  `db:"id" json:"id"`
+	ChecklistItemID   uuid.UUID  `db:"checklist_item_id" json:"checklistItemId"`
+	URL               string     `db:"url" json:"url"`
+	Title             *string    `db:"title" json:"title,omitempty"`
+	Description       *string    `db:"description" json:"description,omitempty"`
+	SiteName          *string    `db:"site_name" json:"siteName,omitempty"`
+	ImageURL          *string    `db:"image_url" json:"imageUrl,omitempty"`
+	ResourceType      string     `db:"resource_type" json:"resourceType"`
+	MetadataFetchedAt *time.Time `db:"metadata_fetched_at" json:"metadataFetchedAt,omitempty"`
+	CreatedAt         time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+/**
+* Attaches a link to a checklist item. The fields match discovery.Resource so that
+* discovery results can be attached as they are.
+**/
+type CreateLinkReq struct {
+	URL          string  `json:"url" binding:"required"`
+	Title        *string `json:"title,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	ResourceType *string `json:"type,omitempty"`
+}
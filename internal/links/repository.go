@@ -0,0 +1,132 @@
+package links
+
+import (
+	"context"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{
+		db: db,
+	}
+}
+
+const linkColumns = `id, checklist_item_id, url, title, description, site_name, image_url, resource_type, metadata_fetched_at, created_at, updated_at`
+
+/**
+* Attaches a link to a checklist item, only if the item belongs to the provided plan.
+**/
+func (r *repository) Create(ctx context.Context, planID uuid.UUID, link Link) (*Link, error) {
+	query := `
+	INSERT INTO checklist_item_links (checklist_item_id, url, title, description, site_name, image_url, resource_type, metadata_fetched_at)
+	SELECT id, :url, :title, :description, :site_name, :image_url, :resource_type, :metadata_fetched_at
+	FROM checklist_items
+	WHERE id = :checklist_item_id
+	AND plan_id = :plan_id
+	AND deleted_at IS NULL
+	RETURNING ` + linkColumns
+
+	params := map[string]interface{}{
+		"checklist_item_id":   link.ChecklistItemID,
+		"plan_id":             planID,
+		"url":                 link.URL,
+		"title":               link.Title,
+		"description":         link.Description,
+		"site_name":           link.SiteName,
+		"image_url":           link.ImageURL,
+		"resource_type":       link.ResourceType,
+		"metadata_fetched_at": link.MetadataFetchedAt,
+	}
+
+	rows, err := r.db.NamedQueryContext(ctx, query, params)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+	defer rows.Close()
+
+	var created Link
+	if rows.Next() {
+		if err := rows.StructScan(&created); err != nil {
+			return nil, errorutils.AnalyzeDBErr(err)
+		}
+	} else {
+		// errors raised by the insert, such as unique violations, only surface here
+		if err := rows.Err(); err != nil {
+			return nil, errorutils.AnalyzeDBErr(err)
+		}
+		return nil, constants.ErrNotFound
+	}
+
+	return &created, nil
+}
+
+func (r *repository) GetAllByItemId(ctx context.Context, itemID uuid.UUID) ([]*Link, error) {
+	query := `
+	SELECT ` + linkColumns + `
+	FROM checklist_item_links
+	WHERE checklist_item_id = $1
+	ORDER BY created_at ASC
+	`
+
+	links := []*Link{}
+	err := r.db.SelectContext(ctx, &links, query, itemID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return links, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id uuid.UUID, itemID uuid.UUID) (*Link, error) {
+	query := `
+	SELECT ` + linkColumns + `
+	FROM checklist_item_links
+	WHERE id = $1
+	AND checklist_item_id = $2
+	`
+
+	var link Link
+	err := r.db.GetContext(ctx, &link, query, id, itemID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &link, nil
+}
+
+func (r *repository) UpdateMetadata(ctx context.Context, link Link) error {
+	query := `
+	UPDATE checklist_item_links
+	SET
+		title = :title,
+		description = :description,
+		site_name = :site_name,
+		image_url = :image_url,
+		metadata_fetched_at = :metadata_fetched_at
+	WHERE id = :id
+	`
+
+	result, err := r.db.NamedExecContext(ctx, query, link)
+
+	return errorutils.AnalyzeDBResults(err, result)
+}
+
+func (r *repository) Delete(ctx context.Context, id uuid.UUID, itemID uuid.UUID) error {
+	query := `
+	DELETE FROM checklist_item_links
+	WHERE id = $1
+	AND checklist_item_id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, itemID)
+
+	return errorutils.AnalyzeDBResults(err, result)
+}
@@ -0,0 +1,162 @@
+package links
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/discovery"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/google/uuid"
+)
+
+const (
+	maxURLLength         = 2048
+	metadataFetchTimeout = 8 * time.Second
+)
+
+type service struct {
+	repo            Repository
+	metadataFetcher LinkMetadataFetcher
+}
+
+type Repository interface {
+	Create(ctx context.Context, planID uuid.UUID, link Link) (*Link, error)
+	GetAllByItemId(ctx context.Context, itemID uuid.UUID) ([]*Link, error)
+	GetByID(ctx context.Context, id uuid.UUID, itemID uuid.UUID) (*Link, error)
+	UpdateMetadata(ctx context.Context, link Link) error
+	Delete(ctx context.Context, id uuid.UUID, itemID uuid.UUID) error
+}
+
+type LinkMetadataFetcher interface {
+	FetchMetadata(ctx context.Context, rawURL string) (*discovery.PageMetadata, error)
+}
+
+func NewService(repo Repository, metadataFetcher LinkMetadataFetcher) Service {
+	return &service{
+		repo:            repo,
+		metadataFetcher: metadataFetcher,
+	}
+}
+
+/**
+* Attaches a link to a checklist item and fills in its title, description and preview
+* from the linked page. Values provided in the request take precedence over fetched ones.
+* Failing to fetch metadata does not prevent the link from being attached.
+**/
+func (s *service) Create(ctx context.Context, planID uuid.UUID, itemID uuid.UUID, req CreateLinkReq) (*Link, error) {
+	linkURL, err := validateURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceType := constants.TypeWebsite
+	if isVideoHost(linkURL) {
+		resourceType = constants.TypeVideo
+	}
+
+	if req.ResourceType != nil {
+		resourceType = constants.ResourceType(*req.ResourceType)
+		if resourceType != constants.TypeWebsite && resourceType != constants.TypeVideo {
+			return nil, errorutils.Invalidf("type must be either 'website' or 'video'")
+		}
+	}
+
+	link := Link{
+		ChecklistItemID: itemID,
+		URL:             linkURL.String(),
+		ResourceType:    string(resourceType),
+	}
+
+	s.applyMetadata(ctx, &link)
+
+	if req.Title != nil {
+		link.Title = req.Title
+	}
+
+	if req.Description != nil {
+		link.Description = req.Description
+	}
+
+	return s.repo.Create(ctx, planID, link)
+}
+
+func (s *service) GetAllByItemId(ctx context.Context, itemID uuid.UUID) ([]*Link, error) {
+	return s.repo.GetAllByItemId(ctx, itemID)
+}
+
+/**
+* Fetches the metadata of an attached link again, e.g. after the page changed.
+**/
+func (s *service) RefreshMetadata(ctx context.Context, id uuid.UUID, itemID uuid.UUID) (*Link, error) {
+	link, err := s.repo.GetByID(ctx, id, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.applyMetadata(ctx, link) {
+		return nil, fmt.Errorf("could not fetch metadata for %s", link.URL)
+	}
+
+	if err := s.repo.UpdateMetadata(ctx, *link); err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+func (s *service) Delete(ctx context.Context, id uuid.UUID, itemID uuid.UUID) error {
+	return s.repo.Delete(ctx, id, itemID)
+}
+
+// fills in the link with fetched page metadata, reporting whether it succeeded
+func (s *service) applyMetadata(ctx context.Context, link *Link) bool {
+	fetchCtx, cancel := context.WithTimeout(ctx, metadataFetchTimeout)
+	defer cancel()
+
+	metadata, err := s.metadataFetcher.FetchMetadata(fetchCtx, link.URL)
+	if err != nil {
+		fmt.Printf("Error when fetching metadata for link %s: %s\n", link.URL, err.Error())
+		return false
+	}
+
+	link.Title = nonEmpty(metadata.Title)
+	link.Description = nonEmpty(metadata.Description)
+	link.SiteName = nonEmpty(metadata.SiteName)
+	link.ImageURL = nonEmpty(metadata.ImageURL)
+
+	now := time.Now()
+	link.MetadataFetchedAt = &now
+
+	return true
+}
+
+func validateURL(rawURL string) (*url.URL, error) {
+	trimmed := strings.TrimSpace(rawURL)
+
+	if len(trimmed) > maxURLLength {
+		return nil, errorutils.Invalidf("url can be at most %d characters", maxURLLength)
+	}
+
+	parsed, err := url.Parse(trimmed)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, errorutils.Invalidf("url must be an absolute http or https url")
+	}
+
+	return parsed, nil
+}
+
+func isVideoHost(u *url.URL) bool {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	return host == "youtube.com" || host == "m.youtube.com" || host == "youtu.be" || host == "vimeo.com"
+}
+
+func nonEmpty(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 /**
@@ -14,6 +15,13 @@ type User struct {
 	Email    string `db:"email" json:"email"`
 	Name     string `db:"name" json:"name"`
 	Password string `db:"password" json:"password,omitempty"`
+
+	// day the week starts on for weekly items, 0 is sunday and 1 is monday
+	WeekStartsOn int `db:"week_starts_on" json:"weekStartsOn"`
+	// IANA timezone, e.g. Europe/Berlin
+	Timezone string `db:"timezone" json:"timezone"`
+	// local hour of the day repeating items are reset at
+	ResetHour int `db:"reset_hour" json:"resetHour"`
 }
 
 type Plan struct {
@@ -24,17 +32,31 @@ type Plan struct {
 	Description string    `db:"description" json:"description"`
 	PlanType    string    `db:"plan_type" json:"planType"`
 	DailyReset  bool      `db:"daily_reset" json:"dailyReset"`
+	Version     int       `db:"version" json:"version"`
+
+	// auto archive policy
+	AutoArchiveCompletedAfterDays *int `db:"auto_archive_completed_after_days" json:"autoArchiveCompletedAfterDays"`
+	AutoArchivePastScheduled      bool `db:"auto_archive_past_scheduled" json:"autoArchivePastScheduled"`
 }
 
 type ChecklistItem struct {
 	BaseDBDateModel
-	Description   string     `db:"description" json:"description"`
-	Done          bool       `db:"done" json:"done"`
-	Sequence      string     `db:"sequence" json:"sequence"`
-	ScheduledTime *time.Time `db:"scheduled_time" json:"scheduledTime,omitempty"`
-	Scope         string     `db:"scope" json:"scope"`
-	Archived      bool       `db:"archived" json:"archived"`
-	PlanID        uuid.UUID  `db:"plan_id" json:"planId"`
+	Description    string     `db:"description" json:"description"`
+	Notes          *string    `db:"notes" json:"notes,omitempty"`
+	Done           bool       `db:"done" json:"done"`
+	Sequence       string     `db:"sequence" json:"sequence"`
+	ScheduledTime  *time.Time `db:"scheduled_time" json:"scheduledTime,omitempty"`
+	DueDate        *time.Time `db:"due_date" json:"dueDate,omitempty"`
+	Priority       string     `db:"priority" json:"priority"`
+	EffortEstimate *int       `db:"effort_estimate" json:"effortEstimate,omitempty"`
+	EffortUnit     *string    `db:"effort_unit" json:"effortUnit,omitempty"`
+	Scope          string     `db:"scope" json:"scope"`
+	Archived       bool       `db:"archived" json:"archived"`
+	PlanID         uuid.UUID  `db:"plan_id" json:"planId"`
+	Version        int        `db:"version" json:"version"`
+
+	// minutes before the scheduled time reminders are sent at
+	ReminderLeadMinutes pq.Int64Array `db:"reminder_lead_minutes" json:"reminderLeadMinutes"`
 }
 
 /**
@@ -0,0 +1,138 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+)
+
+/**
+* Delivers notifications through the channels their user chose for their kind.
+**/
+type Dispatcher struct {
+	repo      Repository
+	notifiers map[constants.NotificationChannel]Notifier
+	now       func() time.Time
+}
+
+func NewDispatcher(repo Repository, notifiers map[constants.NotificationChannel]Notifier) *Dispatcher {
+	return &Dispatcher{
+		repo:      repo,
+		notifiers: notifiers,
+		now:       time.Now,
+	}
+}
+
+/**
+* Hands a notification to each channel of its user. A notification counts as dispatched
+* once any channel delivered it, failures of the other channels are only logged so that
+* retrying does not deliver it twice to the channels that succeeded.
+**/
+func (d *Dispatcher) Dispatch(ctx context.Context, notification Notification) error {
+	recipient, err := d.repo.GetRecipient(ctx, notification.UserID)
+	if err != nil {
+		return err
+	}
+
+	prefs, err := d.repo.GetPreferences(ctx, notification.UserID)
+	if err != nil {
+		return err
+	}
+
+	channels := channelsFor(prefs, notification.Kind, d.now(), recipient.Timezone)
+	if len(channels) == 0 {
+		return nil
+	}
+
+	var errs []error
+	delivered := 0
+
+	for _, channel := range channels {
+		notifier, ok := d.notifiers[channel]
+		if !ok {
+			errs = append(errs, fmt.Errorf("no notifier for channel %s", channel))
+			continue
+		}
+
+		if err := notifier.Notify(ctx, recipient, notification); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel, err))
+			continue
+		}
+		delivered++
+	}
+
+	if delivered == 0 {
+		return errors.Join(errs...)
+	}
+
+	for _, err := range errs {
+		fmt.Printf("Error delivering %s notification to user %s through %s\n", notification.Kind, notification.UserID, err.Error())
+	}
+
+	return nil
+}
+
+/**
+* The channels a kind of notification goes to at a point in time. During quiet hours
+* every channel other than the in-app inbox is replaced by the inbox.
+**/
+func channelsFor(prefs *Preferences, kind constants.NotificationKind, now time.Time, timezone string) []constants.NotificationChannel {
+	channels, ok := prefs.Channels[kind]
+	if !ok {
+		channels = []constants.NotificationChannel{constants.ChannelInApp}
+	}
+
+	if len(channels) == 0 || !inQuietHours(prefs, now, timezone) {
+		return channels
+	}
+
+	return []constants.NotificationChannel{constants.ChannelInApp}
+}
+
+/**
+* Whether a point in time lies within the quiet hours of the user in their timezone. Quiet
+* hours with a start after their end span midnight, e.g. 22:00 to 07:00.
+**/
+func inQuietHours(prefs *Preferences, now time.Time, timezone string) bool {
+	if prefs.QuietHoursStart == nil || prefs.QuietHoursEnd == nil {
+		return false
+	}
+
+	start, err := minuteOfDay(*prefs.QuietHoursStart)
+	if err != nil {
+		return false
+	}
+
+	end, err := minuteOfDay(*prefs.QuietHoursEnd)
+	if err != nil {
+		return false
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	local := now.In(location)
+	current := local.Hour()*60 + local.Minute()
+
+	if start <= end {
+		return current >= start && current < end
+	}
+
+	return current >= start || current < end
+}
+
+// parses a HH:MM or HH:MM:SS time of day into minutes after midnight
+func minuteOfDay(value string) (int, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Hour()*60 + t.Minute(), nil
+		}
+	}
+	return 0, errorutils.Invalidf("%s is not a time of day in the format HH:MM", value)
+}
@@ -0,0 +1,62 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+/**
+* Delivers notifications by email through an SMTP server configured with the SMTP_HOST,
+* SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM environment variables.
+**/
+type EmailNotifier struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func NewEmailNotifier() *EmailNotifier {
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+
+	return &EmailNotifier{
+		host:     os.Getenv("SMTP_HOST"),
+		port:     port,
+		username: os.Getenv("SMTP_USERNAME"),
+		password: os.Getenv("SMTP_PASSWORD"),
+		from:     os.Getenv("SMTP_FROM"),
+	}
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, recipient *Recipient, notification Notification) error {
+	if n.host == "" || n.from == "" {
+		return fmt.Errorf("email notifications are not configured")
+	}
+
+	var auth smtp.Auth
+	if n.username != "" {
+		auth = smtp.PlainAuth("", n.username, n.password, n.host)
+	}
+
+	// header values cannot contain line breaks, otherwise headers could be injected
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(notification.Title)
+
+	message := strings.Join([]string{
+		"From: " + n.from,
+		"To: " + recipient.Email,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		notification.Body,
+	}, "\r\n")
+
+	return smtp.SendMail(n.host+":"+n.port, auth, n.from, []string{recipient.Email}, []byte(message))
+}
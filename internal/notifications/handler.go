@@ -0,0 +1,135 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+}
+
+type Service interface {
+	GetInbox(ctx context.Context, userID uuid.UUID, unreadOnly bool, page *pageutils.Params) ([]*InboxItem, *pageutils.Page, error)
+	CountUnread(ctx context.Context, userID uuid.UUID) (int, error)
+	MarkRead(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+	MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error)
+	GetPreferences(ctx context.Context, userID uuid.UUID) (*Preferences, error)
+	UpdatePreferences(ctx context.Context, userID uuid.UUID, req UpdatePreferencesReq) (*Preferences, error)
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// GetInbox lists the in-app notifications of the user, only the unread ones with ?unread=true
+func (h *Handler) GetInbox(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	page, err := pageutils.ParseParams(c.Query("limit"), c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid pagination parameters", "error": err.Error()})
+		return
+	}
+
+	items, pageInfo, err := h.service.GetInbox(c.Request.Context(), userId, c.Query("unread") == "true", page)
+	if errors.Is(err, pageutils.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid pagination parameters", "error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to get notifications", "error": err.Error()})
+		return
+	}
+
+	unread, err := h.service.CountUnread(c.Request.Context(), userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to count unread notifications", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved notifications", "result": items, "unreadCount": unread, "page": pageInfo})
+}
+
+// MarkRead marks a single notification as read
+func (h *Handler) MarkRead(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Incorrect uuid format."})
+		return
+	}
+
+	if err := h.service.MarkRead(c.Request.Context(), userId, id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, constants.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to mark notification as read", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully marked notification as read"})
+}
+
+// MarkAllRead marks every notification of the user as read
+func (h *Handler) MarkAllRead(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	count, err := h.service.MarkAllRead(c.Request.Context(), userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to mark notifications as read", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully marked notifications as read", "result": gin.H{"updated": count}})
+}
+
+func (h *Handler) GetPreferences(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	prefs, err := h.service.GetPreferences(c.Request.Context(), userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to get notification preferences", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved notification preferences", "result": prefs})
+}
+
+// UpdatePreferences replaces the notification preferences of the user
+func (h *Handler) UpdatePreferences(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	var req UpdatePreferencesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid request body", "error": err.Error()})
+		return
+	}
+
+	prefs, err := h.service.UpdatePreferences(c.Request.Context(), userId, req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, constants.ErrInvalidInput) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to update notification preferences", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully updated notification preferences", "result": prefs})
+}
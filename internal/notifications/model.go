@@ -0,0 +1,98 @@
+package notifications
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/google/uuid"
+)
+
+// a message for a user, independent of the channel it is delivered through
+type Notification struct {
+	UserID uuid.UUID                  `json:"userId"`
+	Kind   constants.NotificationKind `json:"kind"`
+	Title  string                     `json:"title"`
+	Body   string                     `json:"body"`
+	Data   Data                       `json:"data,omitempty"`
+}
+
+// a notification stored in the in-app inbox of a user
+type InboxItem struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	UserID    uuid.UUID  `db:"user_id" json:"-"`
+	Kind      string     `db:"kind" json:"kind"`
+	Title     string     `db:"title" json:"title"`
+	Body      string     `db:"body" json:"body"`
+	Data      Data       `db:"data" json:"data,omitempty"`
+	ReadAt    *time.Time `db:"read_at" json:"readAt,omitempty"`
+	CreatedAt time.Time  `db:"created_at" json:"createdAt"`
+}
+
+// who a notification is delivered to
+type Recipient struct {
+	UserID   uuid.UUID `db:"id"`
+	Email    string    `db:"email"`
+	Timezone string    `db:"timezone"`
+}
+
+/**
+* Notification preferences of a user. Kinds missing from the channels only go to the
+* in-app inbox, a kind with no channels is not sent at all. During quiet hours, given in
+* the user's local time, notifications only go to the in-app inbox.
+**/
+type Preferences struct {
+	UserID          uuid.UUID          `db:"user_id" json:"-"`
+	Channels        ChannelPreferences `db:"channels" json:"channels"`
+	QuietHoursStart *string            `db:"quiet_hours_start" json:"quietHoursStart,omitempty"`
+	QuietHoursEnd   *string            `db:"quiet_hours_end" json:"quietHoursEnd,omitempty"`
+}
+
+// replaces all notification preferences of a user, quiet hours are given as HH:MM
+type UpdatePreferencesReq struct {
+	Channels        ChannelPreferences `json:"channels"`
+	QuietHoursStart *string            `json:"quietHoursStart,omitempty"`
+	QuietHoursEnd   *string            `json:"quietHoursEnd,omitempty"`
+}
+
+// extra information of a notification, stored as json
+type Data map[string]interface{}
+
+func (d Data) Value() (driver.Value, error) {
+	if d == nil {
+		return nil, nil
+	}
+	return json.Marshal(d)
+}
+
+func (d *Data) Scan(src interface{}) error {
+	return scanJSON(src, d)
+}
+
+// the channels each kind of notification is delivered through, stored as json
+type ChannelPreferences map[constants.NotificationKind][]constants.NotificationChannel
+
+func (c ChannelPreferences) Value() (driver.Value, error) {
+	if c == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(c)
+}
+
+func (c *ChannelPreferences) Scan(src interface{}) error {
+	return scanJSON(src, c)
+}
+
+func scanJSON(src interface{}, dest interface{}) error {
+	switch value := src.(type) {
+	case []byte:
+		return json.Unmarshal(value, dest)
+	case string:
+		return json.Unmarshal([]byte(value), dest)
+	case nil:
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into %T", src, dest)
+}
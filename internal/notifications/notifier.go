@@ -0,0 +1,28 @@
+package notifications
+
+import (
+	"context"
+)
+
+/**
+* A channel notifications can be delivered through.
+**/
+type Notifier interface {
+	Notify(ctx context.Context, recipient *Recipient, notification Notification) error
+}
+
+// delivers notifications to the in-app inbox of the user
+type InboxNotifier struct {
+	repo Repository
+}
+
+func NewInboxNotifier(repo Repository) *InboxNotifier {
+	return &InboxNotifier{
+		repo: repo,
+	}
+}
+
+func (n *InboxNotifier) Notify(ctx context.Context, recipient *Recipient, notification Notification) error {
+	_, err := n.repo.CreateInboxItem(ctx, notification)
+	return err
+}
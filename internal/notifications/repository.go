@@ -0,0 +1,162 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{
+		db: db,
+	}
+}
+
+const inboxColumns = `id, user_id, kind, title, body, data, read_at, created_at`
+
+func (r *repository) CreateInboxItem(ctx context.Context, notification Notification) (*InboxItem, error) {
+	query := fmt.Sprintf(`
+	INSERT INTO notifications (user_id, kind, title, body, data)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING %s
+	`, inboxColumns)
+
+	var item InboxItem
+	err := r.db.GetContext(ctx, &item, query, notification.UserID, string(notification.Kind), notification.Title, notification.Body, notification.Data)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &item, nil
+}
+
+// GetInbox returns the in-app notifications of a user, most recent first
+func (r *repository) GetInbox(ctx context.Context, userID uuid.UUID, unreadOnly bool, page *pageutils.Params) ([]*InboxItem, *pageutils.Page, error) {
+	query := fmt.Sprintf(`
+	SELECT %s
+	FROM notifications
+	WHERE user_id = $1
+	`, inboxColumns)
+
+	if unreadOnly {
+		query += `
+	AND read_at IS NULL`
+	}
+
+	keys := []pageutils.Key{{Column: "created_at", Desc: true}}
+
+	query, args, err := pageutils.Apply(query, []interface{}{userID}, keys, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := []*InboxItem{}
+	if err := r.db.SelectContext(ctx, &items, query, args...); err != nil {
+		return nil, nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	items, pageInfo := pageutils.Paginate(items, page, func(item *InboxItem) (uuid.UUID, []interface{}) {
+		return item.ID, []interface{}{item.CreatedAt}
+	})
+
+	return items, pageInfo, nil
+}
+
+func (r *repository) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID)
+	if err != nil {
+		return 0, errorutils.AnalyzeDBErr(err)
+	}
+
+	return count, nil
+}
+
+// MarkRead marks a notification of a user as read, notifications that were already read keep their read time
+func (r *repository) MarkRead(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	query := `
+	UPDATE notifications
+	SET read_at = COALESCE(read_at, NOW())
+	WHERE id = $1
+	AND user_id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	err = errorutils.AnalyzeDBResults(err, result)
+	if errors.Is(err, constants.ErrNoRowsAffected) {
+		return constants.ErrNotFound
+	}
+
+	return err
+}
+
+// MarkAllRead marks every unread notification of a user as read and returns how many there were
+func (r *repository) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL`, userID)
+	if err != nil {
+		return 0, errorutils.AnalyzeDBErr(err)
+	}
+
+	return result.RowsAffected()
+}
+
+// GetRecipient returns the contact details of a user
+func (r *repository) GetRecipient(ctx context.Context, userID uuid.UUID) (*Recipient, error) {
+	var recipient Recipient
+	err := r.db.GetContext(ctx, &recipient, `SELECT id, email, timezone FROM users WHERE id = $1`, userID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &recipient, nil
+}
+
+// GetPreferences returns the notification preferences of a user, users without any get the defaults
+func (r *repository) GetPreferences(ctx context.Context, userID uuid.UUID) (*Preferences, error) {
+	query := `
+	SELECT
+		user_id,
+		channels,
+		to_char(quiet_hours_start, 'HH24:MI') AS quiet_hours_start,
+		to_char(quiet_hours_end, 'HH24:MI') AS quiet_hours_end
+	FROM notification_preferences
+	WHERE user_id = $1
+	`
+
+	prefs := Preferences{UserID: userID, Channels: ChannelPreferences{}}
+	err := r.db.GetContext(ctx, &prefs, query, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &prefs, nil
+	}
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &prefs, nil
+}
+
+func (r *repository) UpsertPreferences(ctx context.Context, userID uuid.UUID, req UpdatePreferencesReq) error {
+	query := `
+	INSERT INTO notification_preferences (user_id, channels, quiet_hours_start, quiet_hours_end)
+	VALUES ($1, $2, CAST($3 AS TIME), CAST($4 AS TIME))
+	ON CONFLICT (user_id) DO UPDATE SET
+		channels = EXCLUDED.channels,
+		quiet_hours_start = EXCLUDED.quiet_hours_start,
+		quiet_hours_end = EXCLUDED.quiet_hours_end
+	`
+
+	_, err := r.db.ExecContext(ctx, query, userID, req.Channels, req.QuietHoursStart, req.QuietHoursEnd)
+
+	return errorutils.AnalyzeDBErr(err)
+}
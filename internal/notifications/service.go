@@ -0,0 +1,118 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
+	"github.com/google/uuid"
+)
+
+type service struct {
+	repo Repository
+}
+
+type Repository interface {
+	CreateInboxItem(ctx context.Context, notification Notification) (*InboxItem, error)
+	GetInbox(ctx context.Context, userID uuid.UUID, unreadOnly bool, page *pageutils.Params) ([]*InboxItem, *pageutils.Page, error)
+	CountUnread(ctx context.Context, userID uuid.UUID) (int, error)
+	MarkRead(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+	MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error)
+	GetRecipient(ctx context.Context, userID uuid.UUID) (*Recipient, error)
+	GetPreferences(ctx context.Context, userID uuid.UUID) (*Preferences, error)
+	UpsertPreferences(ctx context.Context, userID uuid.UUID, req UpdatePreferencesReq) error
+}
+
+func NewService(repo Repository) Service {
+	return &service{
+		repo: repo,
+	}
+}
+
+func (s *service) GetInbox(ctx context.Context, userID uuid.UUID, unreadOnly bool, page *pageutils.Params) ([]*InboxItem, *pageutils.Page, error) {
+	return s.repo.GetInbox(ctx, userID, unreadOnly, page)
+}
+
+func (s *service) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
+	return s.repo.CountUnread(ctx, userID)
+}
+
+func (s *service) MarkRead(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	return s.repo.MarkRead(ctx, userID, id)
+}
+
+func (s *service) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.repo.MarkAllRead(ctx, userID)
+}
+
+func (s *service) GetPreferences(ctx context.Context, userID uuid.UUID) (*Preferences, error) {
+	return s.repo.GetPreferences(ctx, userID)
+}
+
+/**
+* Replaces the notification preferences of a user after validating them.
+**/
+func (s *service) UpdatePreferences(ctx context.Context, userID uuid.UUID, req UpdatePreferencesReq) (*Preferences, error) {
+	if err := validatePreferences(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpsertPreferences(ctx, userID, req); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetPreferences(ctx, userID)
+}
+
+func validatePreferences(req UpdatePreferencesReq) error {
+	for kind, channels := range req.Channels {
+		if !isValidKind(kind) {
+			return errorutils.Invalidf("unknown notification kind %s", kind)
+		}
+
+		seen := make(map[constants.NotificationChannel]bool, len(channels))
+		for _, channel := range channels {
+			if !isValidChannel(channel) {
+				return errorutils.Invalidf("unknown notification channel %s, must be one of 'inApp', 'email' or 'webhook'", channel)
+			}
+			if seen[channel] {
+				return errorutils.Invalidf("channel %s was provided more than once for %s", channel, kind)
+			}
+			seen[channel] = true
+		}
+	}
+
+	if (req.QuietHoursStart == nil) != (req.QuietHoursEnd == nil) {
+		return errorutils.Invalidf("quietHoursStart and quietHoursEnd must be provided together")
+	}
+
+	if req.QuietHoursStart != nil {
+		if _, err := minuteOfDay(*req.QuietHoursStart); err != nil {
+			return err
+		}
+		if _, err := minuteOfDay(*req.QuietHoursEnd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isValidKind(kind constants.NotificationKind) bool {
+	for _, valid := range constants.NotificationKinds {
+		if kind == valid {
+			return true
+		}
+	}
+	return false
+}
+
+func isValidChannel(channel constants.NotificationChannel) bool {
+	for _, valid := range constants.NotificationChannels {
+		if channel == valid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/**
+* Delivers notifications to the webhook endpoints of the user that receive the
+* "notification" event type, signed and retried by the webhooks package like every other
+* event.
+**/
+type WebhookNotifier struct {
+	webhooks NotificationWebhooks
+}
+
+type NotificationWebhooks interface {
+	EnqueueNotification(ctx context.Context, userID uuid.UUID, notificationID uuid.UUID, data interface{}) (int64, error)
+}
+
+func NewWebhookNotifier(webhooks NotificationWebhooks) *WebhookNotifier {
+	return &WebhookNotifier{
+		webhooks: webhooks,
+	}
+}
+
+type webhookPayload struct {
+	Notification
+	SentAt time.Time `json:"sentAt"`
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, recipient *Recipient, notification Notification) error {
+	queued, err := n.webhooks.EnqueueNotification(ctx, recipient.UserID, uuid.New(), webhookPayload{Notification: notification, SentAt: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	if queued == 0 {
+		return fmt.Errorf("no active webhook endpoint receives notifications")
+	}
+
+	return nil
+}
@@ -2,10 +2,14 @@ package plans
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/darkphotonKN/fireplace/internal/constants"
 	"github.com/darkphotonKN/fireplace/internal/models"
+	"github.com/darkphotonKN/fireplace/internal/utils/httputils"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -17,10 +21,12 @@ type Handler struct {
 type Service interface {
 	GetById(ctx context.Context, id uuid.UUID) (*models.Plan, error)
 	Create(ctx context.Context, req CreatePlanReq, userID uuid.UUID) (*models.Plan, error)
+	CreateWithID(ctx context.Context, id uuid.UUID, req CreatePlanReq, userID uuid.UUID) (*models.Plan, error)
 	Update(ctx context.Context, id uuid.UUID, req UpdatePlanReq, userID uuid.UUID) error
-	Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
-	GetAll(ctx context.Context, userID uuid.UUID) ([]*models.Plan, error)
-	ToggleDailyReset(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+	Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID, version *int) error
+	GetAll(ctx context.Context, userID uuid.UUID, page *pageutils.Params) ([]*models.Plan, *pageutils.Page, error)
+	ToggleDailyReset(ctx context.Context, id uuid.UUID, userID uuid.UUID, version *int) error
+	UpdateArchivePolicy(ctx context.Context, id uuid.UUID, req ArchivePolicyReq, userID uuid.UUID) error
 }
 
 func NewHandler(service Service) *Handler {
@@ -49,6 +55,7 @@ func (h *Handler) GetById(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", httputils.ETag(plan.Version))
 	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "Successfully retrieved plan.",
 		"result": plan})
 }
@@ -102,9 +109,16 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
+	req.Version, err = httputils.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid If-Match header", "error": err.Error()})
+		return
+	}
+
 	// Update the plan
 	if err := h.service.Update(c.Request.Context(), id, req, userId); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to update plan", "error": err.Error()})
+		status := writeErrorStatus(err)
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to update plan", "error": err.Error()})
 		return
 	}
 
@@ -120,13 +134,23 @@ func (h *Handler) GetAll(c *gin.Context) {
 		return
 	}
 
-	plans, err := h.service.GetAll(c.Request.Context(), userId)
+	page, err := pageutils.ParseParams(c.Query("limit"), c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid pagination parameters", "error": err.Error()})
+		return
+	}
+
+	plans, pageInfo, err := h.service.GetAll(c.Request.Context(), userId, page)
+	if errors.Is(err, pageutils.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid pagination parameters", "error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to get plans", "error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved all plans", "result": plans})
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved all plans", "result": plans, "page": pageInfo})
 }
 
 // Delete removes a plan by ID
@@ -146,9 +170,16 @@ func (h *Handler) Delete(c *gin.Context) {
 		return
 	}
 
+	version, err := httputils.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid If-Match header", "error": err.Error()})
+		return
+	}
+
 	// Delete the plan
-	if err := h.service.Delete(c.Request.Context(), id, userId); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to delete plan", "error": err.Error()})
+	if err := h.service.Delete(c.Request.Context(), id, userId, version); err != nil {
+		status := writeErrorStatus(err)
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to delete plan", "error": err.Error()})
 		return
 	}
 
@@ -168,11 +199,69 @@ func (h *Handler) ToggleDailyReset(c *gin.Context) {
 	// TODO: static now, will come from jwt in future
 	userId, err := uuid.Parse("11111111-1111-1111-1111-111111111111")
 
+	version, err := httputils.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid If-Match header", "error": err.Error()})
+		return
+	}
+
 	// Toggle daily reset
-	if err := h.service.ToggleDailyReset(c.Request.Context(), id, userId); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to toggle daily reset", "error": err.Error()})
+	if err := h.service.ToggleDailyReset(c.Request.Context(), id, userId, version); err != nil {
+		status := writeErrorStatus(err)
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to toggle daily reset", "error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully toggled daily reset"})
 }
+
+// UpdateArchivePolicy replaces the auto archive policy of a plan
+func (h *Handler) UpdateArchivePolicy(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, err := uuid.Parse("11111111-1111-1111-1111-111111111111")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to parse user ID", "error": err.Error()})
+		return
+	}
+
+	// Get plan ID from URL parameter
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": fmt.Sprintf("Error with id %s, not a valid uuid.", idParam)})
+		return
+	}
+
+	var req ArchivePolicyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid request body", "error": err.Error()})
+		return
+	}
+
+	req.Version, err = httputils.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid If-Match header", "error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateArchivePolicy(c.Request.Context(), id, req, userId); err != nil {
+		status := writeErrorStatus(err)
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to update archive policy", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully updated archive policy"})
+}
+
+// maps errors of writes guarded by If-Match to their status codes
+func writeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, constants.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, constants.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, constants.ErrInvalidInput):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
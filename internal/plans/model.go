@@ -12,5 +12,20 @@ type UpdatePlanReq struct {
 	Focus       *string `json:"focus,omitempty"`
 	Description *string `json:"description,omitempty"`
 	DailyReset  *bool   `json:"dailyReset,omitempty"`
+
+	// version the client last saw, taken from the If-Match header
+	Version *int `json:"-"`
 }
 
+/**
+* Policy for automatically archiving checklist items of a plan. A nil
+* CompletedAfterDays turns off archiving of completed longterm items, while PastScheduled
+* is left unchanged when it is not provided.
+**/
+type ArchivePolicyReq struct {
+	CompletedAfterDays *int  `json:"completedAfterDays"`
+	PastScheduled      *bool `json:"pastScheduled"`
+
+	// version the client last saw, taken from the If-Match header
+	Version *int `json:"-"`
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 
 	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/events"
 	"github.com/darkphotonKN/fireplace/internal/models"
+	"github.com/darkphotonKN/fireplace/internal/utils/dbutils"
 	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
@@ -14,6 +17,8 @@ type repository struct {
 	db *sqlx.DB
 }
 
+const planColumns = `id, user_id, name, description, focus, plan_type, daily_reset, auto_archive_completed_after_days, auto_archive_past_scheduled, version, created_at, updated_at`
+
 func NewRepository(db *sqlx.DB) Repository {
 	return &repository{db: db}
 }
@@ -28,6 +33,9 @@ func (r *repository) GetById(ctx context.Context, id uuid.UUID) (*models.Plan, e
 		focus, 
 		plan_type, 
 		daily_reset,
+		auto_archive_completed_after_days,
+		auto_archive_past_scheduled,
+		version,
 		created_at, 
 		updated_at
 	FROM plans
@@ -44,9 +52,13 @@ func (r *repository) GetById(ctx context.Context, id uuid.UUID) (*models.Plan, e
 	return &plan, nil
 }
 
+/**
+* Creates a plan along with its plan.created event.
+**/
 func (r *repository) Create(ctx context.Context, plan models.Plan) (*models.Plan, error) {
 	query := `
 	INSERT INTO plans (
+		id,
 		user_id, 
 		name, 
 		description,
@@ -54,6 +66,7 @@ func (r *repository) Create(ctx context.Context, plan models.Plan) (*models.Plan
 		plan_type,
 		daily_reset
 	) VALUES (
+		:id,
 		:user_id, 
 		:name, 
 		:description,
@@ -68,27 +81,55 @@ func (r *repository) Create(ctx context.Context, plan models.Plan) (*models.Plan
 		focus, 
 		plan_type, 
 		daily_reset,
+		auto_archive_completed_after_days,
+		auto_archive_past_scheduled,
+		version,
 		created_at, 
 		updated_at
 	`
 
-	rows, err := r.db.NamedQueryContext(ctx, query, plan)
-	if err != nil {
-		return nil, errorutils.AnalyzeDBErr(err)
-	}
-	defer rows.Close()
-
-	// Get the created plan with full details
 	var createdPlan models.Plan
-	if rows.Next() {
-		if err := rows.StructScan(&createdPlan); err != nil {
-			return nil, errorutils.AnalyzeDBErr(err)
+	err := dbutils.ExecTx(r.db, func(tx *sqlx.Tx) error {
+		rows, err := sqlx.NamedQueryContext(ctx, tx, query, plan)
+		if err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		// Get the created plan with full details
+		if !rows.Next() {
+			err := rows.Err()
+			rows.Close()
+			if err != nil {
+				return errorutils.AnalyzeDBErr(err)
+			}
+			return constants.ErrNotFound
+		}
+
+		err = rows.StructScan(&createdPlan)
+		rows.Close()
+		if err != nil {
+			return errorutils.AnalyzeDBErr(err)
 		}
+
+		return events.Enqueue(ctx, tx, events.NewEvent{
+			Type:        constants.EventPlanCreated,
+			UserID:      &createdPlan.UserID,
+			PlanID:      &createdPlan.ID,
+			AggregateID: createdPlan.ID,
+			Payload:     createdPlan,
+		})
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
 	return &createdPlan, nil
 }
 
+/**
+* Updates a plan along with its plan.updated event.
+**/
 func (r *repository) Update(ctx context.Context, id uuid.UUID, req UpdatePlanReq, userID uuid.UUID) error {
 	query := `
 	UPDATE plans SET 
@@ -97,6 +138,8 @@ func (r *repository) Update(ctx context.Context, id uuid.UUID, req UpdatePlanReq
 		focus = COALESCE(:focus, focus),
 		daily_reset = COALESCE(:daily_reset, daily_reset)
 	WHERE id = :id AND user_id = :user_id
+	AND (CAST(:version AS INTEGER) IS NULL OR version = :version)
+	RETURNING ` + planColumns + `
 	`
 
 	// Map for named parameters
@@ -107,18 +150,108 @@ func (r *repository) Update(ctx context.Context, id uuid.UUID, req UpdatePlanReq
 		"focus":       req.Focus,
 		"daily_reset": req.DailyReset,
 		"user_id":     userID,
+		"version":     req.Version,
+	}
+
+	updated := true
+	err := dbutils.ExecTx(r.db, func(tx *sqlx.Tx) error {
+		rows, err := sqlx.NamedQueryContext(ctx, tx, query, params)
+		if err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		var plan models.Plan
+		updated = rows.Next()
+		if updated {
+			err = rows.StructScan(&plan)
+		}
+		rows.Close()
+
+		if err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		if !updated {
+			return nil
+		}
+
+		return events.Enqueue(ctx, tx, events.NewEvent{
+			Type:        constants.EventPlanUpdated,
+			UserID:      &plan.UserID,
+			PlanID:      &plan.ID,
+			AggregateID: plan.ID,
+			Payload:     plan,
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if !updated && req.Version != nil {
+		return r.versionMismatchErr(ctx, id, userID)
+	}
+
+	return nil
+}
+
+/**
+* Tells apart a plan that no longer matches the expected version from one that does not
+* exist at all after a versioned write affected no rows.
+**/
+func (r *repository) versionMismatchErr(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM plans WHERE id = $1 AND user_id = $2)`, id, userID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	if exists {
+		return constants.ErrPreconditionFailed
+	}
+
+	return constants.ErrNotFound
+}
+
+/**
+* Replaces the auto archive policy of a plan.
+**/
+func (r *repository) UpdateArchivePolicy(ctx context.Context, id uuid.UUID, req ArchivePolicyReq, userID uuid.UUID) error {
+	query := `
+	UPDATE plans SET
+		auto_archive_completed_after_days = :completed_after_days,
+		auto_archive_past_scheduled = COALESCE(CAST(:past_scheduled AS BOOLEAN), auto_archive_past_scheduled)
+	WHERE id = :id AND user_id = :user_id
+	AND (CAST(:version AS INTEGER) IS NULL OR version = :version)
+	`
+
+	params := map[string]interface{}{
+		"id":                   id,
+		"completed_after_days": req.CompletedAfterDays,
+		"past_scheduled":       req.PastScheduled,
+		"user_id":              userID,
+		"version":              req.Version,
 	}
 
-	_, err := r.db.NamedExecContext(ctx, query, params)
+	result, err := r.db.NamedExecContext(ctx, query, params)
 	if err != nil {
 		return errorutils.AnalyzeDBErr(err)
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	if rowsAffected == 0 {
+		return r.versionMismatchErr(ctx, id, userID)
+	}
+
 	return nil
 }
 
-// GetAll returns all plans from the database for a specific user
-func (r *repository) GetAll(ctx context.Context, userID uuid.UUID) ([]*models.Plan, error) {
+// GetAll returns the plans of a specific user, a page at a time when page is provided
+func (r *repository) GetAll(ctx context.Context, userID uuid.UUID, page *pageutils.Params) ([]*models.Plan, *pageutils.Page, error) {
 	query := `
 	SELECT
 		id,
@@ -128,38 +261,79 @@ func (r *repository) GetAll(ctx context.Context, userID uuid.UUID) ([]*models.Pl
 		focus,
 		plan_type,
 		daily_reset,
+		auto_archive_completed_after_days,
+		auto_archive_past_scheduled,
+		version,
 		created_at,
 		updated_at
 	FROM plans
 	WHERE user_id = $1
-	ORDER BY created_at DESC
 	`
 
+	// newest plans first
+	keys := []pageutils.Key{{Column: "created_at", Desc: true}}
+
+	query, args, err := pageutils.Apply(query, []interface{}{userID}, keys, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	plans := []*models.Plan{}
-	err := r.db.SelectContext(ctx, &plans, query, userID)
+	err = r.db.SelectContext(ctx, &plans, query, args...)
 
 	if err != nil {
-		return nil, errorutils.AnalyzeDBErr(err)
+		return nil, nil, errorutils.AnalyzeDBErr(err)
 	}
 
-	return plans, nil
+	plans, pageInfo := pageutils.Paginate(plans, page, func(plan *models.Plan) (uuid.UUID, []interface{}) {
+		return plan.ID, []interface{}{plan.CreatedAt}
+	})
+
+	return plans, pageInfo, nil
 }
 
-func (r *repository) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+/**
+* Deletes a plan along with its plan.deleted event.
+**/
+func (r *repository) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID, version *int) error {
 	query := `
 	DELETE FROM plans
 	WHERE id = $1 AND user_id = $2
+	AND ($3::INTEGER IS NULL OR version = $3)
 	`
 
-	result, err := r.db.ExecContext(ctx, query, id, userID)
+	var rowsAffected int64
+	err := dbutils.ExecTx(r.db, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, query, id, userID, version)
+		if err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		// Check if any rows were affected (plan exists and belongs to user)
+		rowsAffected, err = result.RowsAffected()
+		if err != nil {
+			return errorutils.AnalyzeDBErr(err)
+		}
+
+		if rowsAffected == 0 {
+			return nil
+		}
+
+		return events.Enqueue(ctx, tx, events.NewEvent{
+			Type:        constants.EventPlanDeleted,
+			UserID:      &userID,
+			PlanID:      &id,
+			AggregateID: id,
+			Payload:     map[string]interface{}{"id": id},
+		})
+	})
+
 	if err != nil {
-		return errorutils.AnalyzeDBErr(err)
+		return err
 	}
 
-	// Check if any rows were affected (plan exists and belongs to user)
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return errorutils.AnalyzeDBErr(err)
+	if rowsAffected == 0 && version != nil {
+		return r.versionMismatchErr(ctx, id, userID)
 	}
 
 	if rowsAffected == 0 {
@@ -6,6 +6,8 @@ import (
 
 	"github.com/darkphotonKN/fireplace/internal/constants"
 	"github.com/darkphotonKN/fireplace/internal/models"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
 	"github.com/google/uuid"
 )
 
@@ -17,8 +19,9 @@ type Repository interface {
 	GetById(ctx context.Context, id uuid.UUID) (*models.Plan, error)
 	Create(ctx context.Context, plan models.Plan) (*models.Plan, error)
 	Update(ctx context.Context, id uuid.UUID, req UpdatePlanReq, userID uuid.UUID) error
-	Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
-	GetAll(ctx context.Context, userID uuid.UUID) ([]*models.Plan, error)
+	Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID, version *int) error
+	GetAll(ctx context.Context, userID uuid.UUID, page *pageutils.Params) ([]*models.Plan, *pageutils.Page, error)
+	UpdateArchivePolicy(ctx context.Context, id uuid.UUID, req ArchivePolicyReq, userID uuid.UUID) error
 }
 
 func NewService(repo Repository) Service {
@@ -32,6 +35,11 @@ func (s *service) GetById(ctx context.Context, id uuid.UUID) (*models.Plan, erro
 }
 
 func (s *service) Create(ctx context.Context, req CreatePlanReq, userID uuid.UUID) (*models.Plan, error) {
+	return s.CreateWithID(ctx, uuid.New(), req, userID)
+}
+
+// creates a plan under an id chosen by the client, used by plans created offline
+func (s *service) CreateWithID(ctx context.Context, id uuid.UUID, req CreatePlanReq, userID uuid.UUID) (*models.Plan, error) {
 
 	// default to true if its learning based, but false if its development based
 	dailyReset := true
@@ -50,6 +58,7 @@ func (s *service) Create(ctx context.Context, req CreatePlanReq, userID uuid.UUI
 		PlanType:    req.PlanType,
 		DailyReset:  dailyReset,
 	}
+	plan.ID = id
 
 	// Call repository to create the plan
 	return s.repo.Create(ctx, plan)
@@ -60,16 +69,16 @@ func (s *service) Update(ctx context.Context, id uuid.UUID, req UpdatePlanReq, u
 }
 
 // GetAll returns all plans for a specific user
-func (s *service) GetAll(ctx context.Context, userID uuid.UUID) ([]*models.Plan, error) {
-	return s.repo.GetAll(ctx, userID)
+func (s *service) GetAll(ctx context.Context, userID uuid.UUID, page *pageutils.Params) ([]*models.Plan, *pageutils.Page, error) {
+	return s.repo.GetAll(ctx, userID, page)
 }
 
-// Delete removes a plan by ID if it belongs to the specified user
-func (s *service) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
-	return s.repo.Delete(ctx, id, userID)
+// Delete removes a plan by ID if it belongs to the specified user and is still at the expected version, if any
+func (s *service) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID, version *int) error {
+	return s.repo.Delete(ctx, id, userID, version)
 }
 
-func (s *service) ToggleDailyReset(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+func (s *service) ToggleDailyReset(ctx context.Context, id uuid.UUID, userID uuid.UUID, version *int) error {
 	// get corresponding plan, check the daily reset and flip it with an update
 
 	plan, err := s.GetById(ctx, id)
@@ -83,5 +92,15 @@ func (s *service) ToggleDailyReset(ctx context.Context, id uuid.UUID, userID uui
 
 	return s.repo.Update(ctx, id, UpdatePlanReq{
 		DailyReset: &flippedResetState,
+		Version:    version,
 	}, userID)
 }
+
+// UpdateArchivePolicy sets when checklist items of a plan are archived automatically
+func (s *service) UpdateArchivePolicy(ctx context.Context, id uuid.UUID, req ArchivePolicyReq, userID uuid.UUID) error {
+	if req.CompletedAfterDays != nil && *req.CompletedAfterDays < 0 {
+		return errorutils.Invalidf("completedAfterDays must be 0 or greater")
+	}
+
+	return s.repo.UpdateArchivePolicy(ctx, id, req, userID)
+}
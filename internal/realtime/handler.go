@@ -0,0 +1,190 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/auth"
+	"github.com/darkphotonKN/fireplace/internal/events"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// comments sent while idle, so that proxies do not close the connection
+	heartbeatInterval = 25 * time.Second
+	// how long clients wait before reconnecting
+	retryMs = 3000
+	// events replayed to a resuming client, clients that missed more are told to resync
+	maxReplay = events.ReplayWindow
+)
+
+type Handler struct {
+	service Service
+}
+
+type Service interface {
+	Subscribe(userID uuid.UUID, planID *uuid.UUID) (*Client, error)
+	Unsubscribe(client *Client)
+	Replay(ctx context.Context, client *Client, afterSeq int64, limit int) ([]*Message, error)
+	CanAccessPlan(ctx context.Context, userID uuid.UUID, planID uuid.UUID) (bool, error)
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+/**
+* Issues a short lived ticket for opening a stream. EventSource cannot set headers, so the
+* ticket goes in the query string of the stream instead of a long lived credential.
+**/
+func (h *Handler) IssueTicket(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, err := uuid.Parse("11111111-1111-1111-1111-111111111111")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to parse user ID", "error": err.Error()})
+		return
+	}
+
+	ticket, err := auth.GenerateStreamTicket(userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to issue stream ticket", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"statusCode": http.StatusCreated, "message": "Successfully issued stream ticket", "result": TicketRes{
+		Ticket:    ticket,
+		ExpiresIn: int(auth.StreamTicketExpiry.Seconds()),
+	}})
+}
+
+/**
+* Streams the plan and checklist events of the user as Server-Sent Events, only the ones
+* of a single plan with ?planId=. Every event carries its sequence as id, so a client
+* that reconnects with the Last-Event-ID header (or ?lastEventId=) first receives what it
+* missed. A client that missed too much receives a resync event instead and should
+* refetch its data. The stream is opened with a ticket from IssueTicket as ?ticket=.
+**/
+func (h *Handler) Stream(c *gin.Context) {
+	userId := auth.UserID(c)
+	ctx := c.Request.Context()
+
+	var planId *uuid.UUID
+	if raw := c.Query("planId"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Incorrect uuid format."})
+			return
+		}
+
+		owned, err := h.service.CanAccessPlan(ctx, userId, parsed)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to check plan", "error": err.Error()})
+			return
+		}
+		if !owned {
+			c.JSON(http.StatusNotFound, gin.H{"statusCode": http.StatusNotFound, "message": "Plan not found"})
+			return
+		}
+		planId = &parsed
+	}
+
+	var lastEventId *int64
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("lastEventId")
+	}
+	if raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Last event id must be a non negative number"})
+			return
+		}
+		lastEventId = &parsed
+	}
+
+	// subscribing before replaying makes sure nothing falls between the two
+	client, err := h.service.Subscribe(userId, planId)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"statusCode": http.StatusServiceUnavailable, "message": "Failed to subscribe to updates", "error": err.Error()})
+		return
+	}
+	defer h.service.Unsubscribe(client)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// keeps reverse proxies such as nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", retryMs)
+
+	if lastEventId != nil && *lastEventId < client.From() {
+		missed, err := h.service.Replay(ctx, client, *lastEventId, maxReplay+1)
+		if err != nil {
+			fmt.Printf("Error replaying realtime events: %s\n", err.Error())
+			return
+		}
+
+		if len(missed) > maxReplay {
+			writeEvent(c, client.From(), "resync", gin.H{"reason": "too many missed events"})
+		} else {
+			for _, message := range missed {
+				writeMessage(c, message)
+			}
+		}
+	}
+
+	// a client can be ahead of this instance after resuming from another one
+	resumeFrom := client.From()
+	if lastEventId != nil && *lastEventId > resumeFrom {
+		resumeFrom = *lastEventId
+	}
+
+	// tells the client the stream is live, with the id to resume from
+	writeEvent(c, resumeFrom, "ready", gin.H{"planId": planId})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-client.Messages():
+			if !ok {
+				return
+			}
+			if message.Seq <= resumeFrom {
+				continue
+			}
+			writeMessage(c, message)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeMessage(c *gin.Context, message *Message) {
+	writeEvent(c, message.Seq, message.Type, message)
+}
+
+func writeEvent(c *gin.Context, id int64, event string, data interface{}) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		fmt.Printf("Error encoding realtime event %s: %s\n", event, err.Error())
+		return
+	}
+
+	fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", id, event, encoded)
+}
@@ -0,0 +1,263 @@
+package realtime
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/google/uuid"
+)
+
+const (
+	// how often the outbox is checked for new events
+	pollInterval = time.Second
+	// events read from the outbox per query
+	pollBatchSize = 500
+	// how long a missing sequence is waited for. Sequences are taken when an event is written
+	// but become visible on commit, so a gap is usually a transaction about to commit, and
+	// otherwise one that was rolled back
+	gapTimeout = 5 * time.Second
+	// messages buffered per client, clients that fall further behind are disconnected
+	clientBuffer = 256
+)
+
+type Repository interface {
+	GetLatestSeq(ctx context.Context) (int64, error)
+	GetAfter(ctx context.Context, afterSeq int64, limit int) ([]*Message, error)
+	GetUserRange(ctx context.Context, userID uuid.UUID, planID *uuid.UUID, afterSeq int64, upToSeq int64, limit int) ([]*Message, error)
+	PlanOwnedBy(ctx context.Context, userID uuid.UUID, planID uuid.UUID) (bool, error)
+}
+
+/**
+* Pushes the events of the outbox to the clients connected to this instance. A single
+* poller follows the outbox in sequence order and fans every event out to the clients of
+* its user, so every instance sees the changes made through any other instance.
+**/
+type Hub struct {
+	repo Repository
+
+	clients map[*Client]struct{}
+	// sequence of the latest event handed to clients
+	lastSeq int64
+	// when the poller first waited on the missing sequence after lastSeq
+	gapSince time.Time
+	// set once the poller found where the outbox currently ends
+	ready  bool
+	closed bool
+	mu     sync.Mutex
+
+	stop chan struct{}
+}
+
+// a connection receiving the events of a user, or of a single plan of the user
+type Client struct {
+	userID   uuid.UUID
+	planID   *uuid.UUID
+	messages chan *Message
+	// sequence of the latest event published before the client subscribed
+	from int64
+}
+
+// Messages delivers the events of the client, it is closed once the client is disconnected
+func (c *Client) Messages() <-chan *Message {
+	return c.messages
+}
+
+// From returns the sequence the live messages of the client start after
+func (c *Client) From() int64 {
+	return c.from
+}
+
+func NewHub(repo Repository) *Hub {
+	return &Hub{
+		repo:    repo,
+		clients: make(map[*Client]struct{}),
+		stop:    make(chan struct{}),
+	}
+}
+
+/**
+* Starts following the outbox from its latest event in the background. Earlier events are
+* only sent to clients that resume from them. Clients cannot subscribe until the latest
+* event was found.
+**/
+func (h *Hub) Start() {
+	go h.poll()
+}
+
+/**
+* Stops following the outbox and disconnects every client, so that their streams end
+* and do not hold up the shutdown of the server.
+**/
+func (h *Hub) Close() {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+	h.closed = true
+
+	for client := range h.clients {
+		h.disconnect(client)
+	}
+	h.mu.Unlock()
+
+	close(h.stop)
+}
+
+/**
+* Connects a client to the events of a user, of a single plan when planID is given.
+* Every event after the client's From sequence is delivered to it.
+**/
+func (h *Hub) Subscribe(userID uuid.UUID, planID *uuid.UUID) (*Client, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed || !h.ready {
+		return nil, constants.ErrRealtimeUnavailable
+	}
+
+	client := &Client{
+		userID:   userID,
+		planID:   planID,
+		messages: make(chan *Message, clientBuffer),
+		from:     h.lastSeq,
+	}
+	h.clients[client] = struct{}{}
+
+	return client, nil
+}
+
+func (h *Hub) Unsubscribe(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[client]; ok {
+		h.disconnect(client)
+	}
+}
+
+// removes a client and closes its messages, the lock must be held
+func (h *Hub) disconnect(client *Client) {
+	delete(h.clients, client)
+	close(client.messages)
+}
+
+/**
+* Returns the events of a user that a client resuming from a sequence missed, up to the
+* sequence its live messages start after.
+**/
+func (h *Hub) Replay(ctx context.Context, client *Client, afterSeq int64, limit int) ([]*Message, error) {
+	return h.repo.GetUserRange(ctx, client.userID, client.planID, afterSeq, client.from, limit)
+}
+
+func (h *Hub) CanAccessPlan(ctx context.Context, userID uuid.UUID, planID uuid.UUID) (bool, error) {
+	return h.repo.PlanOwnedBy(ctx, userID, planID)
+}
+
+func (h *Hub) poll() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-ticker.C:
+			if h.isReady() {
+				h.publishNew()
+			} else {
+				h.findLatest()
+			}
+		}
+	}
+}
+
+func (h *Hub) isReady() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.ready
+}
+
+// finds where the outbox currently ends, retried on every poll until it succeeds
+func (h *Hub) findLatest() {
+	seq, err := h.repo.GetLatestSeq(context.Background())
+	if err != nil {
+		fmt.Printf("Error finding the latest realtime event: %s\n", err.Error())
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastSeq = seq
+	h.ready = true
+}
+
+// reads the new events of the outbox and hands them to their clients, in sequence order
+func (h *Hub) publishNew() {
+	for {
+		h.mu.Lock()
+		lastSeq := h.lastSeq
+		h.mu.Unlock()
+
+		messages, err := h.repo.GetAfter(context.Background(), lastSeq, pollBatchSize)
+		if err != nil {
+			fmt.Printf("Error reading realtime events from the outbox: %s\n", err.Error())
+			return
+		}
+
+		published := h.publish(messages)
+
+		// a full batch that was published in full means there could be more
+		if len(messages) < pollBatchSize || published < len(messages) {
+			return
+		}
+	}
+}
+
+/**
+* Hands events to the clients of their user, stopping at a gap in the sequence until it
+* is filled or waited on long enough. Returns how many events were published.
+**/
+func (h *Hub) publish(messages []*Message) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+
+	for i, message := range messages {
+		if message.Seq != h.lastSeq+1 {
+			if h.gapSince.IsZero() {
+				h.gapSince = now
+			}
+			if now.Sub(h.gapSince) < gapTimeout {
+				return i
+			}
+		}
+		h.gapSince = time.Time{}
+
+		for client := range h.clients {
+			if client.userID != message.UserID {
+				continue
+			}
+			if client.planID != nil && (message.PlanID == nil || *message.PlanID != *client.planID) {
+				continue
+			}
+
+			select {
+			case client.messages <- message:
+			default:
+				// the client resumes from its last event once it reconnects
+				h.disconnect(client)
+			}
+		}
+
+		h.lastSeq = message.Seq
+	}
+
+	return len(messages)
+}
@@ -0,0 +1,25 @@
+package realtime
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// an event of the outbox as pushed to clients, its sequence is the id clients resume from
+type Message struct {
+	Seq         int64           `db:"seq" json:"-"`
+	Type        string          `db:"type" json:"type"`
+	UserID      uuid.UUID       `db:"user_id" json:"-"`
+	PlanID      *uuid.UUID      `db:"plan_id" json:"planId,omitempty"`
+	AggregateID uuid.UUID       `db:"aggregate_id" json:"aggregateId"`
+	Payload     json.RawMessage `db:"payload" json:"data"`
+	OccurredAt  time.Time       `db:"occurred_at" json:"occurredAt"`
+}
+
+// a ticket for opening a stream, expiresIn is in seconds
+type TicketRes struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int    `json:"expiresIn"`
+}
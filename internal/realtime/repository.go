@@ -0,0 +1,83 @@
+package realtime
+
+import (
+	"context"
+
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{
+		db: db,
+	}
+}
+
+const messageColumns = `seq, type, user_id, plan_id, aggregate_id, payload, occurred_at`
+
+// GetLatestSeq returns the sequence of the latest event in the outbox, 0 when it is empty
+func (r *repository) GetLatestSeq(ctx context.Context) (int64, error) {
+	var seq int64
+	if err := r.db.GetContext(ctx, &seq, `SELECT COALESCE(MAX(seq), 0) FROM outbox_events`); err != nil {
+		return 0, errorutils.AnalyzeDBErr(err)
+	}
+
+	return seq, nil
+}
+
+// GetAfter returns up to limit events of every user after a sequence, in order
+func (r *repository) GetAfter(ctx context.Context, afterSeq int64, limit int) ([]*Message, error) {
+	query := `
+	SELECT ` + messageColumns + `
+	FROM outbox_events
+	WHERE seq > $1
+	ORDER BY seq
+	LIMIT $2
+	`
+
+	messages := []*Message{}
+	if err := r.db.SelectContext(ctx, &messages, query, afterSeq, limit); err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return messages, nil
+}
+
+/**
+* Returns up to limit events of a user in the range (afterSeq, upToSeq], in order,
+* only the ones of a single plan when planID is given.
+**/
+func (r *repository) GetUserRange(ctx context.Context, userID uuid.UUID, planID *uuid.UUID, afterSeq int64, upToSeq int64, limit int) ([]*Message, error) {
+	query := `
+	SELECT ` + messageColumns + `
+	FROM outbox_events
+	WHERE user_id = $1
+	AND ($2::UUID IS NULL OR plan_id = $2)
+	AND seq > $3
+	AND seq <= $4
+	ORDER BY seq
+	LIMIT $5
+	`
+
+	messages := []*Message{}
+	if err := r.db.SelectContext(ctx, &messages, query, userID, planID, afterSeq, upToSeq, limit); err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return messages, nil
+}
+
+func (r *repository) PlanOwnedBy(ctx context.Context, userID uuid.UUID, planID uuid.UUID) (bool, error) {
+	var owned bool
+	err := r.db.GetContext(ctx, &owned, `SELECT EXISTS (SELECT 1 FROM plans WHERE id = $1 AND user_id = $2)`, planID, userID)
+	if err != nil {
+		return false, errorutils.AnalyzeDBErr(err)
+	}
+
+	return owned, nil
+}
@@ -0,0 +1,84 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+}
+
+type Service interface {
+	Search(ctx context.Context, userID uuid.UUID, params SearchParams) (*SearchResult, error)
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// Search runs a full-text search over the plans and checklist items of the user
+func (h *Handler) Search(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	params := SearchParams{
+		Query: c.Query("q"),
+	}
+
+	if planId := c.Query("planId"); planId != "" {
+		id, err := uuid.Parse(planId)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "planId must be a valid uuid"})
+			return
+		}
+		params.PlanID = &id
+	}
+
+	if scope := c.Query("scope"); scope != "" {
+		params.Scope = &scope
+	}
+
+	for name, target := range map[string]**bool{"done": &params.Done, "archived": &params.Archived} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": name + " must be either true or false"})
+			return
+		}
+		*target = &parsed
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "limit must be a number"})
+			return
+		}
+		params.Limit = parsed
+	}
+
+	result, err := h.service.Search(c.Request.Context(), userId, params)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, constants.ErrInvalidInput) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to search", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved search results", "result": result})
+}
@@ -0,0 +1,44 @@
+package search
+
+import (
+	"github.com/google/uuid"
+)
+
+/**
+* Parameters of a search. The scope, done and archived filters only apply to checklist
+* items, the plan filter limits both plans and items to a single plan.
+**/
+type SearchParams struct {
+	Query    string
+	PlanID   *uuid.UUID
+	Scope    *string
+	Done     *bool
+	Archived *bool
+	Limit    int
+}
+
+type PlanResult struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	Focus     string    `db:"focus" json:"focus"`
+	Highlight string    `db:"highlight" json:"highlight"`
+	Rank      float64   `db:"rank" json:"rank"`
+}
+
+type ItemResult struct {
+	ID                   uuid.UUID `db:"id" json:"id"`
+	PlanID               uuid.UUID `db:"plan_id" json:"planId"`
+	PlanName             string    `db:"plan_name" json:"planName"`
+	Description          string    `db:"description" json:"description"`
+	Scope                string    `db:"scope" json:"scope"`
+	Done                 bool      `db:"done" json:"done"`
+	Archived             bool      `db:"archived" json:"archived"`
+	DescriptionHighlight string    `db:"description_highlight" json:"descriptionHighlight"`
+	NotesHighlight       string    `db:"notes_highlight" json:"notesHighlight,omitempty"`
+	Rank                 float64   `db:"rank" json:"rank"`
+}
+
+type SearchResult struct {
+	Plans []*PlanResult `json:"plans"`
+	Items []*ItemResult `json:"items"`
+}
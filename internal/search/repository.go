@@ -0,0 +1,157 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{
+		db: db,
+	}
+}
+
+// ts_headline marks matches with these control characters, they are swapped for html
+// tags once the text around them has been escaped
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+var headlineOptions = fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=30, MinWords=10, MaxFragments=2", highlightStart, highlightStop)
+
+// words shorter than this are too short for trigram matching to be useful
+const minTrigramQueryLength = 3
+
+/**
+* Searches the plans of a user. Plans match on their full-text document, or on partial
+* words of their name and focus through the trigram indexes.
+**/
+func (r *repository) SearchPlans(ctx context.Context, userID uuid.UUID, params SearchParams) ([]*PlanResult, error) {
+	query := `
+	WITH search AS (
+		SELECT websearch_to_tsquery('english', $2) AS tsq
+	)
+	SELECT
+		p.id,
+		p.name,
+		p.focus,
+		ts_headline('english', p.name || ' - ' || p.focus || ' ' || COALESCE(p.description, ''), search.tsq, $3) AS highlight,
+		GREATEST(ts_rank_cd(p.search_vector, search.tsq), word_similarity($2, p.name), word_similarity($2, p.focus) * 0.5)::FLOAT8 AS rank
+	FROM plans p, search
+	WHERE p.user_id = $1
+	AND (
+		p.search_vector @@ search.tsq
+		OR ($4 AND (p.name ILIKE $5 OR p.focus ILIKE $5))
+	)`
+
+	args := []interface{}{userID, params.Query, headlineOptions, useTrigram(params.Query), likePattern(params.Query)}
+
+	if params.PlanID != nil {
+		args = append(args, *params.PlanID)
+		query += fmt.Sprintf(`
+	AND p.id = $%d`, len(args))
+	}
+
+	args = append(args, params.Limit)
+	query += fmt.Sprintf(`
+	ORDER BY rank DESC, p.name ASC
+	LIMIT $%d`, len(args))
+
+	plans := []*PlanResult{}
+	err := r.db.SelectContext(ctx, &plans, query, args...)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return plans, nil
+}
+
+/**
+* Searches the checklist items across all plans of a user, applying the item filters.
+**/
+func (r *repository) SearchItems(ctx context.Context, userID uuid.UUID, params SearchParams) ([]*ItemResult, error) {
+	query := `
+	WITH search AS (
+		SELECT websearch_to_tsquery('english', $2) AS tsq
+	)
+	SELECT
+		ci.id,
+		ci.plan_id,
+		p.name AS plan_name,
+		ci.description,
+		ci.scope,
+		ci.done,
+		ci.archived,
+		ts_headline('english', ci.description, search.tsq, $3) AS description_highlight,
+		CASE WHEN ci.notes IS NULL OR NOT (to_tsvector('english', ci.notes) @@ search.tsq) THEN ''
+			ELSE ts_headline('english', ci.notes, search.tsq, $3)
+		END AS notes_highlight,
+		GREATEST(ts_rank_cd(ci.search_vector, search.tsq), word_similarity($2, ci.description))::FLOAT8 AS rank
+	FROM checklist_items ci
+	JOIN plans p ON ci.plan_id = p.id, search
+	WHERE p.user_id = $1
+	AND ci.deleted_at IS NULL
+	AND (
+		ci.search_vector @@ search.tsq
+		OR ($4 AND ci.description ILIKE $5)
+	)`
+
+	args := []interface{}{userID, params.Query, headlineOptions, useTrigram(params.Query), likePattern(params.Query)}
+
+	if params.PlanID != nil {
+		args = append(args, *params.PlanID)
+		query += fmt.Sprintf(`
+	AND ci.plan_id = $%d`, len(args))
+	}
+
+	if params.Scope != nil {
+		args = append(args, *params.Scope)
+		query += fmt.Sprintf(`
+	AND ci.scope = $%d`, len(args))
+	}
+
+	if params.Done != nil {
+		args = append(args, *params.Done)
+		query += fmt.Sprintf(`
+	AND ci.done = $%d`, len(args))
+	}
+
+	if params.Archived != nil {
+		args = append(args, *params.Archived)
+		query += fmt.Sprintf(`
+	AND ci.archived = $%d`, len(args))
+	}
+
+	args = append(args, params.Limit)
+	query += fmt.Sprintf(`
+	ORDER BY rank DESC, ci.sequence ASC
+	LIMIT $%d`, len(args))
+
+	items := []*ItemResult{}
+	err := r.db.SelectContext(ctx, &items, query, args...)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return items, nil
+}
+
+func useTrigram(query string) bool {
+	return len([]rune(query)) >= minTrigramQueryLength
+}
+
+// builds a contains pattern, escaping the wildcards of LIKE in the user input
+func likePattern(query string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + escaper.Replace(query) + "%"
+}
@@ -0,0 +1,97 @@
+package search
+
+import (
+	"context"
+	"html"
+	"strings"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+	maxQueryLength     = 200
+)
+
+type service struct {
+	repo Repository
+}
+
+type Repository interface {
+	SearchPlans(ctx context.Context, userID uuid.UUID, params SearchParams) ([]*PlanResult, error)
+	SearchItems(ctx context.Context, userID uuid.UUID, params SearchParams) ([]*ItemResult, error)
+}
+
+func NewService(repo Repository) Service {
+	return &service{
+		repo: repo,
+	}
+}
+
+/**
+* Searches the plans and checklist items of a user, best matches first. Matched words
+* are wrapped in <mark> tags in the highlights, everything else in them is html escaped.
+**/
+func (s *service) Search(ctx context.Context, userID uuid.UUID, params SearchParams) (*SearchResult, error) {
+	params.Query = strings.TrimSpace(params.Query)
+
+	if params.Query == "" {
+		return nil, errorutils.Invalidf("search query is required")
+	}
+
+	if len(params.Query) > maxQueryLength {
+		return nil, errorutils.Invalidf("search query cannot be longer than %d characters", maxQueryLength)
+	}
+
+	if params.Scope != nil {
+		if err := constants.ValidateScope(*params.Scope); err != nil {
+			return nil, err
+		}
+	}
+
+	if params.Limit <= 0 {
+		params.Limit = defaultSearchLimit
+	}
+	if params.Limit > maxSearchLimit {
+		params.Limit = maxSearchLimit
+	}
+
+	result := &SearchResult{
+		Plans: []*PlanResult{},
+	}
+
+	// item only filters leave plans out of the results
+	if params.Scope == nil && params.Done == nil && params.Archived == nil {
+		plans, err := s.repo.SearchPlans(ctx, userID, params)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, plan := range plans {
+			plan.Highlight = markHighlights(plan.Highlight)
+		}
+		result.Plans = plans
+	}
+
+	items, err := s.repo.SearchItems(ctx, userID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		item.DescriptionHighlight = markHighlights(item.DescriptionHighlight)
+		item.NotesHighlight = markHighlights(item.NotesHighlight)
+	}
+	result.Items = items
+
+	return result, nil
+}
+
+// escapes a headline and turns the match markers into <mark> tags
+func markHighlights(headline string) string {
+	escaped := html.EscapeString(headline)
+	return strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>").Replace(escaped)
+}
@@ -0,0 +1,250 @@
+package timetracking
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+}
+
+type Service interface {
+	StartTimer(ctx context.Context, userID uuid.UUID, planID uuid.UUID, itemID uuid.UUID, req StartTimerReq) (*TimeEntry, error)
+	StartPomodoro(ctx context.Context, userID uuid.UUID, planID uuid.UUID, itemID uuid.UUID, req StartPomodoroReq) (*TimeEntry, error)
+	StopTimer(ctx context.Context, userID uuid.UUID) (*TimeEntry, error)
+	GetRunning(ctx context.Context, userID uuid.UUID) (*TimeEntry, error)
+	CreateManualEntry(ctx context.Context, userID uuid.UUID, planID uuid.UUID, itemID uuid.UUID, req CreateManualEntryReq) (*TimeEntry, error)
+	GetAllByItemId(ctx context.Context, itemID uuid.UUID) ([]*TimeEntry, error)
+	Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+	GetPlanTotals(ctx context.Context, planID uuid.UUID, period string, from *time.Time, to *time.Time) ([]*PeriodTotal, error)
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// StartTimer starts a running timer on a checklist item
+func (h *Handler) StartTimer(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	planId, itemId, ok := parseItemParams(c)
+	if !ok {
+		return
+	}
+
+	var req StartTimerReq
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid request body", "error": err.Error()})
+		return
+	}
+
+	entry, err := h.service.StartTimer(c.Request.Context(), userId, planId, itemId, req)
+	if err != nil {
+		c.JSON(writeErrorStatus(err), gin.H{"statusCode": writeErrorStatus(err), "message": "Failed to start timer", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"statusCode": http.StatusCreated, "message": "Successfully started timer", "result": entry})
+}
+
+// StartPomodoro starts a pomodoro session on a checklist item
+func (h *Handler) StartPomodoro(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	planId, itemId, ok := parseItemParams(c)
+	if !ok {
+		return
+	}
+
+	var req StartPomodoroReq
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid request body", "error": err.Error()})
+		return
+	}
+
+	entry, err := h.service.StartPomodoro(c.Request.Context(), userId, planId, itemId, req)
+	if err != nil {
+		c.JSON(writeErrorStatus(err), gin.H{"statusCode": writeErrorStatus(err), "message": "Failed to start pomodoro session", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"statusCode": http.StatusCreated, "message": "Successfully started pomodoro session", "result": entry})
+}
+
+// StopTimer stops the running timer of the user
+func (h *Handler) StopTimer(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	entry, err := h.service.StopTimer(c.Request.Context(), userId)
+	if errors.Is(err, constants.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"statusCode": http.StatusNotFound, "message": "No timer is currently running"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to stop timer", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully stopped timer", "result": entry})
+}
+
+// GetRunning returns the running timer of the user, if any
+func (h *Handler) GetRunning(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	entry, err := h.service.GetRunning(c.Request.Context(), userId)
+	if errors.Is(err, constants.ErrNotFound) {
+		c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "No timer is currently running", "result": nil})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to get running timer", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved running timer", "result": entry})
+}
+
+// CreateManualEntry logs time on a checklist item without a timer
+func (h *Handler) CreateManualEntry(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	planId, itemId, ok := parseItemParams(c)
+	if !ok {
+		return
+	}
+
+	var req CreateManualEntryReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid request body", "error": err.Error()})
+		return
+	}
+
+	entry, err := h.service.CreateManualEntry(c.Request.Context(), userId, planId, itemId, req)
+	if err != nil {
+		c.JSON(writeErrorStatus(err), gin.H{"statusCode": writeErrorStatus(err), "message": "Failed to create time entry", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"statusCode": http.StatusCreated, "message": "Successfully created time entry", "result": entry})
+}
+
+// GetAllByItem returns all time entries logged on a checklist item
+func (h *Handler) GetAllByItem(c *gin.Context) {
+	_, itemId, ok := parseItemParams(c)
+	if !ok {
+		return
+	}
+
+	entries, err := h.service.GetAllByItemId(c.Request.Context(), itemId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to get time entries", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved time entries", "result": entries})
+}
+
+// Delete removes a time entry
+func (h *Handler) Delete(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	idParam := c.Param("entry_id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid ID format"})
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id, userId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to delete time entry", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully deleted time entry", "result": constants.UpdateStatusSuccess})
+}
+
+// GetPlanTotals returns the time logged on a plan per day or week
+func (h *Handler) GetPlanTotals(c *gin.Context) {
+	planIdParam := c.Param("id")
+	planId, err := uuid.Parse(planIdParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Incorrect uuid format."})
+		return
+	}
+
+	period := c.DefaultQuery("period", string(constants.PeriodDay))
+
+	var fromPtr, toPtr *time.Time
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "from must be an RFC3339 datetime"})
+			return
+		}
+		fromPtr = &t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "to must be an RFC3339 datetime"})
+			return
+		}
+		toPtr = &t
+	}
+
+	totals, err := h.service.GetPlanTotals(c.Request.Context(), planId, period, fromPtr, toPtr)
+	if err != nil {
+		c.JSON(writeErrorStatus(err), gin.H{"statusCode": writeErrorStatus(err), "message": "Failed to get time totals", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved time totals", "result": totals})
+}
+
+/**
+* Parses the plan and checklist item ids from the route, responding with a bad request
+* if either of them is malformed.
+**/
+func parseItemParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	planId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Incorrect uuid format provided plan id in the param."})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	itemId, err := uuid.Parse(c.Param("checklist_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Incorrect uuid format provided checklist id in the param."})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return planId, itemId, true
+}
+
+func writeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, constants.ErrTimerRunning):
+		return http.StatusConflict
+	case errors.Is(err, constants.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, constants.ErrInvalidInput):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
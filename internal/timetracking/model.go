@@ -0,0 +1,46 @@
+package timetracking
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TimeEntry struct {
+	ID              uuid.UUID  `db:"id" json:"id"`
+	UserID          uuid.UUID  `db:"user_id" json:"userId"`
+	PlanID          uuid.UUID  `db:"plan_id" json:"planId"`
+	ChecklistItemID uuid.UUID  `db:"checklist_item_id" json:"checklistItemId"`
+	Kind            string     `db:"kind" json:"kind"`
+	StartedAt       time.Time  `db:"started_at" json:"startedAt"`
+	EndedAt         *time.Time `db:"ended_at" json:"endedAt,omitempty"`
+	PlannedMinutes  *int       `db:"planned_minutes" json:"plannedMinutes,omitempty"`
+	BreakMinutes    *int       `db:"break_minutes" json:"breakMinutes,omitempty"`
+	Note            *string    `db:"note" json:"note,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+type StartTimerReq struct {
+	Note *string `json:"note,omitempty"`
+}
+
+type StartPomodoroReq struct {
+	WorkMinutes  *int    `json:"workMinutes,omitempty"`
+	BreakMinutes *int    `json:"breakMinutes,omitempty"`
+	Note         *string `json:"note,omitempty"`
+}
+
+type CreateManualEntryReq struct {
+	StartedAt time.Time `json:"startedAt" binding:"required"`
+	// either an end time or a duration has to be provided
+	EndedAt         *time.Time `json:"endedAt,omitempty"`
+	DurationMinutes *int       `json:"durationMinutes,omitempty"`
+	Note            *string    `json:"note,omitempty"`
+}
+
+type PeriodTotal struct {
+	PeriodStart  string `db:"period_start" json:"periodStart"`
+	TotalSeconds int64  `db:"total_seconds" json:"totalSeconds"`
+	EntryCount   int    `db:"entry_count" json:"entryCount"`
+}
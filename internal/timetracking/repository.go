@@ -0,0 +1,189 @@
+package timetracking
+
+import (
+	"context"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{
+		db: db,
+	}
+}
+
+const timeEntryColumns = `id, user_id, plan_id, checklist_item_id, kind, started_at, ended_at, planned_minutes, break_minutes, note, created_at, updated_at`
+
+/**
+* Creates a time entry for a checklist item. The item has to belong to the plan of the
+* entry, otherwise nothing is inserted and ErrNotFound is returned.
+**/
+func (r *repository) Create(ctx context.Context, entry TimeEntry) (*TimeEntry, error) {
+	query := `
+	INSERT INTO time_entries (user_id, plan_id, checklist_item_id, kind, started_at, ended_at, planned_minutes, break_minutes, note)
+	SELECT :user_id, plan_id, id, :kind, :started_at, :ended_at, :planned_minutes, :break_minutes, :note
+	FROM checklist_items
+	WHERE id = :checklist_item_id
+	AND plan_id = :plan_id
+	AND deleted_at IS NULL
+	RETURNING ` + timeEntryColumns
+
+	rows, err := r.db.NamedQueryContext(ctx, query, entry)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+	defer rows.Close()
+
+	var created TimeEntry
+	if rows.Next() {
+		if err := rows.StructScan(&created); err != nil {
+			return nil, errorutils.AnalyzeDBErr(err)
+		}
+	} else {
+		// errors raised by the insert, such as unique violations, only surface here
+		if err := rows.Err(); err != nil {
+			return nil, errorutils.AnalyzeDBErr(err)
+		}
+		return nil, constants.ErrNotFound
+	}
+
+	return &created, nil
+}
+
+func (r *repository) GetRunning(ctx context.Context, userID uuid.UUID) (*TimeEntry, error) {
+	query := `
+	SELECT ` + timeEntryColumns + `
+	FROM time_entries
+	WHERE user_id = $1
+	AND ended_at IS NULL
+	`
+
+	var entry TimeEntry
+	err := r.db.GetContext(ctx, &entry, query, userID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &entry, nil
+}
+
+/**
+* Stops the running timer of a user and returns the finished entry.
+**/
+func (r *repository) StopRunning(ctx context.Context, userID uuid.UUID, endedAt time.Time) (*TimeEntry, error) {
+	query := `
+	UPDATE time_entries
+	SET ended_at = GREATEST(started_at, $2)
+	WHERE user_id = $1
+	AND ended_at IS NULL
+	RETURNING ` + timeEntryColumns
+
+	var entry TimeEntry
+	err := r.db.GetContext(ctx, &entry, query, userID, endedAt)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &entry, nil
+}
+
+/**
+* Ends pomodoro sessions that ran past their planned length at the time they were
+* planned to end.
+**/
+func (r *repository) CloseExpiredPomodoros(ctx context.Context, userID uuid.UUID) error {
+	query := `
+	UPDATE time_entries
+	SET ended_at = started_at + make_interval(mins => planned_minutes)
+	WHERE user_id = $1
+	AND ended_at IS NULL
+	AND kind = 'pomodoro'
+	AND started_at + make_interval(mins => planned_minutes) <= CURRENT_TIMESTAMP
+	`
+
+	_, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+
+func (r *repository) GetAllByItemId(ctx context.Context, itemID uuid.UUID) ([]*TimeEntry, error) {
+	query := `
+	SELECT ` + timeEntryColumns + `
+	FROM time_entries
+	WHERE checklist_item_id = $1
+	ORDER BY started_at DESC
+	`
+
+	entries := []*TimeEntry{}
+	err := r.db.SelectContext(ctx, &entries, query, itemID)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return entries, nil
+}
+
+func (r *repository) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	query := `
+	DELETE FROM time_entries
+	WHERE id = $1 AND user_id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+
+	return errorutils.AnalyzeDBResults(err, result)
+}
+
+/**
+* Sums up the time logged on a plan per day or week of the user's timezone. Running timers
+* count up until now, pomodoros at most until their planned end even when not yet closed.
+**/
+func (r *repository) GetPlanTotals(ctx context.Context, planID uuid.UUID, period constants.TimePeriod, from time.Time, to time.Time) ([]*PeriodTotal, error) {
+	query := `
+	SELECT
+		to_char(period_start, 'YYYY-MM-DD') AS period_start,
+		COALESCE(SUM(seconds), 0)::BIGINT AS total_seconds,
+		COUNT(id) AS entry_count
+	FROM (
+		SELECT
+			time_entries.id,
+			date_trunc($2, time_entries.started_at AT TIME ZONE COALESCE(users.timezone, 'UTC')) AS period_start,
+			EXTRACT(EPOCH FROM (
+				COALESCE(
+					time_entries.ended_at,
+					CASE
+						WHEN time_entries.kind = 'pomodoro'
+						THEN LEAST(time_entries.started_at + make_interval(mins => time_entries.planned_minutes), CURRENT_TIMESTAMP)
+						ELSE CURRENT_TIMESTAMP
+					END
+				) - time_entries.started_at
+			)) AS seconds
+		FROM time_entries
+		LEFT JOIN users ON time_entries.user_id = users.id
+		WHERE time_entries.plan_id = $1
+		AND time_entries.started_at >= $3
+		AND time_entries.started_at < $4
+	) AS entries
+	GROUP BY period_start
+	ORDER BY period_start ASC
+	`
+
+	totals := []*PeriodTotal{}
+	err := r.db.SelectContext(ctx, &totals, query, planID, string(period), from, to)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return totals, nil
+}
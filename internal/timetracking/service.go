@@ -0,0 +1,205 @@
+package timetracking
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPomodoroWorkMinutes  = 25
+	defaultPomodoroBreakMinutes = 5
+	maxPomodoroMinutes          = 240
+	defaultTotalsDays           = 30
+	defaultTotalsWeeks          = 12
+)
+
+type service struct {
+	repo Repository
+}
+
+type Repository interface {
+	Create(ctx context.Context, entry TimeEntry) (*TimeEntry, error)
+	GetRunning(ctx context.Context, userID uuid.UUID) (*TimeEntry, error)
+	StopRunning(ctx context.Context, userID uuid.UUID, endedAt time.Time) (*TimeEntry, error)
+	CloseExpiredPomodoros(ctx context.Context, userID uuid.UUID) error
+	GetAllByItemId(ctx context.Context, itemID uuid.UUID) ([]*TimeEntry, error)
+	Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+	GetPlanTotals(ctx context.Context, planID uuid.UUID, period constants.TimePeriod, from time.Time, to time.Time) ([]*PeriodTotal, error)
+}
+
+func NewService(repo Repository) Service {
+	return &service{
+		repo: repo,
+	}
+}
+
+/**
+* Starts a running timer on a checklist item. Only one timer can run per user at a time.
+**/
+func (s *service) StartTimer(ctx context.Context, userID uuid.UUID, planID uuid.UUID, itemID uuid.UUID, req StartTimerReq) (*TimeEntry, error) {
+	return s.start(ctx, TimeEntry{
+		UserID:          userID,
+		PlanID:          planID,
+		ChecklistItemID: itemID,
+		Kind:            string(constants.TimeEntryTimer),
+		Note:            req.Note,
+	})
+}
+
+/**
+* Starts a pomodoro session on a checklist item. The session ends by itself once its
+* work length has passed.
+**/
+func (s *service) StartPomodoro(ctx context.Context, userID uuid.UUID, planID uuid.UUID, itemID uuid.UUID, req StartPomodoroReq) (*TimeEntry, error) {
+	workMinutes := defaultPomodoroWorkMinutes
+	if req.WorkMinutes != nil {
+		workMinutes = *req.WorkMinutes
+	}
+
+	breakMinutes := defaultPomodoroBreakMinutes
+	if req.BreakMinutes != nil {
+		breakMinutes = *req.BreakMinutes
+	}
+
+	if workMinutes <= 0 || workMinutes > maxPomodoroMinutes {
+		return nil, errorutils.Invalidf("workMinutes must be between 1 and %d", maxPomodoroMinutes)
+	}
+
+	if breakMinutes < 0 || breakMinutes > maxPomodoroMinutes {
+		return nil, errorutils.Invalidf("breakMinutes must be between 0 and %d", maxPomodoroMinutes)
+	}
+
+	return s.start(ctx, TimeEntry{
+		UserID:          userID,
+		PlanID:          planID,
+		ChecklistItemID: itemID,
+		Kind:            string(constants.TimeEntryPomodoro),
+		PlannedMinutes:  &workMinutes,
+		BreakMinutes:    &breakMinutes,
+		Note:            req.Note,
+	})
+}
+
+func (s *service) start(ctx context.Context, entry TimeEntry) (*TimeEntry, error) {
+	// finished pomodoros should not block a new timer
+	if err := s.repo.CloseExpiredPomodoros(ctx, entry.UserID); err != nil {
+		return nil, err
+	}
+
+	running, err := s.repo.GetRunning(ctx, entry.UserID)
+	if err != nil && !errors.Is(err, constants.ErrNotFound) {
+		return nil, err
+	}
+
+	if running != nil {
+		return nil, constants.ErrTimerRunning
+	}
+
+	entry.StartedAt = time.Now()
+
+	created, err := s.repo.Create(ctx, entry)
+
+	// the unique running index catches timers started concurrently
+	if errors.Is(err, constants.ErrDuplicateResource) {
+		return nil, constants.ErrTimerRunning
+	}
+
+	return created, err
+}
+
+func (s *service) StopTimer(ctx context.Context, userID uuid.UUID) (*TimeEntry, error) {
+	if err := s.repo.CloseExpiredPomodoros(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.StopRunning(ctx, userID, time.Now())
+}
+
+func (s *service) GetRunning(ctx context.Context, userID uuid.UUID) (*TimeEntry, error) {
+	if err := s.repo.CloseExpiredPomodoros(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetRunning(ctx, userID)
+}
+
+/**
+* Logs time that was spent on a checklist item without running a timer.
+**/
+func (s *service) CreateManualEntry(ctx context.Context, userID uuid.UUID, planID uuid.UUID, itemID uuid.UUID, req CreateManualEntryReq) (*TimeEntry, error) {
+	endedAt := req.EndedAt
+
+	if endedAt == nil {
+		if req.DurationMinutes == nil {
+			return nil, errorutils.Invalidf("either endedAt or durationMinutes is required")
+		}
+
+		if *req.DurationMinutes <= 0 {
+			return nil, errorutils.Invalidf("durationMinutes must be greater than 0")
+		}
+
+		end := req.StartedAt.Add(time.Duration(*req.DurationMinutes) * time.Minute)
+		endedAt = &end
+	}
+
+	if !endedAt.After(req.StartedAt) {
+		return nil, errorutils.Invalidf("endedAt must be after startedAt")
+	}
+
+	if endedAt.After(time.Now()) {
+		return nil, errorutils.Invalidf("manual time entries cannot end in the future")
+	}
+
+	return s.repo.Create(ctx, TimeEntry{
+		UserID:          userID,
+		PlanID:          planID,
+		ChecklistItemID: itemID,
+		Kind:            string(constants.TimeEntryManual),
+		StartedAt:       req.StartedAt,
+		EndedAt:         endedAt,
+		Note:            req.Note,
+	})
+}
+
+func (s *service) GetAllByItemId(ctx context.Context, itemID uuid.UUID) ([]*TimeEntry, error) {
+	return s.repo.GetAllByItemId(ctx, itemID)
+}
+
+func (s *service) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	return s.repo.Delete(ctx, id, userID)
+}
+
+/**
+* Gets the time logged on a plan grouped by day or week. Defaults to the last 30 days or
+* 12 weeks when no range is provided.
+**/
+func (s *service) GetPlanTotals(ctx context.Context, planID uuid.UUID, period string, from *time.Time, to *time.Time) ([]*PeriodTotal, error) {
+	p := constants.TimePeriod(period)
+	if p != constants.PeriodDay && p != constants.PeriodWeek {
+		return nil, errorutils.Invalidf("period must be either 'day' or 'week'")
+	}
+
+	end := time.Now()
+	if to != nil {
+		end = *to
+	}
+
+	start := end.AddDate(0, 0, -defaultTotalsDays)
+	if p == constants.PeriodWeek {
+		start = end.AddDate(0, 0, -7*defaultTotalsWeeks)
+	}
+	if from != nil {
+		start = *from
+	}
+
+	if !start.Before(end) {
+		return nil, errorutils.Invalidf("from must be before to")
+	}
+
+	return s.repo.GetPlanTotals(ctx, planID, p, start, end)
+}
@@ -1,10 +1,13 @@
 package user
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/darkphotonKN/fireplace/internal/constants"
 	"github.com/darkphotonKN/fireplace/internal/models"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -17,8 +20,9 @@ type Service interface {
 	GetById(id uuid.UUID) (*models.User, error)
 	Create(user models.User) error
 	HashPassword(password string) (string, error)
-	GetAll() ([]*Response, error)
+	GetAll(page *pageutils.Params) ([]*Response, *pageutils.Page, error)
 	Login(loginReq LoginRequest) (*LoginResponse, error)
+	UpdateSettings(id uuid.UUID, req UpdateSettingsReq) error
 }
 
 func NewHandler(service Service) *Handler {
@@ -69,16 +73,53 @@ func (h *Handler) GetById(c *gin.Context) {
 		"result": user})
 }
 
+// UpdateSettings updates the timezone, week start and reset hour of a user
+func (h *Handler) UpdateSettings(c *gin.Context) {
+	idParam := c.Param("id")
+
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode:": http.StatusBadRequest, "message": fmt.Sprintf("Error with id %s, not a valid uuid.", idParam)})
+		return
+	}
+
+	var req UpdateSettingsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode:": http.StatusBadRequest, "message": fmt.Sprintf("Error with parsing payload as JSON.")})
+		return
+	}
+
+	if err := h.service.UpdateSettings(id, req); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, constants.ErrInvalidInput), errors.Is(err, constants.ErrConstraintViolation):
+			status = http.StatusBadRequest
+		case errors.Is(err, constants.ErrNoRowsAffected):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"statusCode:": status, "message": fmt.Sprintf("Error when attempting to update user settings: %s", err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "Successfully updated user settings."})
+}
+
 // gets all users with bookings
 func (h *Handler) GetAll(c *gin.Context) {
-	users, err := h.service.GetAll()
+	page, err := pageutils.ParseParams(c.Query("limit"), c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode:": http.StatusBadRequest, "message": fmt.Sprintf("Invalid pagination parameters: %s", err.Error())})
+		return
+	}
+
+	users, pageInfo, err := h.service.GetAll(page)
 
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"statusCode:": http.StatusBadRequest, "message": fmt.Sprintf("Error when attempting to get all users: %s:\n", err.Error())})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "Successfully retrieved users.", "result": users})
+	c.JSON(http.StatusOK, gin.H{"statusCode:": http.StatusOK, "message": "Successfully retrieved users.", "result": users, "page": pageInfo})
 }
 
 func (h *Handler) Login(c *gin.Context) {
@@ -21,3 +21,10 @@ type LoginRequest struct {
 	Email    string `db:"email" json:"email"`
 	Password string `db:"password" json:"password"`
 }
+
+// settings of a user, only the provided fields are updated
+type UpdateSettingsReq struct {
+	Timezone     *string `json:"timezone,omitempty"`
+	WeekStartsOn *int    `json:"weekStartsOn,omitempty"`
+	ResetHour    *int    `json:"resetHour,omitempty"`
+}
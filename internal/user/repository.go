@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/darkphotonKN/fireplace/internal/models"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
@@ -47,7 +49,7 @@ func (r *repository) GetById(id uuid.UUID) (*models.User, error) {
 	return &user, nil
 }
 
-func (r *repository) GetAll() ([]*Response, error) {
+func (r *repository) GetAll(page *pageutils.Params) ([]*Response, *pageutils.Page, error) {
 	query := `
 	SELECT 
 		users.id,
@@ -55,15 +57,27 @@ func (r *repository) GetAll() ([]*Response, error) {
 		users.email,
 		users.created_at,
 		users.updated_at
-	FROM users 
+	FROM users
+	WHERE true
 	`
 
+	keys := []pageutils.Key{{Column: "users.created_at"}}
+
+	query, args, err := pageutils.Apply(query, []interface{}{}, keys, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var users []*Response
-	if err := r.DB.Select(&users, query); err != nil {
-		return nil, err
+	if err := r.DB.Select(&users, query, args...); err != nil {
+		return nil, nil, err
 	}
 
-	return users, nil
+	users, pageInfo := pageutils.Paginate(users, page, func(user *Response) (uuid.UUID, []interface{}) {
+		return user.ID, []interface{}{user.CreatedAt}
+	})
+
+	return users, pageInfo, nil
 }
 
 func (r *repository) GetUserByEmail(email string) (*models.User, error) {
@@ -81,3 +95,32 @@ func (r *repository) GetUserByEmail(email string) (*models.User, error) {
 
 	return &user, nil
 }
+
+func (r *repository) UpdateSettings(id uuid.UUID, req UpdateSettingsReq) error {
+	query := `
+	UPDATE users SET
+		timezone = COALESCE(:timezone, timezone),
+		week_starts_on = COALESCE(:week_starts_on, week_starts_on),
+		reset_hour = COALESCE(:reset_hour, reset_hour)
+	WHERE id = :id
+	`
+
+	params := map[string]interface{}{
+		"id":             id,
+		"timezone":       req.Timezone,
+		"week_starts_on": req.WeekStartsOn,
+		"reset_hour":     req.ResetHour,
+	}
+
+	result, err := r.DB.NamedExec(query, params)
+
+	return errorutils.AnalyzeDBResults(err, result)
+}
+
+// TimezoneExists reports whether postgres knows a timezone by the name
+func (r *repository) TimezoneExists(name string) (bool, error) {
+	var exists bool
+	err := r.DB.Get(&exists, `SELECT EXISTS (SELECT 1 FROM pg_timezone_names WHERE name = $1)`, name)
+
+	return exists, errorutils.AnalyzeDBErr(err)
+}
@@ -3,10 +3,13 @@ package user
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/darkphotonKN/fireplace/internal/auth"
 	"github.com/darkphotonKN/fireplace/internal/models"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -18,8 +21,10 @@ type service struct {
 type Repository interface {
 	Create(user models.User) error
 	GetById(id uuid.UUID) (*models.User, error)
-	GetAll() ([]*Response, error)
+	GetAll(page *pageutils.Params) ([]*Response, *pageutils.Page, error)
 	GetUserByEmail(email string) (*models.User, error)
+	UpdateSettings(id uuid.UUID, req UpdateSettingsReq) error
+	TimezoneExists(name string) (bool, error)
 }
 
 func NewService(repo Repository) Service {
@@ -54,8 +59,56 @@ func (s *service) HashPassword(password string) (string, error) {
 	return string(hash), nil
 }
 
-func (s *service) GetAll() ([]*Response, error) {
-	return s.Repo.GetAll()
+/**
+* Updates the timezone, the day the week starts on and the local reset hour of a user.
+**/
+func (s *service) UpdateSettings(id uuid.UUID, req UpdateSettingsReq) error {
+	if req.Timezone != nil {
+		if err := s.validateTimezone(*req.Timezone); err != nil {
+			return err
+		}
+	}
+
+	if req.WeekStartsOn != nil && (*req.WeekStartsOn < 0 || *req.WeekStartsOn > 6) {
+		return errorutils.Invalidf("weekStartsOn must be between 0 (sunday) and 6 (saturday)")
+	}
+
+	if req.ResetHour != nil && (*req.ResetHour < 0 || *req.ResetHour > 23) {
+		return errorutils.Invalidf("resetHour must be between 0 and 23")
+	}
+
+	return s.Repo.UpdateSettings(id, req)
+}
+
+/**
+* Checks that a timezone is known to both Go and Postgres, as reminders are scheduled in Go
+* while the daily reset converts times in the database. "Local" is the timezone of the
+* server to Go and not a timezone of its own.
+**/
+func (s *service) validateTimezone(timezone string) error {
+	invalid := errorutils.Invalidf("timezone must be an IANA timezone such as 'Europe/Berlin'")
+
+	if timezone == "" || strings.EqualFold(timezone, "Local") {
+		return invalid
+	}
+
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return invalid
+	}
+
+	exists, err := s.Repo.TimezoneExists(timezone)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return invalid
+	}
+
+	return nil
+}
+
+func (s *service) GetAll(page *pageutils.Params) ([]*Response, *pageutils.Page, error) {
+	return s.Repo.GetAll(page)
 }
 
 func (s *service) Login(loginReq LoginRequest) (*LoginResponse, error) {
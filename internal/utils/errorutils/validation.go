@@ -0,0 +1,29 @@
+package errorutils
+
+import (
+	"fmt"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+)
+
+/**
+* An error about invalid input that reads as its own message but still matches
+* constants.ErrInvalidInput with errors.Is, so that callers can tell it apart from
+* failures that are worth retrying.
+**/
+type ValidationError struct {
+	message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.message
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == constants.ErrInvalidInput
+}
+
+// Invalidf creates a ValidationError with a formatted message
+func Invalidf(format string, args ...interface{}) error {
+	return &ValidationError{message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,100 @@
+package filterutils
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+)
+
+/**
+* Parses a comma separated list of json field names to include in a response, validating
+* them against the json fields of the model. The id is always included.
+**/
+func ParseFields(raw string, model interface{}) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	available := jsonFields(reflect.TypeOf(model))
+
+	fields := []string{"id"}
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || field == "id" {
+			continue
+		}
+
+		if !available[field] {
+			return nil, errorutils.Invalidf("unknown field '%s'", field)
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+/**
+* Reduces each element of a slice to the selected json fields. Without selected fields
+* the items are returned as they are.
+**/
+func SelectFields(items interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return items, nil
+	}
+
+	encoded, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+
+	selected := make([]map[string]interface{}, 0, len(decoded))
+	for _, item := range decoded {
+		reduced := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			if value, ok := item[field]; ok {
+				reduced[field] = value
+			}
+		}
+		selected = append(selected, reduced)
+	}
+
+	return selected, nil
+}
+
+// collects the json names of a struct's fields, including those of embedded structs
+func jsonFields(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	fields := make(map[string]bool)
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous {
+			for name := range jsonFields(field.Type) {
+				fields[name] = true
+			}
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = true
+	}
+
+	return fields
+}
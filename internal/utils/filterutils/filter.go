@@ -0,0 +1,332 @@
+package filterutils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/lib/pq"
+)
+
+/**
+* Filter Utilities - Helper Functions
+*
+* Parses small filter expressions such as `done:false scope:daily scheduled<7d` into
+* conditions that are appended to queries as parameterized SQL. Terms are separated by
+* spaces and combined with AND, a leading '-' negates a term and values containing spaces
+* can be wrapped in double quotes.
+**/
+
+type FieldType int
+
+const (
+	BoolField FieldType = iota
+	EnumField
+	TimeField
+	NumberField
+	TextField
+)
+
+/**
+* A field that can be filtered on. Column is the SQL column or expression the field maps
+* to, it is never taken from user input.
+**/
+type Field struct {
+	Column string
+	Type   FieldType
+	Values []string // allowed values of enum fields
+}
+
+type Operator string
+
+const (
+	OpEquals       Operator = ":"
+	OpLess         Operator = "<"
+	OpLessEqual    Operator = "<="
+	OpGreater      Operator = ">"
+	OpGreaterEqual Operator = ">="
+)
+
+// special value matching fields that are not set, e.g. scheduled:none
+const noneValue = "none"
+
+const maxTerms = 20
+
+type Condition struct {
+	Field    string
+	Operator Operator
+	Negated  bool
+	Value    interface{} // bool, string, []string, time.Time, int or nil for none
+	// end of the day for date equality on time fields
+	Until *time.Time
+}
+
+type Filter struct {
+	Conditions []Condition
+	fields     map[string]Field
+}
+
+var termPattern = regexp.MustCompile(`^(-?)([a-zA-Z]+)(:|<=|>=|<|>)(.+)$`)
+
+// relative times such as 7d, -12h or 2w
+var relativePattern = regexp.MustCompile(`^([+-]?\d+)([hdwm])$`)
+
+/**
+* Parses a filter expression against the fields that may be filtered on. Relative times
+* are resolved against now. An empty expression results in a nil filter.
+**/
+func Parse(expr string, fields map[string]Field, now time.Time) (*Filter, error) {
+	terms, err := splitTerms(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	if len(terms) > maxTerms {
+		return nil, errorutils.Invalidf("filter cannot have more than %d terms", maxTerms)
+	}
+
+	filter := &Filter{
+		fields: fields,
+	}
+
+	for _, term := range terms {
+		match := termPattern.FindStringSubmatch(term)
+		if match == nil {
+			return nil, errorutils.Invalidf("invalid filter term '%s', expected field:value or a comparison like field<value", term)
+		}
+
+		name := match[2]
+		field, ok := fields[name]
+		if !ok {
+			return nil, errorutils.Invalidf("unknown filter field '%s', filterable fields are: %s", name, strings.Join(fieldNames(fields), ", "))
+		}
+
+		condition, err := parseCondition(name, field, Operator(match[3]), match[4], now)
+		if err != nil {
+			return nil, err
+		}
+		condition.Negated = match[1] == "-"
+
+		filter.Conditions = append(filter.Conditions, condition)
+	}
+
+	return filter, nil
+}
+
+/**
+* Appends the conditions of the filter to a query that already has a WHERE clause. Values
+* are always passed as arguments, numbered after the existing ones.
+**/
+func (f *Filter) Apply(query string, args []interface{}) (string, []interface{}) {
+	if f == nil {
+		return query, args
+	}
+
+	for _, condition := range f.Conditions {
+		column := f.fields[condition.Field].Column
+
+		var clause string
+		switch value := condition.Value.(type) {
+		case nil:
+			clause = fmt.Sprintf("%s IS NULL", column)
+
+		case []string:
+			args = append(args, pq.Array(value))
+			clause = fmt.Sprintf("%s = ANY($%d)", column, len(args))
+
+		default:
+			args = append(args, value)
+			clause = fmt.Sprintf("%s %s $%d", column, sqlOperator(condition.Operator), len(args))
+
+			if f.fields[condition.Field].Type == TextField {
+				clause = fmt.Sprintf("%s ILIKE $%d", column, len(args))
+			}
+
+			if condition.Until != nil {
+				args = append(args, *condition.Until)
+				clause = fmt.Sprintf("%s >= $%d AND %s < $%d", column, len(args)-1, column, len(args))
+			}
+		}
+
+		// rows without a value match negated conditions, e.g. -scheduled<7d includes unscheduled items
+		if condition.Negated && condition.Value != nil {
+			clause = fmt.Sprintf("NOT COALESCE(%s, false)", clause)
+		} else if condition.Negated {
+			clause = fmt.Sprintf("NOT (%s)", clause)
+		}
+
+		query += fmt.Sprintf(`AND %s
+	`, clause)
+	}
+
+	return query, args
+}
+
+func parseCondition(name string, field Field, op Operator, raw string, now time.Time) (Condition, error) {
+	condition := Condition{Field: name, Operator: op}
+	value := strings.Trim(raw, `"`)
+
+	if value == noneValue && op == OpEquals && (field.Type == TimeField || field.Type == NumberField) {
+		return condition, nil
+	}
+
+	switch field.Type {
+	case BoolField:
+		if op != OpEquals {
+			return condition, errorutils.Invalidf("%s can only be filtered with ':'", name)
+		}
+
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return condition, errorutils.Invalidf("%s must be either true or false", name)
+		}
+		condition.Value = parsed
+
+	case EnumField:
+		if op != OpEquals {
+			return condition, errorutils.Invalidf("%s can only be filtered with ':'", name)
+		}
+
+		values := strings.Split(value, ",")
+		for _, v := range values {
+			if !contains(field.Values, v) {
+				return condition, errorutils.Invalidf("%s must be one of: %s", name, strings.Join(field.Values, ", "))
+			}
+		}
+		condition.Value = values
+
+	case NumberField:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return condition, errorutils.Invalidf("%s must be a whole number", name)
+		}
+		condition.Value = parsed
+
+	case TimeField:
+		parsed, isDate, err := parseTime(value, now)
+		if err != nil {
+			return condition, fmt.Errorf("invalid %s value '%s': %w", name, value, err)
+		}
+		condition.Value = parsed
+
+		// field:date matches the whole day
+		if op == OpEquals {
+			if !isDate {
+				return condition, errorutils.Invalidf("%s can only be matched with ':' against a date or 'none', use < or > for relative times", name)
+			}
+			until := parsed.AddDate(0, 0, 1)
+			condition.Until = &until
+		}
+
+	case TextField:
+		if op != OpEquals {
+			return condition, errorutils.Invalidf("%s can only be filtered with ':'", name)
+		}
+		condition.Value = "%" + escapeLike(value) + "%"
+	}
+
+	return condition, nil
+}
+
+/**
+* Parses an absolute (2006-01-02 or RFC3339) or relative (7d, -12h, 2w, 1m, now) time.
+**/
+func parseTime(value string, now time.Time) (time.Time, bool, error) {
+	if value == "now" {
+		return now, false, nil
+	}
+
+	if match := relativePattern.FindStringSubmatch(value); match != nil {
+		amount, _ := strconv.Atoi(match[1])
+
+		switch match[2] {
+		case "h":
+			return now.Add(time.Duration(amount) * time.Hour), false, nil
+		case "d":
+			return now.AddDate(0, 0, amount), false, nil
+		case "w":
+			return now.AddDate(0, 0, 7*amount), false, nil
+		case "m":
+			return now.AddDate(0, amount, 0), false, nil
+		}
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return t, true, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+
+	return time.Time{}, false, errorutils.Invalidf("expected a date, an RFC3339 datetime or a relative time like 7d")
+}
+
+// splits an expression on spaces that are not inside double quotes
+func splitTerms(expr string) ([]string, error) {
+	var terms []string
+	var current strings.Builder
+	quoted := false
+
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case (r == ' ' || r == '\t') && !quoted:
+			if current.Len() > 0 {
+				terms = append(terms, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	if quoted {
+		return nil, errorutils.Invalidf("filter has an unclosed quote")
+	}
+
+	if current.Len() > 0 {
+		terms = append(terms, current.String())
+	}
+
+	return terms, nil
+}
+
+func sqlOperator(op Operator) string {
+	if op == OpEquals {
+		return "="
+	}
+	return string(op)
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+func fieldNames(fields map[string]Field) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package filterutils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+)
+
+var testFields = map[string]Field{
+	"done":      {Column: "done", Type: BoolField},
+	"scope":     {Column: "scope", Type: EnumField, Values: []string{"daily", "weekly"}},
+	"scheduled": {Column: "scheduled_time", Type: TimeField},
+	"effort":    {Column: "effort_estimate", Type: NumberField},
+	"text":      {Column: "description", Type: TextField},
+}
+
+var testNow = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want []Condition
+	}{
+		{"empty", "", nil},
+		{"only spaces", "   ", nil},
+		{"bool", "done:false", []Condition{{Field: "done", Operator: OpEquals, Value: false}}},
+		{"negated bool", "-done:true", []Condition{{Field: "done", Operator: OpEquals, Negated: true, Value: true}}},
+		{"enum list", "scope:daily,weekly", []Condition{{Field: "scope", Operator: OpEquals, Value: []string{"daily", "weekly"}}}},
+		{"number comparison", "effort>=30", []Condition{{Field: "effort", Operator: OpGreaterEqual, Value: 30}}},
+		{"none", "scheduled:none", []Condition{{Field: "scheduled", Operator: OpEquals}}},
+		{"relative time", "scheduled<7d", []Condition{{Field: "scheduled", Operator: OpLess, Value: testNow.AddDate(0, 0, 7)}}},
+		{"negative relative time", "scheduled>-12h", []Condition{{Field: "scheduled", Operator: OpGreater, Value: testNow.Add(-12 * time.Hour)}}},
+		{
+			"date equality matches the whole day",
+			"scheduled:2026-03-12",
+			[]Condition{{
+				Field:    "scheduled",
+				Operator: OpEquals,
+				Value:    time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC),
+				Until:    timePtr(time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC)),
+			}},
+		},
+		{"quoted text with like characters", `text:"50% off_"`, []Condition{{Field: "text", Operator: OpEquals, Value: `%50\% off\_%`}}},
+		{
+			"several terms",
+			"done:false  scope:daily",
+			[]Condition{
+				{Field: "done", Operator: OpEquals, Value: false},
+				{Field: "scope", Operator: OpEquals, Value: []string{"daily"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := Parse(tt.expr, testFields, testNow)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned error: %v", tt.expr, err)
+			}
+
+			var got []Condition
+			if filter != nil {
+				got = filter.Conditions
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"unknown field", "color:red"},
+		{"not a term", "done"},
+		{"bool comparison", "done>true"},
+		{"bool value", "done:maybe"},
+		{"enum value", "scope:yearly"},
+		{"number value", "effort:lots"},
+		{"time value", "scheduled<soon"},
+		{"relative time equality", "scheduled:7d"},
+		{"text comparison", "text>abc"},
+		{"unclosed quote", `text:"abc`},
+		{"too many terms", "done:true done:true done:true done:true done:true done:true done:true done:true done:true done:true done:true done:true done:true done:true done:true done:true done:true done:true done:true done:true done:true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expr, testFields, testNow)
+			if !errors.Is(err, constants.ErrInvalidInput) {
+				t.Errorf("Parse(%q) error = %v, want an invalid input error", tt.expr, err)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	base := "SELECT * FROM checklist_items WHERE plan_id = $1\n\t"
+
+	tests := []struct {
+		name      string
+		expr      string
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{"no filter", "", base, []interface{}{"plan"}},
+		{"bool", "done:false", base + "AND done = $2\n\t", []interface{}{"plan", false}},
+		{"negated bool", "-done:true", base + "AND NOT COALESCE(done = $2, false)\n\t", []interface{}{"plan", true}},
+		{"none", "scheduled:none", base + "AND scheduled_time IS NULL\n\t", []interface{}{"plan"}},
+		{"negated none", "-scheduled:none", base + "AND NOT (scheduled_time IS NULL)\n\t", []interface{}{"plan"}},
+		{"text", "text:abc", base + "AND description ILIKE $2\n\t", []interface{}{"plan", "%abc%"}},
+		{
+			"date",
+			"scheduled:2026-03-12",
+			base + "AND scheduled_time >= $2 AND scheduled_time < $3\n\t",
+			[]interface{}{"plan", time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := Parse(tt.expr, testFields, testNow)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned error: %v", tt.expr, err)
+			}
+
+			query, args := filter.Apply(base, []interface{}{"plan"})
+			if query != tt.wantQuery {
+				t.Errorf("query = %q, want %q", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	type embedded struct {
+		CreatedAt string `json:"createdAt"`
+	}
+	type model struct {
+		embedded
+		ID       string `json:"id"`
+		Name     string `json:"name,omitempty"`
+		Internal string `json:"-"`
+	}
+
+	tests := []struct {
+		name    string
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"fields with id first", "name, createdAt", []string{"id", "name", "createdAt"}, false},
+		{"id only once", "id,name", []string{"id", "name"}, false},
+		{"hidden field", "Internal", nil, true},
+		{"unknown field", "color", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFields(tt.raw, model{})
+			if tt.wantErr {
+				if !errors.Is(err, constants.ErrInvalidInput) {
+					t.Errorf("ParseFields(%q) error = %v, want an invalid input error", tt.raw, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFields(%q) returned error: %v", tt.raw, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFields(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
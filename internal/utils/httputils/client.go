@@ -0,0 +1,56 @@
+package httputils
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+/**
+* Creates a client for calling user provided urls. It only connects to public addresses,
+* so that the urls cannot be used to reach internal services. The address is checked when
+* connecting rather than when resolving, which also covers redirects and dns rebinding.
+* Up to maxRedirects redirects are followed, with none the redirect response is returned.
+* allowPrivateAddresses lifts the address check for local development.
+**/
+func NewPublicClient(maxRedirects int, allowPrivateAddresses bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if allowPrivateAddresses {
+				return nil
+			}
+
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			ip := net.ParseIP(host)
+			if ip == nil || !IsPublicIP(ip) {
+				return fmt.Errorf("refusing to connect to non public address %s", host)
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if maxRedirects == 0 {
+				return http.ErrUseLastResponse
+			}
+			if len(via) > maxRedirects {
+				return fmt.Errorf("too many redirects")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,41 @@
+package httputils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+)
+
+/**
+* HTTP Utilities - Helper Functions
+**/
+
+// ETag builds the entity tag of a resource from its version
+func ETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+/**
+* Parses the version out of an If-Match header. A missing header or "*" matches any
+* version and results in nil. Weak tags are compared by their value.
+**/
+func ParseIfMatch(header string) (*int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	tag := strings.TrimPrefix(header, "W/")
+	if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return nil, errorutils.Invalidf("If-Match must be a single quoted ETag")
+	}
+
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil {
+		return nil, errorutils.Invalidf("If-Match does not match an ETag of this resource")
+	}
+
+	return &version, nil
+}
@@ -0,0 +1,57 @@
+package httputils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+)
+
+func TestETag(t *testing.T) {
+	if got := ETag(7); got != `"7"` {
+		t.Errorf(`ETag(7) = %s, want "7"`, got)
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    *int
+		wantErr bool
+	}{
+		{"missing", "", nil, false},
+		{"any", "*", nil, false},
+		{"any with spaces", " * ", nil, false},
+		{"version", `"3"`, intPtr(3), false},
+		{"weak version", `W/"12"`, intPtr(12), false},
+		{"round trip", ETag(42), intPtr(42), false},
+		{"unquoted", "3", nil, true},
+		{"half quoted", `"3`, nil, true},
+		{"empty tag", `""`, nil, true},
+		{"not a version", `"abc"`, nil, true},
+		{"several tags", `"1", "2"`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseIfMatch(tt.header)
+			if tt.wantErr {
+				if !errors.Is(err, constants.ErrInvalidInput) {
+					t.Errorf("ParseIfMatch(%q) error = %v, want an invalid input error", tt.header, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseIfMatch(%q) returned error: %v", tt.header, err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("ParseIfMatch(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,271 @@
+package pageutils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/google/uuid"
+)
+
+/**
+* Pagination Utilities - Helper Functions
+*
+* Keyset (cursor) pagination shared by the listing repositories. A listing describes its
+* ordering as sort keys, the row id is always added as the final tiebreaker so that the
+* order is stable. Cursors are opaque to clients and hold the sort values of the row a
+* page starts after.
+**/
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type Key struct {
+	Column   string // column or expression, never user input
+	Desc     bool
+	Nullable bool // nullable keys sort their NULLs last
+}
+
+type Params struct {
+	Limit  int
+	cursor *cursor
+}
+
+type Page struct {
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"nextCursor"`
+	PrevCursor *string `json:"prevCursor"`
+}
+
+type cursor struct {
+	Values   []interface{} `json:"v"`
+	ID       uuid.UUID     `json:"id"`
+	Backward bool          `json:"b,omitempty"`
+}
+
+/**
+* Parses the limit and cursor query parameters of a listing. The limit defaults to 50
+* and is capped at 100.
+**/
+func ParseParams(limit string, rawCursor string) (*Params, error) {
+	params := &Params{Limit: DefaultLimit}
+
+	if limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed <= 0 {
+			return nil, errorutils.Invalidf("limit must be a positive number")
+		}
+		params.Limit = min(parsed, MaxLimit)
+	}
+
+	if rawCursor != "" {
+		decoded, err := decodeCursor(rawCursor)
+		if err != nil {
+			return nil, err
+		}
+		params.cursor = decoded
+	}
+
+	return params, nil
+}
+
+/**
+* Appends the keyset condition, ordering and limit to a query that already has a WHERE
+* clause. Without params the whole listing is ordered and returned, which is what
+* internal callers use.
+**/
+func Apply(query string, args []interface{}, keys []Key, params *Params) (string, []interface{}, error) {
+	backward := false
+
+	if params != nil && params.cursor != nil {
+		c := params.cursor
+		if len(c.Values) != len(keys) {
+			return "", nil, fmt.Errorf("%w: the cursor does not belong to this sort order", ErrInvalidCursor)
+		}
+
+		backward = c.Backward
+
+		var clause string
+		clause, args = afterClause(effectiveKeys(keys, backward), c.Values, c.ID, backward, args)
+		query += fmt.Sprintf(`AND %s
+	`, clause)
+	}
+
+	query += `ORDER BY ` + orderBy(effectiveKeys(keys, backward), backward)
+
+	if params != nil {
+		// one extra row tells whether there is another page
+		args = append(args, params.Limit+1)
+		query += fmt.Sprintf(`
+	LIMIT $%d`, len(args))
+	}
+
+	return query, args, nil
+}
+
+/**
+* Trims the extra row fetched by Apply, restores the order of backward pages and builds
+* the cursors of the neighbouring pages. values returns the id and sort key values of a
+* row, in the order of the keys.
+**/
+func Paginate[T any](rows []T, params *Params, values func(row T) (uuid.UUID, []interface{})) ([]T, *Page) {
+	if params == nil {
+		return rows, nil
+	}
+
+	page := &Page{Limit: params.Limit}
+	backward := params.cursor != nil && params.cursor.Backward
+
+	hasMore := len(rows) > params.Limit
+	if hasMore {
+		rows = rows[:params.Limit]
+	}
+
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	if len(rows) == 0 {
+		return rows, page
+	}
+
+	firstID, firstValues := values(rows[0])
+	lastID, lastValues := values(rows[len(rows)-1])
+
+	// a next page exists when moving forward with more rows, or whenever we came back from it
+	if (!backward && hasMore) || backward {
+		next := encodeCursor(cursor{Values: lastValues, ID: lastID})
+		page.NextCursor = &next
+	}
+
+	if (backward && hasMore) || (!backward && params.cursor != nil) {
+		prev := encodeCursor(cursor{Values: firstValues, ID: firstID, Backward: true})
+		page.PrevCursor = &prev
+	}
+
+	return rows, page
+}
+
+// walking backwards reverses every key, including where their NULLs sort
+func effectiveKeys(keys []Key, backward bool) []Key {
+	if !backward {
+		return keys
+	}
+
+	reversed := make([]Key, len(keys))
+	for i, key := range keys {
+		reversed[i] = Key{Column: key.Column, Desc: !key.Desc, Nullable: key.Nullable}
+	}
+	return reversed
+}
+
+func orderBy(keys []Key, backward bool) string {
+	parts := make([]string, 0, len(keys)+1)
+
+	for _, key := range keys {
+		part := key.Column + " " + direction(key.Desc)
+		if key.Nullable {
+			part += " " + nullsPosition(backward)
+		}
+		parts = append(parts, part)
+	}
+
+	return strings.Join(append(parts, "id "+direction(backward)), ", ")
+}
+
+/**
+* Builds the condition matching rows that sort strictly after the cursor row, comparing
+* the keys lexicographically: (k1 after) OR (k1 equal AND ((k2 after) OR ...)).
+**/
+func afterClause(keys []Key, values []interface{}, id uuid.UUID, backward bool, args []interface{}) (string, []interface{}) {
+	args = append(args, id)
+	clause := fmt.Sprintf("id %s $%d", comparison(backward), len(args))
+
+	for i := len(keys) - 1; i >= 0; i-- {
+		key := keys[i]
+		nullsFirst := backward
+
+		if values[i] == nil {
+			equal := fmt.Sprintf("%s IS NULL", key.Column)
+
+			if nullsFirst {
+				// every value sorts after the leading NULLs
+				clause = fmt.Sprintf("(%s IS NOT NULL OR (%s AND %s))", key.Column, equal, clause)
+			} else {
+				clause = fmt.Sprintf("(%s AND %s)", equal, clause)
+			}
+			continue
+		}
+
+		args = append(args, values[i])
+		after := fmt.Sprintf("%s %s $%d", key.Column, comparison(key.Desc), len(args))
+		if key.Nullable && !nullsFirst {
+			after = fmt.Sprintf("(%s OR %s IS NULL)", after, key.Column)
+		}
+
+		clause = fmt.Sprintf("(%s OR (%s = $%d AND %s))", after, key.Column, len(args), clause)
+	}
+
+	return clause, args
+}
+
+func direction(desc bool) string {
+	if desc {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+func comparison(desc bool) string {
+	if desc {
+		return "<"
+	}
+	return ">"
+}
+
+func nullsPosition(backward bool) string {
+	if backward {
+		return "NULLS FIRST"
+	}
+	return "NULLS LAST"
+}
+
+func encodeCursor(c cursor) string {
+	encoded, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func decodeCursor(raw string) (*cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	// keep numbers as they were written instead of turning them into floats
+	decoder := json.NewDecoder(bytes.NewReader(decoded))
+	decoder.UseNumber()
+
+	var c cursor
+	if err := decoder.Decode(&c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	for i, value := range c.Values {
+		if number, ok := value.(json.Number); ok {
+			c.Values[i] = number.String()
+		}
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,229 @@
+package pageutils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/google/uuid"
+)
+
+func TestParseParams(t *testing.T) {
+	valid := encodeCursor(cursor{Values: []interface{}{"a"}, ID: uuid.Nil})
+
+	tests := []struct {
+		name      string
+		limit     string
+		cursor    string
+		wantLimit int
+		wantErr   error
+	}{
+		{"defaults", "", "", DefaultLimit, nil},
+		{"limit", "10", "", 10, nil},
+		{"limit above the maximum", "1000", "", MaxLimit, nil},
+		{"zero limit", "0", "", 0, constants.ErrInvalidInput},
+		{"negative limit", "-5", "", 0, constants.ErrInvalidInput},
+		{"limit not a number", "ten", "", 0, constants.ErrInvalidInput},
+		{"cursor", "", valid, DefaultLimit, nil},
+		{"cursor not base64", "", "!!!", 0, ErrInvalidCursor},
+		{"cursor not json", "", "bm90IGpzb24", 0, ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := ParseParams(tt.limit, tt.cursor)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ParseParams(%q, %q) error = %v, want %v", tt.limit, tt.cursor, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseParams(%q, %q) returned error: %v", tt.limit, tt.cursor, err)
+			}
+			if params.Limit != tt.wantLimit {
+				t.Errorf("limit = %d, want %d", params.Limit, tt.wantLimit)
+			}
+			if (params.cursor != nil) != (tt.cursor != "") {
+				t.Errorf("cursor = %+v, want one only when provided", params.cursor)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := uuid.MustParse("6b1f0c1e-3d4b-4c55-9a0e-1f2d3c4b5a69")
+
+	tests := []struct {
+		name string
+		in   cursor
+		want cursor
+	}{
+		{"text", cursor{Values: []interface{}{"b"}, ID: id}, cursor{Values: []interface{}{"b"}, ID: id}},
+		{"numbers stay exact", cursor{Values: []interface{}{9007199254740993}, ID: id}, cursor{Values: []interface{}{"9007199254740993"}, ID: id}},
+		{"null", cursor{Values: []interface{}{nil, 3}, ID: id, Backward: true}, cursor{Values: []interface{}{nil, "3"}, ID: id, Backward: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(encodeCursor(tt.in))
+			if err != nil {
+				t.Fatalf("decodeCursor returned error: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("decoded %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	id := uuid.MustParse("6b1f0c1e-3d4b-4c55-9a0e-1f2d3c4b5a69")
+	base := "SELECT * FROM items WHERE plan_id = $1\n\t"
+	keys := []Key{{Column: "due_date", Nullable: true}, {Column: "sequence"}}
+
+	tests := []struct {
+		name      string
+		params    *Params
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			"without params the whole listing is ordered",
+			nil,
+			base + "ORDER BY due_date ASC NULLS LAST, sequence ASC, id ASC",
+			[]interface{}{"plan"},
+		},
+		{
+			"first page",
+			&Params{Limit: 10},
+			base + "ORDER BY due_date ASC NULLS LAST, sequence ASC, id ASC\n\tLIMIT $2",
+			[]interface{}{"plan", 11},
+		},
+		{
+			"after a row",
+			&Params{Limit: 10, cursor: &cursor{Values: []interface{}{"2026-03-10", "4"}, ID: id}},
+			base + "AND ((due_date > $4 OR due_date IS NULL) OR (due_date = $4 AND (sequence > $3 OR (sequence = $3 AND id > $2))))\n\t" +
+				"ORDER BY due_date ASC NULLS LAST, sequence ASC, id ASC\n\tLIMIT $5",
+			[]interface{}{"plan", id, "4", "2026-03-10", 11},
+		},
+		{
+			"after a row without a value",
+			&Params{Limit: 10, cursor: &cursor{Values: []interface{}{nil, "4"}, ID: id}},
+			base + "AND (due_date IS NULL AND (sequence > $3 OR (sequence = $3 AND id > $2)))\n\t" +
+				"ORDER BY due_date ASC NULLS LAST, sequence ASC, id ASC\n\tLIMIT $4",
+			[]interface{}{"plan", id, "4", 11},
+		},
+		{
+			"before a row",
+			&Params{Limit: 10, cursor: &cursor{Values: []interface{}{"2026-03-10", "4"}, ID: id, Backward: true}},
+			base + "AND (due_date < $4 OR (due_date = $4 AND (sequence < $3 OR (sequence = $3 AND id < $2))))\n\t" +
+				"ORDER BY due_date DESC NULLS FIRST, sequence DESC, id DESC\n\tLIMIT $5",
+			[]interface{}{"plan", id, "4", "2026-03-10", 11},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := Apply(base, []interface{}{"plan"}, keys, tt.params)
+			if err != nil {
+				t.Fatalf("Apply returned error: %v", err)
+			}
+			if query != tt.wantQuery {
+				t.Errorf("query =\n%s\nwant\n%s", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestApplyCursorOfAnotherSortOrder(t *testing.T) {
+	params := &Params{Limit: 10, cursor: &cursor{Values: []interface{}{"a", "b"}, ID: uuid.Nil}}
+
+	_, _, err := Apply("SELECT 1 WHERE true\n\t", nil, []Key{{Column: "sequence"}}, params)
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Apply error = %v, want %v", err, ErrInvalidCursor)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	type row struct {
+		id  uuid.UUID
+		seq int
+	}
+
+	rows := func(seqs ...int) []row {
+		result := make([]row, 0, len(seqs))
+		for _, seq := range seqs {
+			result = append(result, row{id: uuid.New(), seq: seq})
+		}
+		return result
+	}
+
+	values := func(r row) (uuid.UUID, []interface{}) {
+		return r.id, []interface{}{r.seq}
+	}
+
+	tests := []struct {
+		name     string
+		rows     []row
+		params   *Params
+		wantSeqs []int
+		wantNext bool
+		wantPrev bool
+	}{
+		{"first and only page", rows(1, 2), &Params{Limit: 2}, []int{1, 2}, false, false},
+		{"first page with more", rows(1, 2, 3), &Params{Limit: 2}, []int{1, 2}, true, false},
+		{"middle page", rows(3, 4, 5), &Params{Limit: 2, cursor: &cursor{}}, []int{3, 4}, true, true},
+		{"last page", rows(5), &Params{Limit: 2, cursor: &cursor{}}, []int{5}, false, true},
+		{"backward page with more", rows(4, 3, 2), &Params{Limit: 2, cursor: &cursor{Backward: true}}, []int{3, 4}, true, true},
+		{"backward to the first page", rows(2, 1), &Params{Limit: 2, cursor: &cursor{Backward: true}}, []int{1, 2}, true, false},
+		{"empty", rows(), &Params{Limit: 2}, []int{}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, page := Paginate(tt.rows, tt.params, values)
+
+			seqs := make([]int, 0, len(got))
+			for _, r := range got {
+				seqs = append(seqs, r.seq)
+			}
+			if !reflect.DeepEqual(seqs, tt.wantSeqs) {
+				t.Errorf("rows = %v, want %v", seqs, tt.wantSeqs)
+			}
+			if (page.NextCursor != nil) != tt.wantNext {
+				t.Errorf("next cursor = %v, want one: %v", page.NextCursor, tt.wantNext)
+			}
+			if (page.PrevCursor != nil) != tt.wantPrev {
+				t.Errorf("prev cursor = %v, want one: %v", page.PrevCursor, tt.wantPrev)
+			}
+		})
+	}
+}
+
+func TestPaginateCursorsContinueAtTheEdges(t *testing.T) {
+	first := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	last := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	values := func(id uuid.UUID) (uuid.UUID, []interface{}) {
+		if id == first {
+			return id, []interface{}{1}
+		}
+		return id, []interface{}{2}
+	}
+
+	_, page := Paginate([]uuid.UUID{first, last, uuid.New()}, &Params{Limit: 2, cursor: &cursor{}}, values)
+
+	next, err := decodeCursor(*page.NextCursor)
+	if err != nil || next.ID != last || next.Backward || next.Values[0] != "2" {
+		t.Errorf("next cursor = %+v, %v, want it to start after the last row", next, err)
+	}
+
+	prev, err := decodeCursor(*page.PrevCursor)
+	if err != nil || prev.ID != first || !prev.Backward || prev.Values[0] != "1" {
+		t.Errorf("prev cursor = %+v, %v, want it to end before the first row", prev, err)
+	}
+}
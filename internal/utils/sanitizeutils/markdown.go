@@ -0,0 +1,259 @@
+package sanitizeutils
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+/**
+* Sanitization Utilities - Helper Functions
+**/
+
+// html elements that are removed along with everything inside of them
+var droppedWithContent = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"object":   true,
+	"embed":    true,
+	"applet":   true,
+	"noscript": true,
+	"template": true,
+	"svg":      true,
+	"math":     true,
+	"textarea": true,
+	"select":   true,
+	"title":    true,
+}
+
+// html elements that are removed but whose content is kept
+var dropped = map[string]bool{
+	"html":     true,
+	"head":     true,
+	"body":     true,
+	"link":     true,
+	"meta":     true,
+	"base":     true,
+	"form":     true,
+	"input":    true,
+	"button":   true,
+	"frame":    true,
+	"frameset": true,
+	"option":   true,
+}
+
+// html elements that are allowed in notes and the attributes each of them may keep
+var allowed = map[string][]string{
+	"a":          {"href", "title"},
+	"img":        {"src", "alt", "title"},
+	"b":          nil,
+	"i":          nil,
+	"em":         nil,
+	"strong":     nil,
+	"s":          nil,
+	"del":        nil,
+	"code":       nil,
+	"pre":        nil,
+	"kbd":        nil,
+	"mark":       nil,
+	"sub":        nil,
+	"sup":        nil,
+	"br":         nil,
+	"hr":         nil,
+	"p":          nil,
+	"blockquote": nil,
+	"ul":         nil,
+	"ol":         nil,
+	"li":         nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"table":      nil,
+	"thead":      nil,
+	"tbody":      nil,
+	"tr":         nil,
+	"th":         nil,
+	"td":         nil,
+	"details":    nil,
+	"summary":    nil,
+}
+
+// markdown links and images, e.g. [text](url) or ![alt](url "title")
+var markdownLink = regexp.MustCompile(`(!?\[[^\]]*\]\()\s*<?([^\s()>]*(?:\([^\s()]*\))?[^\s()>]*)>?`)
+
+// reference link definitions, e.g. [ref]: url "title", the url may start on the next line
+var markdownLinkDefinition = regexp.MustCompile(`(?m)^( {0,3}\[[^\]]+\]:[ \t]*\n?[ \t]*)(<[^<>\n]*>|\S+)`)
+
+// autolinks, e.g. <https://example.com> or <someone@example.com>
+var markdownAutolink = regexp.MustCompile(`^<(?:[a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^\s<>]*|[^\s<>@]+@[^\s<>@]+)>$`)
+
+// backslash escapes markdown renderers remove from urls, e.g. javascript\:
+var markdownEscape = regexp.MustCompile("\\\\([!-/:-@\\[-`{-~])")
+
+/**
+* Sanitizes user provided markdown before it is stored. Dangerous html elements and
+* attributes are removed, allowed elements are rebuilt with only their safe attributes,
+* and links using unsafe schemes (javascript:, data:, ...) are neutralized, be they
+* inline links, autolinks or reference link definitions.
+*
+* Anything that merely looks like a tag but is not html (e.g. generics like List<String>)
+* is escaped, so that technical notes still read the same once rendered.
+**/
+func SanitizeMarkdown(input string) string {
+	tokenizer := html.NewTokenizer(strings.NewReader(input))
+
+	var out bytes.Buffer
+	skipDepth := 0
+	skipTag := ""
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+
+		// copy the raw bytes first as reading the token lowercases tag names in place
+		raw := append([]byte(nil), tokenizer.Raw()...)
+		token := tokenizer.Token()
+
+		// inside an element that is dropped with its content
+		if skipDepth > 0 {
+			switch {
+			case tokenType == html.StartTagToken && token.Data == skipTag:
+				skipDepth++
+			case tokenType == html.EndTagToken && token.Data == skipTag:
+				skipDepth--
+			}
+			continue
+		}
+
+		switch tokenType {
+		case html.TextToken:
+			out.Write(raw)
+
+		case html.CommentToken, html.DoctypeToken:
+			// comments can hide conditional markup, drop them
+
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			name := token.Data
+
+			if droppedWithContent[name] {
+				if tokenType == html.StartTagToken {
+					skipDepth = 1
+					skipTag = name
+				}
+				continue
+			}
+
+			if dropped[name] {
+				continue
+			}
+
+			attrs, ok := allowed[name]
+			if !ok {
+				// safe autolinks are kept as written, anything else that is not an html
+				// element we know of is escaped so that it renders as text
+				if markdownAutolink.Match(raw) && IsSafeURL(string(raw[1:len(raw)-1])) {
+					out.Write(raw)
+				} else {
+					out.WriteString(html.EscapeString(string(raw)))
+				}
+				continue
+			}
+
+			out.WriteString(rebuildTag(tokenType, token, attrs))
+		}
+	}
+
+	sanitized := markdownLink.ReplaceAllStringFunc(out.String(), func(match string) string {
+		parts := markdownLink.FindStringSubmatch(match)
+		if IsSafeURL(parts[2]) {
+			return match
+		}
+		return parts[1] + "#"
+	})
+
+	return markdownLinkDefinition.ReplaceAllStringFunc(sanitized, func(match string) string {
+		parts := markdownLinkDefinition.FindStringSubmatch(match)
+		if IsSafeURL(strings.TrimSuffix(strings.TrimPrefix(parts[2], "<"), ">")) {
+			return match
+		}
+		return parts[1] + "#"
+	})
+}
+
+/**
+* Checks that a url is relative or uses a scheme that is safe to render as a link.
+**/
+func IsSafeURL(rawURL string) bool {
+	trimmed := strings.TrimSpace(rawURL)
+	if trimmed == "" {
+		return true
+	}
+
+	// markdown renderers decode entities and backslash escapes in urls, and browsers
+	// ignore whitespace and control characters inside schemes
+	decoded := html.UnescapeString(markdownEscape.ReplaceAllString(trimmed, "$1"))
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, decoded)
+
+	parsed, err := url.Parse(cleaned)
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(parsed.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+
+	return false
+}
+
+func rebuildTag(tokenType html.TokenType, token html.Token, allowedAttrs []string) string {
+	if tokenType == html.EndTagToken {
+		return "</" + token.Data + ">"
+	}
+
+	var b strings.Builder
+	b.WriteString("<" + token.Data)
+
+	for _, attr := range token.Attr {
+		if !contains(allowedAttrs, attr.Key) {
+			continue
+		}
+
+		if (attr.Key == "href" || attr.Key == "src") && !IsSafeURL(attr.Val) {
+			continue
+		}
+
+		b.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+
+	if tokenType == html.SelfClosingTagToken {
+		b.WriteString(" /")
+	}
+	b.WriteString(">")
+
+	return b.String()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
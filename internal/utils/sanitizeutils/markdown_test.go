@@ -0,0 +1,69 @@
+package sanitizeutils
+
+import "testing"
+
+func TestSanitizeMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain text", "just **bold** text", "just **bold** text"},
+		{"safe inline link", "[x](https://example.com)", "[x](https://example.com)"},
+		{"javascript inline link", "[x](javascript:alert(1))", "[x](#)"},
+		{"entity encoded inline link", "[x](jav&#97;script:alert(1))", "[x](#)"},
+		{"escaped inline link", "[x](javascript\\:alert(1))", "[x](#)"},
+		{"javascript image", "![x](javascript:alert(1))", "![x](#)"},
+		{"safe autolink", "<https://example.com/a?b=1>", "<https://example.com/a?b=1>"},
+		{"email autolink", "<someone@example.com>", "<someone@example.com>"},
+		{"javascript autolink", "<javascript:alert(1)>", "&lt;javascript:alert(1)&gt;"},
+		{"data autolink", "<data:text/html;base64,PHNjcmlwdD4=>", "&lt;data:text/html;base64,PHNjcmlwdD4=&gt;"},
+		{"safe reference definition", "[x][r]\n\n[r]: https://example.com", "[x][r]\n\n[r]: https://example.com"},
+		{"javascript reference definition", "[x][r]\n\n[r]: javascript:alert(1)", "[x][r]\n\n[r]: #"},
+		{"javascript reference definition on next line", "[x][r]\n\n[r]:\n  javascript:alert(1) \"t\"", "[x][r]\n\n[r]:\n  # \"t\""},
+		{"indented javascript reference definition", "   [r]: vbscript:msgbox", "   [r]: #"},
+		{"generic type", "use List<String> here", "use List&lt;String&gt; here"},
+		{"unknown element with handler", "<foo onclick=\"alert(1)\">x</foo>", "&lt;foo onclick=&#34;alert(1)&#34;&gt;x&lt;/foo&gt;"},
+		{"script", "a<script>alert(1)</script>b", "ab"},
+		{"event handler", "<b onmouseover=\"alert(1)\">x</b>", "<b>x</b>"},
+		{"javascript href", "<a href=\"javascript:alert(1)\">x</a>", "<a>x</a>"},
+		{"safe href", "<a href=\"https://example.com\" title=\"t\">x</a>", "<a href=\"https://example.com\" title=\"t\">x</a>"},
+		{"comment", "a<!-- <script> -->b", "ab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeMarkdown(tt.input); got != tt.want {
+				t.Errorf("SanitizeMarkdown(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSafeURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"", true},
+		{"/relative/path", true},
+		{"#anchor", true},
+		{"https://example.com", true},
+		{"HTTP://example.com", true},
+		{"mailto:someone@example.com", true},
+		{"javascript:alert(1)", false},
+		{"JaVaScRiPt:alert(1)", false},
+		{" java\tscript:alert(1)", false},
+		{"jav&#x61;script:alert(1)", false},
+		{"javascript&colon;alert(1)", false},
+		{"javascript\\:alert(1)", false},
+		{"data:text/html;base64,PHNjcmlwdD4=", false},
+		{"vbscript:msgbox", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsSafeURL(tt.url); got != tt.want {
+			t.Errorf("IsSafeURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
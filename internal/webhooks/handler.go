@@ -0,0 +1,219 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+}
+
+type Service interface {
+	GetEndpoints(ctx context.Context, userID uuid.UUID) ([]*Endpoint, error)
+	GetEndpoint(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Endpoint, error)
+	CreateEndpoint(ctx context.Context, userID uuid.UUID, req CreateEndpointReq) (*EndpointWithSecret, error)
+	UpdateEndpoint(ctx context.Context, userID uuid.UUID, id uuid.UUID, req UpdateEndpointReq) (*Endpoint, error)
+	RotateSecret(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*EndpointWithSecret, error)
+	DeleteEndpoint(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+	GetDeliveries(ctx context.Context, userID uuid.UUID, id uuid.UUID, status *constants.WebhookDeliveryStatus, page *pageutils.Params) ([]*Delivery, *pageutils.Page, error)
+	Ping(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Delivery, error)
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func (h *Handler) GetEndpoints(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	endpoints, err := h.service.GetEndpoints(c.Request.Context(), userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"statusCode": http.StatusInternalServerError, "message": "Failed to get webhook endpoints", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved webhook endpoints", "result": endpoints})
+}
+
+func (h *Handler) GetEndpoint(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Incorrect uuid format."})
+		return
+	}
+
+	endpoint, err := h.service.GetEndpoint(c.Request.Context(), userId, id)
+	if err != nil {
+		status := statusFor(err)
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to get webhook endpoint", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved webhook endpoint", "result": endpoint})
+}
+
+// CreateEndpoint registers a webhook endpoint, the response holds its signing secret
+func (h *Handler) CreateEndpoint(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	var req CreateEndpointReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid request body", "error": err.Error()})
+		return
+	}
+
+	endpoint, err := h.service.CreateEndpoint(c.Request.Context(), userId, req)
+	if err != nil {
+		status := statusFor(err)
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to create webhook endpoint", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"statusCode": http.StatusCreated, "message": "Successfully created webhook endpoint", "result": endpoint})
+}
+
+func (h *Handler) UpdateEndpoint(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Incorrect uuid format."})
+		return
+	}
+
+	var req UpdateEndpointReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid request body", "error": err.Error()})
+		return
+	}
+
+	endpoint, err := h.service.UpdateEndpoint(c.Request.Context(), userId, id, req)
+	if err != nil {
+		status := statusFor(err)
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to update webhook endpoint", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully updated webhook endpoint", "result": endpoint})
+}
+
+// RotateSecret replaces the signing secret of an endpoint, the response holds the new secret
+func (h *Handler) RotateSecret(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Incorrect uuid format."})
+		return
+	}
+
+	endpoint, err := h.service.RotateSecret(c.Request.Context(), userId, id)
+	if err != nil {
+		status := statusFor(err)
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to rotate webhook secret", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully rotated webhook secret", "result": endpoint})
+}
+
+func (h *Handler) DeleteEndpoint(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Incorrect uuid format."})
+		return
+	}
+
+	if err := h.service.DeleteEndpoint(c.Request.Context(), userId, id); err != nil {
+		status := statusFor(err)
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to delete webhook endpoint", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully deleted webhook endpoint"})
+}
+
+// GetDeliveries lists the delivery log of an endpoint, newest first, filtered by ?status=
+func (h *Handler) GetDeliveries(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Incorrect uuid format."})
+		return
+	}
+
+	page, err := pageutils.ParseParams(c.Query("limit"), c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Invalid pagination parameters", "error": err.Error()})
+		return
+	}
+
+	var status *constants.WebhookDeliveryStatus
+	if raw := c.Query("status"); raw != "" {
+		parsed := constants.WebhookDeliveryStatus(raw)
+		status = &parsed
+	}
+
+	deliveries, pageInfo, err := h.service.GetDeliveries(c.Request.Context(), userId, id, status, page)
+	if err != nil {
+		statusCode := statusFor(err)
+		c.JSON(statusCode, gin.H{"statusCode": statusCode, "message": "Failed to get webhook deliveries", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully retrieved webhook deliveries", "result": deliveries, "page": pageInfo})
+}
+
+// Ping sends a test event to an endpoint right away and returns the recorded delivery
+func (h *Handler) Ping(c *gin.Context) {
+	// TODO: static now, will come from jwt in future
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"statusCode": http.StatusBadRequest, "message": "Incorrect uuid format."})
+		return
+	}
+
+	delivery, err := h.service.Ping(c.Request.Context(), userId, id)
+	if err != nil {
+		status := statusFor(err)
+		c.JSON(status, gin.H{"statusCode": status, "message": "Failed to ping webhook endpoint", "error": err.Error()})
+		return
+	}
+
+	// the ping itself may have failed, which the delivery tells
+	c.JSON(http.StatusOK, gin.H{"statusCode": http.StatusOK, "message": "Successfully sent ping to webhook endpoint", "result": delivery})
+}
+
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, constants.ErrNotFound), errors.Is(err, constants.ErrNoRowsAffected):
+		return http.StatusNotFound
+	case errors.Is(err, constants.ErrInvalidInput), errors.Is(err, pageutils.ErrInvalidCursor):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
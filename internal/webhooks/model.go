@@ -0,0 +1,90 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// a url registered by a user to receive events
+type Endpoint struct {
+	ID          uuid.UUID      `db:"id" json:"id"`
+	UserID      uuid.UUID      `db:"user_id" json:"userId"`
+	URL         string         `db:"url" json:"url"`
+	Description string         `db:"description" json:"description"`
+	Secret      string         `db:"secret" json:"-"`
+	EventTypes  pq.StringArray `db:"event_types" json:"eventTypes"`
+	Active      bool           `db:"active" json:"active"`
+	CreatedAt   time.Time      `db:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updatedAt"`
+}
+
+// an endpoint along with its secret, only returned when the secret is created
+type EndpointWithSecret struct {
+	*Endpoint
+	Secret string `json:"secret"`
+}
+
+type CreateEndpointReq struct {
+	URL         string                `json:"url" binding:"required"`
+	Description string                `json:"description"`
+	EventTypes  []constants.EventType `json:"eventTypes"`
+}
+
+// fields left out are not changed
+type UpdateEndpointReq struct {
+	URL         *string                `json:"url"`
+	Description *string                `json:"description"`
+	EventTypes  *[]constants.EventType `json:"eventTypes"`
+	Active      *bool                  `json:"active"`
+}
+
+// an event sent to an endpoint, with the outcome of its latest attempt
+type Delivery struct {
+	ID             uuid.UUID       `db:"id" json:"id"`
+	EndpointID     uuid.UUID       `db:"endpoint_id" json:"endpointId"`
+	EventID        *uuid.UUID      `db:"event_id" json:"eventId"`
+	EventType      string          `db:"event_type" json:"eventType"`
+	Payload        json.RawMessage `db:"payload" json:"payload"`
+	Status         string          `db:"status" json:"status"`
+	Attempts       int             `db:"attempts" json:"attempts"`
+	NextAttemptAt  *time.Time      `db:"next_attempt_at" json:"nextAttemptAt"`
+	LastAttemptAt  *time.Time      `db:"last_attempt_at" json:"lastAttemptAt"`
+	ResponseStatus *int            `db:"response_status" json:"responseStatus"`
+	ResponseBody   *string         `db:"response_body" json:"responseBody"`
+	DurationMs     *int            `db:"duration_ms" json:"durationMs"`
+	LastError      *string         `db:"last_error" json:"lastError"`
+	DeliveredAt    *time.Time      `db:"delivered_at" json:"deliveredAt"`
+	CreatedAt      time.Time       `db:"created_at" json:"createdAt"`
+}
+
+// a delivery that is due, along with where to send it
+type dueDelivery struct {
+	Delivery
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+// the outcome of sending a delivery once
+type Attempt struct {
+	ResponseStatus *int
+	ResponseBody   *string
+	Duration       time.Duration
+	Err            error
+}
+
+/**
+* The body POSTed to endpoints. It is the same for every attempt of a delivery, so
+* receivers can tell repeated deliveries apart by id.
+**/
+type Payload struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        string          `json:"type"`
+	OccurredAt  time.Time       `json:"occurredAt"`
+	PlanID      *uuid.UUID      `json:"planId,omitempty"`
+	AggregateID *uuid.UUID      `json:"aggregateId,omitempty"`
+	Data        json.RawMessage `json:"data"`
+}
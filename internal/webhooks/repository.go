@@ -0,0 +1,285 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{
+		db: db,
+	}
+}
+
+const endpointColumns = `id, user_id, url, description, secret, event_types, active, created_at, updated_at`
+
+const deliveryColumns = `id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_attempt_at,
+	response_status, response_body, duration_ms, last_error, delivered_at, created_at`
+
+func (r *repository) CreateEndpoint(ctx context.Context, userID uuid.UUID, req CreateEndpointReq, secret string) (*Endpoint, error) {
+	query := `
+	INSERT INTO webhook_endpoints (user_id, url, description, secret, event_types)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING ` + endpointColumns
+
+	var endpoint Endpoint
+	err := r.db.GetContext(ctx, &endpoint, query, userID, req.URL, req.Description, secret, eventTypeArray(req.EventTypes))
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &endpoint, nil
+}
+
+func (r *repository) GetEndpoints(ctx context.Context, userID uuid.UUID) ([]*Endpoint, error) {
+	query := `
+	SELECT ` + endpointColumns + `
+	FROM webhook_endpoints
+	WHERE user_id = $1
+	ORDER BY created_at
+	`
+
+	endpoints := []*Endpoint{}
+	if err := r.db.SelectContext(ctx, &endpoints, query, userID); err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return endpoints, nil
+}
+
+func (r *repository) GetEndpoint(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Endpoint, error) {
+	query := `
+	SELECT ` + endpointColumns + `
+	FROM webhook_endpoints
+	WHERE id = $1 AND user_id = $2
+	`
+
+	var endpoint Endpoint
+	if err := r.db.GetContext(ctx, &endpoint, query, id, userID); err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &endpoint, nil
+}
+
+func (r *repository) UpdateEndpoint(ctx context.Context, userID uuid.UUID, id uuid.UUID, req UpdateEndpointReq) (*Endpoint, error) {
+	query := `
+	UPDATE webhook_endpoints SET
+		url = COALESCE($3, url),
+		description = COALESCE($4, description),
+		event_types = COALESCE($5::TEXT[], event_types),
+		active = COALESCE($6, active)
+	WHERE id = $1 AND user_id = $2
+	RETURNING ` + endpointColumns
+
+	var eventTypes interface{}
+	if req.EventTypes != nil {
+		eventTypes = eventTypeArray(*req.EventTypes)
+	}
+
+	var endpoint Endpoint
+	err := r.db.GetContext(ctx, &endpoint, query, id, userID, req.URL, req.Description, eventTypes, req.Active)
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &endpoint, nil
+}
+
+func (r *repository) UpdateSecret(ctx context.Context, userID uuid.UUID, id uuid.UUID, secret string) (*Endpoint, error) {
+	query := `
+	UPDATE webhook_endpoints SET
+		secret = $3
+	WHERE id = $1 AND user_id = $2
+	RETURNING ` + endpointColumns
+
+	var endpoint Endpoint
+	if err := r.db.GetContext(ctx, &endpoint, query, id, userID, secret); err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &endpoint, nil
+}
+
+// DeleteEndpoint removes an endpoint along with its delivery log
+func (r *repository) DeleteEndpoint(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	query := `
+	DELETE FROM webhook_endpoints
+	WHERE id = $1 AND user_id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+
+	return errorutils.AnalyzeDBResults(err, result)
+}
+
+/**
+* Creates a pending delivery of an event for every active endpoint of its user that
+* receives its type. Endpoints that already have a delivery of the event are skipped,
+* so handing over the same event twice is harmless.
+**/
+func (r *repository) EnqueueDeliveries(ctx context.Context, userID uuid.UUID, eventID uuid.UUID, eventType string, payload []byte) (int64, error) {
+	query := `
+	INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload)
+	SELECT id, $1, $2, $3
+	FROM webhook_endpoints
+	WHERE user_id = $4
+	AND active
+	AND (cardinality(event_types) = 0 OR $2 = ANY(event_types))
+	ON CONFLICT (endpoint_id, event_id) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query, eventID, eventType, payload, userID)
+	if err != nil {
+		return 0, errorutils.AnalyzeDBErr(err)
+	}
+
+	return result.RowsAffected()
+}
+
+// CreateSentDelivery records a delivery that was already sent, such as a ping
+func (r *repository) CreateSentDelivery(ctx context.Context, endpointID uuid.UUID, eventType string, payload []byte, attempt Attempt) (*Delivery, error) {
+	query := `
+	INSERT INTO webhook_deliveries (
+		endpoint_id, event_type, payload, status, attempts, last_attempt_at,
+		response_status, response_body, duration_ms, last_error, delivered_at
+	)
+	VALUES ($1, $2, $3, $4, 1, NOW(), $5, $6, $7, $8, CASE WHEN $4 = 'succeeded' THEN NOW() END)
+	RETURNING ` + deliveryColumns
+
+	status := constants.WebhookDeliverySucceeded
+	if attempt.Err != nil {
+		status = constants.WebhookDeliveryFailed
+	}
+
+	var delivery Delivery
+	err := r.db.GetContext(ctx, &delivery, query, endpointID, eventType, payload, string(status),
+		attempt.ResponseStatus, attempt.ResponseBody, attempt.Duration.Milliseconds(), attemptError(attempt))
+	if err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return &delivery, nil
+}
+
+/**
+* Claims up to limit pending deliveries of active endpoints that are due, oldest first.
+* Claimed deliveries are leased until the lease passes, after which they are claimed
+* again unless their attempt was recorded.
+**/
+func (r *repository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*dueDelivery, error) {
+	query := `
+	WITH claimed AS (
+		UPDATE webhook_deliveries SET
+			next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT d.id
+			FROM webhook_deliveries d
+			JOIN webhook_endpoints e ON e.id = d.endpoint_id
+			WHERE d.status = 'pending'
+			AND d.next_attempt_at <= NOW()
+			AND e.active
+			ORDER BY d.created_at
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING ` + deliveryColumns + `
+	)
+	SELECT claimed.*, e.url, e.secret
+	FROM claimed
+	JOIN webhook_endpoints e ON e.id = claimed.endpoint_id
+	ORDER BY claimed.created_at
+	`
+
+	deliveries := []*dueDelivery{}
+	if err := r.db.SelectContext(ctx, &deliveries, query, limit, lease.Milliseconds()); err != nil {
+		return nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	return deliveries, nil
+}
+
+/**
+* Records an attempt of a delivery. Succeeded and failed deliveries are done, pending
+* ones are attempted again at nextAttemptAt.
+**/
+func (r *repository) RecordAttempt(ctx context.Context, id uuid.UUID, attempt Attempt, status constants.WebhookDeliveryStatus, nextAttemptAt time.Time) error {
+	query := `
+	UPDATE webhook_deliveries SET
+		status = $2,
+		attempts = attempts + 1,
+		next_attempt_at = $3,
+		last_attempt_at = NOW(),
+		response_status = $4,
+		response_body = $5,
+		duration_ms = $6,
+		last_error = $7,
+		delivered_at = CASE WHEN $2 = 'succeeded' THEN NOW() END
+	WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, string(status), nextAttemptAt,
+		attempt.ResponseStatus, attempt.ResponseBody, attempt.Duration.Milliseconds(), attemptError(attempt))
+
+	return errorutils.AnalyzeDBResults(err, result)
+}
+
+// GetDeliveries returns the delivery log of an endpoint, newest first, optionally of a single status
+func (r *repository) GetDeliveries(ctx context.Context, endpointID uuid.UUID, status *constants.WebhookDeliveryStatus, page *pageutils.Params) ([]*Delivery, *pageutils.Page, error) {
+	query := `
+	SELECT ` + deliveryColumns + `
+	FROM webhook_deliveries
+	WHERE endpoint_id = $1
+	AND ($2::TEXT IS NULL OR status = $2)
+	`
+
+	var statusArg interface{}
+	if status != nil {
+		statusArg = string(*status)
+	}
+
+	keys := []pageutils.Key{{Column: "created_at", Desc: true}}
+
+	query, args, err := pageutils.Apply(query, []interface{}{endpointID, statusArg}, keys, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deliveries := []*Delivery{}
+	if err := r.db.SelectContext(ctx, &deliveries, query, args...); err != nil {
+		return nil, nil, errorutils.AnalyzeDBErr(err)
+	}
+
+	deliveries, pageInfo := pageutils.Paginate(deliveries, page, func(delivery *Delivery) (uuid.UUID, []interface{}) {
+		return delivery.ID, []interface{}{delivery.CreatedAt}
+	})
+
+	return deliveries, pageInfo, nil
+}
+
+func eventTypeArray(eventTypes []constants.EventType) pq.StringArray {
+	array := make(pq.StringArray, len(eventTypes))
+	for i, eventType := range eventTypes {
+		array[i] = string(eventType)
+	}
+	return array
+}
+
+func attemptError(attempt Attempt) *string {
+	if attempt.Err == nil {
+		return nil
+	}
+	message := attempt.Err.Error()
+	return &message
+}
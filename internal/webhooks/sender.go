@@ -0,0 +1,113 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/utils/httputils"
+	"github.com/google/uuid"
+)
+
+const (
+	// headers sent with every delivery
+	HeaderEvent     = "X-Fireplace-Event"
+	HeaderDelivery  = "X-Fireplace-Delivery"
+	HeaderTimestamp = "X-Fireplace-Timestamp"
+	HeaderSignature = "X-Fireplace-Signature"
+
+	// how much of a response body is kept in the delivery log
+	maxResponseBody = 1024
+)
+
+// sends deliveries as signed json POSTs
+type Sender struct {
+	client *http.Client
+}
+
+/**
+* Creates a sender for user registered endpoints. allowPrivateAddresses lifts the public
+* address check for local development, where endpoints usually point at a stand-in on
+* localhost.
+**/
+func NewSender(allowPrivateAddresses bool) *Sender {
+	// redirects are not followed, an endpoint has to answer itself
+	return &Sender{
+		client: httputils.NewPublicClient(0, allowPrivateAddresses),
+	}
+}
+
+/**
+* POSTs a payload to an endpoint once. Any 2xx response counts as delivered, the outcome
+* is returned either way so that it can be written to the delivery log.
+**/
+func (s *Sender) Send(ctx context.Context, url string, secret string, deliveryID uuid.UUID, eventType string, body []byte) Attempt {
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Attempt{Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Fireplace-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderDelivery, deliveryID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, "sha256="+Sign(secret, timestamp, body))
+
+	start := time.Now()
+	res, err := s.client.Do(req)
+	if err != nil {
+		return Attempt{Duration: time.Since(start), Err: err}
+	}
+	defer res.Body.Close()
+
+	raw, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseBody))
+	attempt := Attempt{
+		ResponseStatus: &res.StatusCode,
+		Duration:       time.Since(start),
+	}
+
+	// postgres text cannot hold null bytes
+	responseBody := strings.ReplaceAll(strings.ToValidUTF8(string(raw), ""), "\x00", "")
+	attempt.ResponseBody = &responseBody
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		attempt.Err = fmt.Errorf("endpoint responded with status %d", res.StatusCode)
+	}
+
+	return attempt
+}
+
+/**
+* Signs a payload as the hex encoded HMAC-SHA256 of "<timestamp>.<body>". Receivers
+* compute the same with their secret and the X-Fireplace-Timestamp header, and should
+* reject old timestamps so that captured requests cannot be replayed.
+**/
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// generates the secret of an endpoint
+func newSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+
+	return "whsec_" + hex.EncodeToString(key), nil
+}
@@ -0,0 +1,49 @@
+package webhooks
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      string
+		want      string
+	}{
+		{"payload", "whsec_test", 1700000000, `{"type":"ping"}`, "bc08c591847b765241711bcbe7067e3869a219e424d3fdd9d00b3b6f915baf97"},
+		{"timestamp is signed", "whsec_test", 1700000001, `{"type":"ping"}`, "640fdd977892c68f0b82f14d983bc98a671f2d1fee03579be0f882a3a672ecb6"},
+		{"secret is the key", "other", 1700000000, `{"type":"ping"}`, "038931591ecc398db25322b7821f268fb84ec9c193f0d15872ed1933a363ca71"},
+		{"empty body", "whsec_test", 0, "", "a2fa7a43c6a1cf2e784eaf3327d65c65b3d2b790320ebed9aa5661bc42a8cccd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Errorf("Sign(%q, %d, %q) = %s, want %s", tt.secret, tt.timestamp, tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	first, err := newSecret()
+	if err != nil {
+		t.Fatalf("newSecret returned error: %v", err)
+	}
+
+	second, err := newSecret()
+	if err != nil {
+		t.Fatalf("newSecret returned error: %v", err)
+	}
+
+	if !strings.HasPrefix(first, "whsec_") || len(first) != len("whsec_")+64 {
+		t.Errorf("newSecret() = %s, want whsec_ followed by 32 hex encoded bytes", first)
+	}
+
+	if first == second {
+		t.Errorf("newSecret() returned %s twice", first)
+	}
+}
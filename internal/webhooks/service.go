@@ -0,0 +1,318 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/darkphotonKN/fireplace/internal/constants"
+	"github.com/darkphotonKN/fireplace/internal/utils/errorutils"
+	"github.com/darkphotonKN/fireplace/internal/utils/pageutils"
+	"github.com/google/uuid"
+)
+
+const (
+	// deliveries claimed per run of the delivery job
+	deliveryBatchSize = 50
+	// deliveries sent at the same time, so that one slow endpoint does not hold up the others
+	deliveryConcurrency = 8
+	// how long a claimed delivery is reserved before it can be claimed again
+	claimLease = 5 * time.Minute
+
+	// failed deliveries are retried after 30s, 1m, 2m, ... up to 6 hours apart
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = 6 * time.Hour
+	// deliveries that failed this often are given up on
+	maxDeliveryAttempts = 10
+)
+
+type service struct {
+	repo   Repository
+	sender *Sender
+}
+
+type Repository interface {
+	CreateEndpoint(ctx context.Context, userID uuid.UUID, req CreateEndpointReq, secret string) (*Endpoint, error)
+	GetEndpoints(ctx context.Context, userID uuid.UUID) ([]*Endpoint, error)
+	GetEndpoint(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Endpoint, error)
+	UpdateEndpoint(ctx context.Context, userID uuid.UUID, id uuid.UUID, req UpdateEndpointReq) (*Endpoint, error)
+	UpdateSecret(ctx context.Context, userID uuid.UUID, id uuid.UUID, secret string) (*Endpoint, error)
+	DeleteEndpoint(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+	EnqueueDeliveries(ctx context.Context, userID uuid.UUID, eventID uuid.UUID, eventType string, payload []byte) (int64, error)
+	CreateSentDelivery(ctx context.Context, endpointID uuid.UUID, eventType string, payload []byte, attempt Attempt) (*Delivery, error)
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*dueDelivery, error)
+	RecordAttempt(ctx context.Context, id uuid.UUID, attempt Attempt, status constants.WebhookDeliveryStatus, nextAttemptAt time.Time) error
+	GetDeliveries(ctx context.Context, endpointID uuid.UUID, status *constants.WebhookDeliveryStatus, page *pageutils.Params) ([]*Delivery, *pageutils.Page, error)
+}
+
+func NewService(repo Repository, sender *Sender) *service {
+	return &service{
+		repo:   repo,
+		sender: sender,
+	}
+}
+
+func (s *service) GetEndpoints(ctx context.Context, userID uuid.UUID) ([]*Endpoint, error) {
+	return s.repo.GetEndpoints(ctx, userID)
+}
+
+func (s *service) GetEndpoint(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Endpoint, error) {
+	return s.repo.GetEndpoint(ctx, userID, id)
+}
+
+/**
+* Registers an endpoint with a newly generated secret. The secret is only returned here
+* and when it is rotated.
+**/
+func (s *service) CreateEndpoint(ctx context.Context, userID uuid.UUID, req CreateEndpointReq) (*EndpointWithSecret, error) {
+	if err := validateURL(req.URL); err != nil {
+		return nil, err
+	}
+	if err := validateEventTypes(req.EventTypes); err != nil {
+		return nil, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := s.repo.CreateEndpoint(ctx, userID, req, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EndpointWithSecret{Endpoint: endpoint, Secret: secret}, nil
+}
+
+func (s *service) UpdateEndpoint(ctx context.Context, userID uuid.UUID, id uuid.UUID, req UpdateEndpointReq) (*Endpoint, error) {
+	if req.URL != nil {
+		if err := validateURL(*req.URL); err != nil {
+			return nil, err
+		}
+	}
+	if req.EventTypes != nil {
+		if err := validateEventTypes(*req.EventTypes); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.UpdateEndpoint(ctx, userID, id, req)
+}
+
+// RotateSecret replaces the secret of an endpoint, payloads are signed with the new one right away
+func (s *service) RotateSecret(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*EndpointWithSecret, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := s.repo.UpdateSecret(ctx, userID, id, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EndpointWithSecret{Endpoint: endpoint, Secret: secret}, nil
+}
+
+func (s *service) DeleteEndpoint(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	return s.repo.DeleteEndpoint(ctx, userID, id)
+}
+
+func (s *service) GetDeliveries(ctx context.Context, userID uuid.UUID, id uuid.UUID, status *constants.WebhookDeliveryStatus, page *pageutils.Params) ([]*Delivery, *pageutils.Page, error) {
+	if status != nil && !isValidStatus(*status) {
+		return nil, nil, errorutils.Invalidf("status must be one of 'pending', 'succeeded' or 'failed'")
+	}
+
+	// makes sure the endpoint belongs to the user
+	if _, err := s.repo.GetEndpoint(ctx, userID, id); err != nil {
+		return nil, nil, err
+	}
+
+	return s.repo.GetDeliveries(ctx, id, status, page)
+}
+
+/**
+* Sends a ping event to an endpoint right away and records it in the delivery log, so
+* that users can check their endpoint and its signature verification. Pings are sent
+* to inactive endpoints as well and are not retried.
+**/
+func (s *service) Ping(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Delivery, error) {
+	endpoint, err := s.repo.GetEndpoint(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"endpointId": endpoint.ID, "message": "ping from fireplace"})
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(Payload{
+		ID:         uuid.New(),
+		Type:       constants.WebhookPingEvent,
+		OccurredAt: time.Now(),
+		Data:       data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the delivery is only recorded once it was sent, so its id is not known yet
+	attempt := s.sender.Send(ctx, endpoint.URL, endpoint.Secret, uuid.New(), constants.WebhookPingEvent, payload)
+
+	return s.repo.CreateSentDelivery(ctx, endpoint.ID, constants.WebhookPingEvent, payload, attempt)
+}
+
+/**
+* Queues a notification for the endpoints of a user that receive notifications, it is
+* signed and retried like any other delivery. Returns how many endpoints it was queued for.
+**/
+func (s *service) EnqueueNotification(ctx context.Context, userID uuid.UUID, notificationID uuid.UUID, data interface{}) (int64, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return 0, err
+	}
+
+	payload, err := json.Marshal(Payload{
+		ID:         notificationID,
+		Type:       string(constants.WebhookNotificationEvent),
+		OccurredAt: time.Now(),
+		Data:       raw,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return s.repo.EnqueueDeliveries(ctx, userID, notificationID, string(constants.WebhookNotificationEvent), payload)
+}
+
+/**
+* Sends the deliveries that are due, run periodically by the webhook delivery job.
+* Returns how many were delivered, failed ones are retried with exponential backoff
+* until they run out of attempts.
+**/
+func (s *service) DeliverPending(ctx context.Context) (int64, error) {
+	deliveries, err := s.repo.ClaimDue(ctx, deliveryBatchSize, claimLease)
+	if err != nil {
+		return 0, err
+	}
+
+	var delivered atomic.Int64
+	var errs []error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, deliveryConcurrency)
+
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func(delivery *dueDelivery) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			ok, err := s.deliver(ctx, delivery)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				return
+			}
+			if ok {
+				delivered.Add(1)
+			}
+		}(delivery)
+	}
+
+	wg.Wait()
+
+	return delivered.Load(), errors.Join(errs...)
+}
+
+// sends a delivery once and records the outcome, reporting whether it was delivered
+func (s *service) deliver(ctx context.Context, delivery *dueDelivery) (bool, error) {
+	attempt := s.sender.Send(ctx, delivery.URL, delivery.Secret, delivery.ID, delivery.EventType, delivery.Payload)
+
+	if attempt.Err == nil {
+		return true, s.repo.RecordAttempt(ctx, delivery.ID, attempt, constants.WebhookDeliverySucceeded, time.Now())
+	}
+
+	attempts := delivery.Attempts + 1
+	if attempts >= maxDeliveryAttempts {
+		fmt.Printf("Giving up on webhook delivery %s after %d attempts: %s\n", delivery.ID, attempts, attempt.Err.Error())
+		return false, s.repo.RecordAttempt(ctx, delivery.ID, attempt, constants.WebhookDeliveryFailed, time.Now())
+	}
+
+	fmt.Printf("Error sending webhook delivery %s, attempt %d: %s\n", delivery.ID, attempts, attempt.Err.Error())
+
+	return false, s.repo.RecordAttempt(ctx, delivery.ID, attempt, constants.WebhookDeliveryPending, time.Now().Add(backoff(attempts)))
+}
+
+// delay before the next attempt after the given number of failed attempts
+func backoff(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+
+	return delay
+}
+
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errorutils.Invalidf("url must be an absolute http or https url")
+	}
+	if u.User != nil {
+		return errorutils.Invalidf("url must not contain credentials")
+	}
+
+	return nil
+}
+
+func validateEventTypes(eventTypes []constants.EventType) error {
+	seen := make(map[constants.EventType]bool, len(eventTypes))
+
+	for _, eventType := range eventTypes {
+		if !isValidEventType(eventType) {
+			return errorutils.Invalidf("unknown event type %s", eventType)
+		}
+		if seen[eventType] {
+			return errorutils.Invalidf("event type %s was provided more than once", eventType)
+		}
+		seen[eventType] = true
+	}
+
+	return nil
+}
+
+func isValidEventType(eventType constants.EventType) bool {
+	if eventType == constants.WebhookNotificationEvent {
+		return true
+	}
+
+	for _, valid := range constants.EventTypes {
+		if eventType == valid {
+			return true
+		}
+	}
+	return false
+}
+
+func isValidStatus(status constants.WebhookDeliveryStatus) bool {
+	for _, valid := range constants.WebhookDeliveryStatuses {
+		if status == valid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/darkphotonKN/fireplace/internal/events"
+)
+
+/**
+* Hands the domain events of the outbox to the webhook endpoints of their user, by
+* creating a pending delivery for each endpoint that receives the event type. The
+* deliveries are sent by the webhook delivery job, so a slow endpoint never holds up
+* the outbox.
+**/
+type Subscriber struct {
+	repo Repository
+}
+
+func NewSubscriber(repo Repository) *Subscriber {
+	return &Subscriber{
+		repo: repo,
+	}
+}
+
+func (s *Subscriber) Handle(ctx context.Context, event *events.Event) error {
+	aggregateID := event.AggregateID
+
+	payload, err := json.Marshal(Payload{
+		ID:          event.ID,
+		Type:        event.Type,
+		OccurredAt:  event.OccurredAt,
+		PlanID:      event.PlanID,
+		AggregateID: &aggregateID,
+		Data:        event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.repo.EnqueueDeliveries(ctx, event.UserID, event.ID, event.Type, payload)
+
+	return err
+}
@@ -0,0 +1,4 @@
+-- Migration: 000011_create_checklist_item_completions_table.down.sql
+DROP INDEX IF EXISTS idx_completions_plan_date;
+DROP INDEX IF EXISTS idx_completions_item_occurrence;
+DROP TABLE IF EXISTS checklist_item_completions;
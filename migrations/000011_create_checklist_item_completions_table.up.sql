@@ -0,0 +1,19 @@
+-- Migration: 000011_create_checklist_item_completions_table.up.sql
+CREATE TABLE IF NOT EXISTS checklist_item_completions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    checklist_item_id UUID NOT NULL REFERENCES checklist_items(id) ON DELETE CASCADE,
+    plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    scope TEXT NOT NULL,
+    occurrence_date DATE NOT NULL, -- the day the completion counts towards
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- An item can only be completed once per occurrence
+CREATE UNIQUE INDEX idx_completions_item_occurrence ON checklist_item_completions(checklist_item_id, occurrence_date);
+
+-- Index for plan level history and heatmaps
+CREATE INDEX idx_completions_plan_date ON checklist_item_completions(plan_id, occurrence_date);
+
+COMMENT ON TABLE checklist_item_completions IS 'Completion events of checklist items, kept so that history survives daily resets';
@@ -0,0 +1,14 @@
+-- Migration: 000012_add_due_date_priority_effort_to_checklist_items.down.sql
+DROP INDEX IF EXISTS idx_checklist_items_priority;
+DROP INDEX IF EXISTS idx_checklist_items_due_date;
+
+ALTER TABLE checklist_items
+DROP CONSTRAINT IF EXISTS check_positive_effort_estimate,
+DROP CONSTRAINT IF EXISTS check_valid_effort_unit,
+DROP CONSTRAINT IF EXISTS check_valid_priority;
+
+ALTER TABLE checklist_items
+DROP COLUMN effort_unit,
+DROP COLUMN effort_estimate,
+DROP COLUMN priority,
+DROP COLUMN due_date;
@@ -0,0 +1,16 @@
+-- Migration: 000012_add_due_date_priority_effort_to_checklist_items.up.sql
+ALTER TABLE checklist_items
+ADD COLUMN due_date TIMESTAMP WITH TIME ZONE,
+ADD COLUMN priority TEXT NOT NULL DEFAULT 'medium',
+ADD COLUMN effort_estimate INTEGER,
+ADD COLUMN effort_unit TEXT;
+
+-- Add check constraints to validate priority and effort values
+ALTER TABLE checklist_items
+ADD CONSTRAINT check_valid_priority CHECK (priority IN ('low', 'medium', 'high', 'urgent')),
+ADD CONSTRAINT check_valid_effort_unit CHECK (effort_unit IS NULL OR effort_unit IN ('minutes', 'points')),
+ADD CONSTRAINT check_positive_effort_estimate CHECK (effort_estimate IS NULL OR effort_estimate > 0);
+
+-- Indexes for sorting and filtering by due date and priority
+CREATE INDEX idx_checklist_items_due_date ON checklist_items(due_date);
+CREATE INDEX idx_checklist_items_priority ON checklist_items(priority);
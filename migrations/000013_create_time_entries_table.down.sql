@@ -0,0 +1,6 @@
+-- Migration: 000013_create_time_entries_table.down.sql
+DROP TRIGGER IF EXISTS update_time_entries_modtime ON time_entries;
+DROP INDEX IF EXISTS idx_time_entries_checklist_item;
+DROP INDEX IF EXISTS idx_time_entries_plan_started;
+DROP INDEX IF EXISTS idx_time_entries_running_per_user;
+DROP TABLE IF EXISTS time_entries;
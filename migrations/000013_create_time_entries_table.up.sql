@@ -0,0 +1,31 @@
+-- Migration: 000013_create_time_entries_table.up.sql
+CREATE TABLE IF NOT EXISTS time_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+    checklist_item_id UUID NOT NULL REFERENCES checklist_items(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL DEFAULT 'timer', -- timer, manual or pomodoro
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_at TIMESTAMP WITH TIME ZONE, -- NULL while the timer is running
+    planned_minutes INTEGER, -- work length of a pomodoro session
+    break_minutes INTEGER, -- break length following a pomodoro session
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_valid_time_entry_kind CHECK (kind IN ('timer', 'manual', 'pomodoro')),
+    CONSTRAINT check_valid_time_entry_range CHECK (ended_at IS NULL OR ended_at >= started_at)
+);
+
+-- Guards against more than one running timer per user
+CREATE UNIQUE INDEX idx_time_entries_running_per_user ON time_entries(user_id) WHERE ended_at IS NULL;
+
+-- Index for plan totals
+CREATE INDEX idx_time_entries_plan_started ON time_entries(plan_id, started_at);
+
+-- Index for filtering by checklist item
+CREATE INDEX idx_time_entries_checklist_item ON time_entries(checklist_item_id);
+
+CREATE TRIGGER update_time_entries_modtime
+BEFORE UPDATE ON time_entries
+FOR EACH ROW
+EXECUTE FUNCTION update_modified_column();
@@ -0,0 +1,7 @@
+-- Migration: 000014_add_notes_and_links_to_checklist_items.down.sql
+DROP TRIGGER IF EXISTS update_checklist_item_links_modtime ON checklist_item_links;
+DROP INDEX IF EXISTS idx_checklist_item_links_item_url;
+DROP TABLE IF EXISTS checklist_item_links;
+
+ALTER TABLE checklist_items
+DROP COLUMN notes;
@@ -0,0 +1,28 @@
+-- Migration: 000014_add_notes_and_links_to_checklist_items.up.sql
+ALTER TABLE checklist_items
+ADD COLUMN notes TEXT;
+
+COMMENT ON COLUMN checklist_items.notes IS 'Markdown notes, sanitized before they are stored';
+
+CREATE TABLE IF NOT EXISTS checklist_item_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    checklist_item_id UUID NOT NULL REFERENCES checklist_items(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    title TEXT,
+    description TEXT,
+    site_name TEXT,
+    image_url TEXT,
+    resource_type TEXT NOT NULL DEFAULT 'website', -- video or website
+    metadata_fetched_at TIMESTAMP WITH TIME ZONE, -- NULL until metadata was fetched successfully
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_valid_link_resource_type CHECK (resource_type IN ('video', 'website'))
+);
+
+-- A url can only be attached to an item once
+CREATE UNIQUE INDEX idx_checklist_item_links_item_url ON checklist_item_links(checklist_item_id, url);
+
+CREATE TRIGGER update_checklist_item_links_modtime
+BEFORE UPDATE ON checklist_item_links
+FOR EACH ROW
+EXECUTE FUNCTION update_modified_column();
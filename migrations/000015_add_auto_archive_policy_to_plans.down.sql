@@ -0,0 +1,7 @@
+-- Migration: 000015_add_auto_archive_policy_to_plans.down.sql
+ALTER TABLE plans
+DROP CONSTRAINT IF EXISTS check_valid_auto_archive_days;
+
+ALTER TABLE plans
+DROP COLUMN IF EXISTS auto_archive_past_scheduled,
+DROP COLUMN IF EXISTS auto_archive_completed_after_days;
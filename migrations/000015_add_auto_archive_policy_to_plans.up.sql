@@ -0,0 +1,10 @@
+-- Migration: 000015_add_auto_archive_policy_to_plans.up.sql
+ALTER TABLE plans
+ADD COLUMN auto_archive_completed_after_days INTEGER,
+ADD COLUMN auto_archive_past_scheduled BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE plans
+ADD CONSTRAINT check_valid_auto_archive_days CHECK (auto_archive_completed_after_days IS NULL OR auto_archive_completed_after_days >= 0);
+
+COMMENT ON COLUMN plans.auto_archive_completed_after_days IS 'Archives completed longterm items this many days after completion, NULL disables it';
+COMMENT ON COLUMN plans.auto_archive_past_scheduled IS 'Archives items once their scheduled time has passed';
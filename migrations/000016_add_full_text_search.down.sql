@@ -0,0 +1,14 @@
+-- Migration: 000016_add_full_text_search.down.sql
+DROP INDEX IF EXISTS idx_checklist_items_description_trgm;
+DROP INDEX IF EXISTS idx_plans_focus_trgm;
+DROP INDEX IF EXISTS idx_plans_name_trgm;
+DROP INDEX IF EXISTS idx_checklist_items_search_vector;
+DROP INDEX IF EXISTS idx_plans_search_vector;
+
+ALTER TABLE checklist_items
+DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE plans
+DROP COLUMN IF EXISTS search_vector;
+
+-- Don't drop the pg_trgm extension in case it's used elsewhere
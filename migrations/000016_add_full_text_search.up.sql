@@ -0,0 +1,24 @@
+-- Migration: 000016_add_full_text_search.up.sql
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- weighted search documents, names and descriptions rank above the rest
+ALTER TABLE plans
+ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(name, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(focus, '')), 'B') ||
+    setweight(to_tsvector('english', COALESCE(description, '')), 'C')
+) STORED;
+
+ALTER TABLE checklist_items
+ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(description, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(notes, '')), 'B')
+) STORED;
+
+CREATE INDEX idx_plans_search_vector ON plans USING GIN (search_vector);
+CREATE INDEX idx_checklist_items_search_vector ON checklist_items USING GIN (search_vector);
+
+-- trigram indexes for partial word matches
+CREATE INDEX idx_plans_name_trgm ON plans USING GIN (name gin_trgm_ops);
+CREATE INDEX idx_plans_focus_trgm ON plans USING GIN (focus gin_trgm_ops);
+CREATE INDEX idx_checklist_items_description_trgm ON checklist_items USING GIN (description gin_trgm_ops);
@@ -0,0 +1,10 @@
+-- Migration: 000017_add_version_to_plans_and_checklist_items.down.sql
+DROP TRIGGER IF EXISTS increment_checklist_items_version ON checklist_items;
+DROP TRIGGER IF EXISTS increment_plans_version ON plans;
+DROP FUNCTION IF EXISTS increment_version();
+
+ALTER TABLE checklist_items
+DROP COLUMN IF EXISTS version;
+
+ALTER TABLE plans
+DROP COLUMN IF EXISTS version;
@@ -0,0 +1,25 @@
+-- Migration: 000017_add_version_to_plans_and_checklist_items.up.sql
+ALTER TABLE plans
+ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE checklist_items
+ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- every update produces a new version, used for ETags and If-Match checks
+CREATE OR REPLACE FUNCTION increment_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER increment_plans_version
+BEFORE UPDATE ON plans
+FOR EACH ROW
+EXECUTE FUNCTION increment_version();
+
+CREATE TRIGGER increment_checklist_items_version
+BEFORE UPDATE ON checklist_items
+FOR EACH ROW
+EXECUTE FUNCTION increment_version();
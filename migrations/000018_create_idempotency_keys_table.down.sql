@@ -0,0 +1,3 @@
+-- Migration: 000018_create_idempotency_keys_table.down.sql
+DROP INDEX IF EXISTS idx_idempotency_keys_expires_at;
+DROP TABLE IF EXISTS idempotency_keys;
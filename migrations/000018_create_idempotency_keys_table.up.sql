@@ -0,0 +1,19 @@
+-- Migration: 000018_create_idempotency_keys_table.up.sql
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    idempotency_key TEXT NOT NULL,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    -- response is NULL while the original request is still being processed
+    status_code INTEGER,
+    content_type TEXT,
+    response_body BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT unique_user_idempotency_key UNIQUE (user_id, idempotency_key)
+);
+
+-- Index for purging expired keys
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
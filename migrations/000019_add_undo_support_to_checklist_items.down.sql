@@ -0,0 +1,11 @@
+-- Migration: 000019_add_undo_support_to_checklist_items.down.sql
+DROP INDEX IF EXISTS idx_checklist_operations_user;
+DROP TABLE IF EXISTS checklist_operations;
+
+-- soft deleted items are removed for good
+DELETE FROM checklist_items WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_checklist_items_deleted_at;
+
+ALTER TABLE checklist_items
+DROP COLUMN IF EXISTS deleted_at;
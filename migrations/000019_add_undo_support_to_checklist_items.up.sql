@@ -0,0 +1,23 @@
+-- Migration: 000019_add_undo_support_to_checklist_items.up.sql
+ALTER TABLE checklist_items
+ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Index for purging soft deleted items once they can no longer be restored
+CREATE INDEX idx_checklist_items_deleted_at ON checklist_items(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- journal of operations that can be undone, the id doubles as the undo token
+CREATE TABLE IF NOT EXISTS checklist_operations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    plan_id UUID REFERENCES plans(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    -- state of the affected items before the operation
+    snapshot JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    undone_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT check_valid_operation_kind CHECK (kind IN ('delete', 'archive', 'autoArchive', 'move', 'copy'))
+);
+
+-- Index for listing the journal of a user
+CREATE INDEX idx_checklist_operations_user ON checklist_operations(user_id, created_at DESC);
@@ -0,0 +1,15 @@
+-- Migration: 000020_add_weekly_and_monthly_scopes.down.sql
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS check_valid_week_starts_on,
+DROP COLUMN IF EXISTS week_starts_on;
+
+ALTER TABLE checklist_items
+DROP CONSTRAINT check_valid_scope;
+
+-- repeating items without a daily equivalent become longterm items
+UPDATE checklist_items
+SET scope = 'longterm'
+WHERE scope IN ('weekly', 'monthly');
+
+ALTER TABLE checklist_items
+ADD CONSTRAINT check_valid_scope CHECK (scope IN ('longterm', 'daily'));
@@ -0,0 +1,11 @@
+-- Migration: 000020_add_weekly_and_monthly_scopes.up.sql
+ALTER TABLE checklist_items
+DROP CONSTRAINT check_valid_scope;
+
+ALTER TABLE checklist_items
+ADD CONSTRAINT check_valid_scope CHECK (scope IN ('longterm', 'daily', 'weekly', 'monthly'));
+
+-- day the week of a user starts on, weekly items reset at its start. 0 is sunday, 1 is monday
+ALTER TABLE users
+ADD COLUMN week_starts_on SMALLINT NOT NULL DEFAULT 1,
+ADD CONSTRAINT check_valid_week_starts_on CHECK (week_starts_on BETWEEN 0 AND 6);
@@ -0,0 +1,7 @@
+-- Migration: 000021_create_checklist_item_reminders_table.down.sql
+DROP INDEX IF EXISTS idx_checklist_items_scheduled_time;
+DROP INDEX IF EXISTS idx_checklist_item_reminders_occurrence;
+DROP TABLE IF EXISTS checklist_item_reminders;
+
+ALTER TABLE checklist_items
+DROP COLUMN IF EXISTS reminder_lead_minutes;
@@ -0,0 +1,24 @@
+-- Migration: 000021_create_checklist_item_reminders_table.up.sql
+-- minutes before the scheduled time reminders are sent at, 0 reminds at the scheduled time
+ALTER TABLE checklist_items
+ADD COLUMN reminder_lead_minutes INTEGER[] NOT NULL DEFAULT '{0}';
+
+-- delivery state of reminders, one row per item, lead time and scheduled time
+CREATE TABLE IF NOT EXISTS checklist_item_reminders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    checklist_item_id UUID NOT NULL REFERENCES checklist_items(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    lead_minutes INTEGER NOT NULL,
+    scheduled_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    remind_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    claimed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    -- NULL while the reminder is being handed to the dispatcher
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Each reminder of a scheduled time is only sent once
+CREATE UNIQUE INDEX idx_checklist_item_reminders_occurrence ON checklist_item_reminders(checklist_item_id, lead_minutes, scheduled_time);
+
+-- Index for finding items with reminders that are due
+CREATE INDEX idx_checklist_items_scheduled_time ON checklist_items(scheduled_time) WHERE scheduled_time IS NOT NULL;
@@ -0,0 +1,9 @@
+-- Migration: 000022_create_notifications_tables.down.sql
+DROP TRIGGER IF EXISTS update_notification_preferences_modtime ON notification_preferences;
+DROP TABLE IF EXISTS notification_preferences;
+DROP INDEX IF EXISTS idx_notifications_user_unread;
+DROP INDEX IF EXISTS idx_notifications_user_created;
+DROP TABLE IF EXISTS notifications;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS timezone;
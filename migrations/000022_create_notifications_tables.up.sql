@@ -0,0 +1,40 @@
+-- Migration: 000022_create_notifications_tables.up.sql
+-- IANA timezone of the user, e.g. Europe/Berlin
+ALTER TABLE users
+ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+
+-- in-app inbox of notifications
+CREATE TABLE IF NOT EXISTS notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    data JSONB,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Index for listing the inbox of a user
+CREATE INDEX idx_notifications_user_created ON notifications(user_id, created_at DESC);
+
+-- Index for counting unread notifications
+CREATE INDEX idx_notifications_user_unread ON notifications(user_id) WHERE read_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    -- channels each kind of notification goes to, e.g. {"reminder": ["inApp", "email"]}
+    channels JSONB NOT NULL DEFAULT '{}',
+    webhook_url TEXT,
+    -- local times of the user between which only the in-app inbox receives notifications
+    quiet_hours_start TIME,
+    quiet_hours_end TIME,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_quiet_hours_complete CHECK ((quiet_hours_start IS NULL) = (quiet_hours_end IS NULL))
+);
+
+CREATE TRIGGER update_notification_preferences_modtime
+BEFORE UPDATE ON notification_preferences
+FOR EACH ROW
+EXECUTE FUNCTION update_modified_column();
@@ -0,0 +1,20 @@
+-- Migration: 000023_add_local_daily_reset.down.sql
+DROP TRIGGER IF EXISTS update_plans_modtime ON plans;
+DROP TRIGGER IF EXISTS increment_plans_version ON plans;
+
+CREATE TRIGGER update_plans_modtime
+BEFORE UPDATE ON plans
+FOR EACH ROW
+EXECUTE FUNCTION update_modified_column();
+
+CREATE TRIGGER increment_plans_version
+BEFORE UPDATE ON plans
+FOR EACH ROW
+EXECUTE FUNCTION increment_version();
+
+ALTER TABLE plans
+DROP COLUMN IF EXISTS last_reset_date;
+
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS check_valid_reset_hour,
+DROP COLUMN IF EXISTS reset_hour;
@@ -0,0 +1,31 @@
+-- Migration: 000023_add_local_daily_reset.up.sql
+-- local hour of the day, in the timezone of the user, repeating items are reset at
+ALTER TABLE users
+ADD COLUMN reset_hour SMALLINT NOT NULL DEFAULT 0,
+ADD CONSTRAINT check_valid_reset_hour CHECK (reset_hour BETWEEN 0 AND 23);
+
+-- local date the repeating items of the plan were last reset on
+ALTER TABLE plans
+ADD COLUMN last_reset_date DATE;
+
+-- plans were already reset by the previous fixed schedule today
+UPDATE plans
+SET last_reset_date = (NOW() AT TIME ZONE users.timezone)::DATE
+FROM users
+WHERE plans.user_id = users.id;
+
+-- recording a reset is bookkeeping and is not a modification of the plan
+DROP TRIGGER IF EXISTS update_plans_modtime ON plans;
+DROP TRIGGER IF EXISTS increment_plans_version ON plans;
+
+CREATE TRIGGER update_plans_modtime
+BEFORE UPDATE ON plans
+FOR EACH ROW
+WHEN (OLD.last_reset_date IS NOT DISTINCT FROM NEW.last_reset_date)
+EXECUTE FUNCTION update_modified_column();
+
+CREATE TRIGGER increment_plans_version
+BEFORE UPDATE ON plans
+FOR EACH ROW
+WHEN (OLD.last_reset_date IS NOT DISTINCT FROM NEW.last_reset_date)
+EXECUTE FUNCTION increment_version();
@@ -0,0 +1,6 @@
+-- Migration: 000024_create_job_runs_table.down.sql
+DROP TRIGGER IF EXISTS update_job_states_modtime ON job_states;
+DROP TABLE IF EXISTS job_states;
+
+DROP INDEX IF EXISTS idx_job_runs_job_started;
+DROP TABLE IF EXISTS job_runs;
@@ -0,0 +1,30 @@
+-- Migration: 000024_create_job_runs_table.up.sql
+-- history of background job runs
+CREATE TABLE IF NOT EXISTS job_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_name TEXT NOT NULL,
+    trigger TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running',
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE,
+    error TEXT,
+    items_affected BIGINT NOT NULL DEFAULT 0,
+    CONSTRAINT check_valid_job_run_trigger CHECK (trigger IN ('schedule', 'manual')),
+    CONSTRAINT check_valid_job_run_status CHECK (status IN ('running', 'succeeded', 'failed'))
+);
+
+-- Index for the run history of a job, newest first
+CREATE INDEX idx_job_runs_job_started ON job_runs(job_name, started_at DESC);
+
+-- jobs paused through the admin API, kept across restarts
+CREATE TABLE IF NOT EXISTS job_states (
+    job_name TEXT PRIMARY KEY,
+    paused BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_job_states_modtime
+BEFORE UPDATE ON job_states
+FOR EACH ROW
+EXECUTE FUNCTION update_modified_column();
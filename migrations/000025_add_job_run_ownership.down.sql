@@ -0,0 +1,6 @@
+-- Migration: 000025_add_job_run_ownership.down.sql
+DROP INDEX IF EXISTS idx_job_runs_scheduled_for;
+
+ALTER TABLE job_runs
+DROP COLUMN IF EXISTS scheduled_for,
+DROP COLUMN IF EXISTS instance_id;
@@ -0,0 +1,9 @@
+-- Migration: 000025_add_job_run_ownership.up.sql
+-- instance that held the advisory lock of the job while running it
+ALTER TABLE job_runs
+ADD COLUMN instance_id TEXT,
+-- time the run was scheduled for, NULL for manual runs
+ADD COLUMN scheduled_for TIMESTAMP WITH TIME ZONE;
+
+-- Each scheduled run executes once across all instances
+CREATE UNIQUE INDEX idx_job_runs_scheduled_for ON job_runs(job_name, scheduled_for) WHERE scheduled_for IS NOT NULL;
@@ -0,0 +1,12 @@
+-- Migration: 000026_add_job_catch_up.down.sql
+ALTER TABLE job_states
+DROP COLUMN IF EXISTS last_succeeded_at;
+
+DELETE FROM job_runs
+WHERE trigger = 'catchUp';
+
+ALTER TABLE job_runs
+DROP CONSTRAINT check_valid_job_run_trigger;
+
+ALTER TABLE job_runs
+ADD CONSTRAINT check_valid_job_run_trigger CHECK (trigger IN ('schedule', 'manual'));
@@ -0,0 +1,19 @@
+-- Migration: 000026_add_job_catch_up.up.sql
+-- runs executed on startup for the runs missed while no instance was up
+ALTER TABLE job_runs
+DROP CONSTRAINT check_valid_job_run_trigger;
+
+ALTER TABLE job_runs
+ADD CONSTRAINT check_valid_job_run_trigger CHECK (trigger IN ('schedule', 'manual', 'catchUp'));
+
+-- start of the last successful run, runs scheduled after it are caught up on startup
+ALTER TABLE job_states
+ADD COLUMN last_succeeded_at TIMESTAMP WITH TIME ZONE;
+
+INSERT INTO job_states (job_name, last_succeeded_at)
+SELECT job_name, MAX(started_at)
+FROM job_runs
+WHERE status = 'succeeded'
+GROUP BY job_name
+ON CONFLICT (job_name) DO UPDATE SET
+    last_succeeded_at = EXCLUDED.last_succeeded_at;
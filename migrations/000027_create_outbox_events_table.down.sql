@@ -0,0 +1,3 @@
+-- Migration: 000027_create_outbox_events_table.down.sql
+DROP INDEX IF EXISTS idx_outbox_events_pending;
+DROP TABLE IF EXISTS outbox_events;
@@ -0,0 +1,21 @@
+-- Migration: 000027_create_outbox_events_table.up.sql
+-- domain events, written in the same transaction as the change they describe
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    type TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- no reference as events outlive the plans they describe
+    plan_id UUID,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    -- set once the event ran out of attempts
+    failed_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT
+);
+
+-- Index for finding the events that are due for delivery
+CREATE INDEX idx_outbox_events_pending ON outbox_events(next_attempt_at) WHERE delivered_at IS NULL AND failed_at IS NULL;
@@ -0,0 +1,7 @@
+-- Migration: 000028_create_webhooks_tables.down.sql
+DROP INDEX IF EXISTS idx_webhook_deliveries_pending;
+DROP INDEX IF EXISTS idx_webhook_deliveries_endpoint_created;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TRIGGER IF EXISTS update_webhook_endpoints_modtime ON webhook_endpoints;
+DROP INDEX IF EXISTS idx_webhook_endpoints_user;
+DROP TABLE IF EXISTS webhook_endpoints;
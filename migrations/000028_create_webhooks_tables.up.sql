@@ -0,0 +1,53 @@
+-- Migration: 000028_create_webhooks_tables.up.sql
+-- urls registered by users to receive their plan and checklist events
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    -- key the payloads are signed with
+    secret TEXT NOT NULL,
+    -- event types the endpoint receives, every type when empty
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Index for finding the endpoints of a user
+CREATE INDEX idx_webhook_endpoints_user ON webhook_endpoints(user_id);
+
+CREATE TRIGGER update_webhook_endpoints_modtime
+BEFORE UPDATE ON webhook_endpoints
+FOR EACH ROW
+EXECUTE FUNCTION update_modified_column();
+
+-- an event sent to an endpoint, including pings, along with the outcome of its latest attempt
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    endpoint_id UUID NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    -- outbox event delivered, empty for pings
+    event_id UUID,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_attempt_at TIMESTAMP WITH TIME ZONE,
+    response_status INTEGER,
+    -- start of the response body, for debugging
+    response_body TEXT,
+    duration_ms INTEGER,
+    last_error TEXT,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_webhook_delivery_status CHECK (status IN ('pending', 'succeeded', 'failed')),
+    -- events are handed over at least once, but are sent to each endpoint once
+    CONSTRAINT unique_webhook_delivery_event UNIQUE (endpoint_id, event_id)
+);
+
+-- Index for the delivery log of an endpoint
+CREATE INDEX idx_webhook_deliveries_endpoint_created ON webhook_deliveries(endpoint_id, created_at DESC);
+
+-- Index for finding the deliveries that are due
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
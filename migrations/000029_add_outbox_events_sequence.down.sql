@@ -0,0 +1,8 @@
+-- Migration: 000029_add_outbox_events_sequence.down.sql
+DROP INDEX IF EXISTS idx_outbox_events_user_seq;
+
+ALTER TABLE outbox_events
+DROP CONSTRAINT IF EXISTS unique_outbox_events_seq;
+
+ALTER TABLE outbox_events
+DROP COLUMN IF EXISTS seq;
@@ -0,0 +1,10 @@
+-- Migration: 000029_add_outbox_events_sequence.up.sql
+-- position of each event in the outbox, the id of realtime messages that clients resume from
+ALTER TABLE outbox_events
+ADD COLUMN seq BIGSERIAL;
+
+ALTER TABLE outbox_events
+ADD CONSTRAINT unique_outbox_events_seq UNIQUE (seq);
+
+-- Index for replaying the events of a user after a sequence
+CREATE INDEX idx_outbox_events_user_seq ON outbox_events(user_id, seq);
@@ -0,0 +1,23 @@
+-- Migration: 000030_add_sync_change_tracking.down.sql
+DROP TRIGGER IF EXISTS record_checklist_items_tombstone ON checklist_items;
+DROP TRIGGER IF EXISTS record_plans_tombstone ON plans;
+DROP FUNCTION IF EXISTS record_checklist_item_tombstone();
+DROP FUNCTION IF EXISTS record_plan_tombstone();
+
+DROP INDEX IF EXISTS idx_sync_tombstones_deleted_at;
+DROP INDEX IF EXISTS idx_sync_tombstones_entity;
+DROP INDEX IF EXISTS idx_sync_tombstones_user_change_xid;
+DROP TABLE IF EXISTS sync_tombstones;
+
+DROP INDEX IF EXISTS idx_checklist_items_plan_change_xid;
+DROP INDEX IF EXISTS idx_plans_user_change_xid;
+
+DROP TRIGGER IF EXISTS record_checklist_items_change_xid ON checklist_items;
+DROP TRIGGER IF EXISTS record_plans_change_xid ON plans;
+DROP FUNCTION IF EXISTS record_change_xid();
+
+ALTER TABLE checklist_items
+DROP COLUMN IF EXISTS change_xid;
+
+ALTER TABLE plans
+DROP COLUMN IF EXISTS change_xid;
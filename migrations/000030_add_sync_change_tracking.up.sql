@@ -0,0 +1,83 @@
+-- Migration: 000030_add_sync_change_tracking.up.sql
+-- id of the transaction that last changed a row, the change feed of the sync api compares
+-- it against the snapshot a client last synced at (requires postgres 13 or later)
+ALTER TABLE plans
+ADD COLUMN change_xid XID8 NOT NULL DEFAULT pg_current_xact_id();
+
+ALTER TABLE checklist_items
+ADD COLUMN change_xid XID8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE OR REPLACE FUNCTION record_change_xid()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.change_xid = pg_current_xact_id();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_plans_change_xid
+BEFORE INSERT OR UPDATE ON plans
+FOR EACH ROW
+EXECUTE FUNCTION record_change_xid();
+
+CREATE TRIGGER record_checklist_items_change_xid
+BEFORE INSERT OR UPDATE ON checklist_items
+FOR EACH ROW
+EXECUTE FUNCTION record_change_xid();
+
+-- Indexes for finding the changes of a user
+CREATE INDEX idx_plans_user_change_xid ON plans(user_id, change_xid);
+CREATE INDEX idx_checklist_items_plan_change_xid ON checklist_items(plan_id, change_xid);
+
+-- rows that were removed for good, so that clients learn about the removal
+CREATE TABLE IF NOT EXISTS sync_tombstones (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    -- no references as tombstones outlive what they describe
+    user_id UUID NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id UUID NOT NULL,
+    plan_id UUID,
+    change_xid XID8 NOT NULL DEFAULT pg_current_xact_id(),
+    deleted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_sync_tombstone_entity_type CHECK (entity_type IN ('plan', 'checklistItem'))
+);
+
+-- Index for finding the tombstones of a user
+CREATE INDEX idx_sync_tombstones_user_change_xid ON sync_tombstones(user_id, change_xid);
+
+-- Index for finding whether an id was removed
+CREATE INDEX idx_sync_tombstones_entity ON sync_tombstones(entity_id);
+
+-- Index for purging old tombstones
+CREATE INDEX idx_sync_tombstones_deleted_at ON sync_tombstones(deleted_at);
+
+CREATE OR REPLACE FUNCTION record_plan_tombstone()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO sync_tombstones (user_id, entity_type, entity_id, plan_id)
+    VALUES (OLD.user_id, 'plan', OLD.id, OLD.id);
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+-- items removed along with their plan are not recorded, the tombstone of the plan covers them
+CREATE OR REPLACE FUNCTION record_checklist_item_tombstone()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO sync_tombstones (user_id, entity_type, entity_id, plan_id)
+    SELECT plans.user_id, 'checklistItem', OLD.id, OLD.plan_id
+    FROM plans
+    WHERE plans.id = OLD.plan_id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_plans_tombstone
+AFTER DELETE ON plans
+FOR EACH ROW
+EXECUTE FUNCTION record_plan_tombstone();
+
+CREATE TRIGGER record_checklist_items_tombstone
+AFTER DELETE ON checklist_items
+FOR EACH ROW
+EXECUTE FUNCTION record_checklist_item_tombstone();
@@ -0,0 +1,3 @@
+-- Migration: 000031_validate_user_timezones.down.sql
+DROP TRIGGER IF EXISTS validate_users_timezone ON users;
+DROP FUNCTION IF EXISTS validate_user_timezone();
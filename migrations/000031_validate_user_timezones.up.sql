@@ -0,0 +1,22 @@
+-- Migration: 000031_validate_user_timezones.up.sql
+-- timezones postgres does not know fail every query converting times into them
+UPDATE users
+SET timezone = 'UTC'
+WHERE timezone NOT IN (SELECT name FROM pg_timezone_names);
+
+-- check constraints cannot look up other relations, so timezones are checked by a trigger
+CREATE OR REPLACE FUNCTION validate_user_timezone()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_timezone_names WHERE name = NEW.timezone) THEN
+        RAISE EXCEPTION 'new row for relation "users" violates check constraint "check_valid_timezone"'
+            USING ERRCODE = 'check_violation', DETAIL = format('Unknown timezone %s.', NEW.timezone);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER validate_users_timezone
+BEFORE INSERT OR UPDATE OF timezone ON users
+FOR EACH ROW
+EXECUTE FUNCTION validate_user_timezone();
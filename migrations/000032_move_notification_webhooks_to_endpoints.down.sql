@@ -0,0 +1,14 @@
+-- Migration: 000032_move_notification_webhooks_to_endpoints.down.sql
+ALTER TABLE notification_preferences
+ADD COLUMN IF NOT EXISTS webhook_url TEXT;
+
+-- the endpoints stay, the url of one that receives notifications is put back
+UPDATE notification_preferences
+SET webhook_url = (
+    SELECT url
+    FROM webhook_endpoints
+    WHERE webhook_endpoints.user_id = notification_preferences.user_id
+    AND 'notification' = ANY(webhook_endpoints.event_types)
+    ORDER BY created_at
+    LIMIT 1
+);
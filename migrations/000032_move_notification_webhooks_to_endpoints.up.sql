@@ -0,0 +1,16 @@
+-- Migration: 000032_move_notification_webhooks_to_endpoints.up.sql
+-- notifications sent to the webhook channel are now delivered, signed, to the webhook
+-- endpoints of the user, so the urls from the preferences become endpoints that only
+-- receive notifications. Users obtain their secret by rotating it.
+INSERT INTO webhook_endpoints (user_id, url, description, secret, event_types)
+SELECT
+    user_id,
+    webhook_url,
+    'Notifications',
+    'whsec_' || replace(gen_random_uuid()::TEXT, '-', '') || replace(gen_random_uuid()::TEXT, '-', ''),
+    ARRAY['notification']
+FROM notification_preferences
+WHERE webhook_url IS NOT NULL;
+
+ALTER TABLE notification_preferences
+DROP COLUMN IF EXISTS webhook_url;